@@ -0,0 +1,400 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// AtomicExtension is the URI of the Atomic Operations extension.
+//
+// It is found in the ext parameter of the media type of requests and responses
+// that use the extension.
+const AtomicExtension = "https://jsonapi.org/ext/atomic"
+
+// An Operation is an operation of the Atomic Operations extension.
+//
+// Op is "add", "update", or "remove". Data is a Resource when the operation
+// targets a resource (a *SoftResource with only the fields found in the
+// payload for "update"), an Identifier, Identifiers, or nil when it targets a
+// relationship, and nil when a resource is removed.
+type Operation struct {
+	Op   string
+	Ref  OperationRef
+	Href string
+	Data interface{}
+	Meta Meta
+}
+
+// An OperationRef identifies the target of an operation.
+//
+// The resource is identified by ID or, if it is created by a previous operation
+// of the same request, by its local ID (LID). Relationship is set when the
+// operation targets a relationship of the resource.
+//
+// When an operation has no ref member, Type, ID, and LID are taken from the
+// resource found in its data.
+type OperationRef struct {
+	Type         string `json:"type"`
+	ID           string `json:"id,omitempty"`
+	LID          string `json:"lid,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+}
+
+// An OperationResult is the result of an operation.
+//
+// Data is the resource created or updated by the operation, if any.
+type OperationResult struct {
+	Data Resource
+	Meta Meta
+}
+
+// unmarshalOperations reads the atomic:operations member of a payload.
+//
+// A local ID can only be referenced by an operation if a previous operation
+// adds a resource with it.
+func unmarshalOperations(raws []operationSkeleton, schema *Schema) ([]Operation, error) {
+	ops := make([]Operation, 0, len(raws))
+	lids := map[string]string{}
+
+	for i, ske := range raws {
+		pointer := "/atomic:operations/" + strconv.Itoa(i)
+
+		op, err := unmarshalOperation(ske, schema, lids)
+		if err != nil {
+			if e, ok := err.(Error); ok {
+				if _, ok := e.Source["pointer"]; !ok {
+					e.Source["pointer"] = pointer
+				}
+
+				return nil, e
+			}
+
+			return nil, err
+		}
+
+		if op.Op == "add" && op.Ref.Relationship == "" && op.Ref.LID != "" {
+			if _, ok := lids[op.Ref.LID]; ok {
+				return nil, newErrInvalidOperation(
+					pointer+"/data/lid",
+					"The local ID "+strconv.Quote(op.Ref.LID)+" is used more than once.",
+				)
+			}
+
+			lids[op.Ref.LID] = op.Ref.Type
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// unmarshalOperation reads an operation. lids maps the local IDs defined by the
+// previous operations to their types.
+func unmarshalOperation(ske operationSkeleton, schema *Schema, lids map[string]string) (
+	Operation, error,
+) {
+	op := Operation{
+		Op:   ske.Op,
+		Href: ske.Href,
+		Meta: ske.Meta,
+	}
+
+	switch op.Op {
+	case "add", "update", "remove":
+	default:
+		return Operation{}, newErrInvalidOperation(
+			"",
+			"The op member must be \"add\", \"update\", or \"remove\".",
+		)
+	}
+
+	// Ref
+	hasData := len(ske.Data) > 0 && string(ske.Data) != "null"
+
+	if ske.Ref != nil {
+		op.Ref = *ske.Ref
+	} else if hasData && ske.Data[0] == '{' {
+		// The target is deduced from the resource.
+		var rske resourceSkeleton
+
+		_ = json.Unmarshal(ske.Data, &rske)
+		op.Ref = OperationRef{
+			Type: rske.Type,
+			ID:   rske.ID,
+			LID:  rske.LID,
+		}
+	}
+
+	typ := schema.GetType(op.Ref.Type)
+	if typ.Name == "" {
+		return Operation{}, newErrInvalidOperation(
+			"",
+			strconv.Quote(op.Ref.Type)+" is not a known type.",
+		)
+	}
+
+	if op.Ref.LID != "" && (op.Op != "add" || op.Ref.Relationship != "") {
+		if lids[op.Ref.LID] != op.Ref.Type {
+			return Operation{}, newErrUnknownLID(op.Ref.LID)
+		}
+	}
+
+	// Data
+	if op.Ref.Relationship != "" {
+		rel, ok := typ.Rels[op.Ref.Relationship]
+		if !ok {
+			return Operation{}, NewErrUnknownFieldInBody(typ.Name, op.Ref.Relationship)
+		}
+
+		if op.Ref.ID == "" && op.Ref.LID == "" {
+			return Operation{}, newErrInvalidOperation(
+				"",
+				"The resource of the relationship must be identified.",
+			)
+		}
+
+		if len(ske.Data) == 0 {
+			return Operation{}, NewErrMissingDataMember()
+		}
+
+		// To-one relationships can only be replaced by one
+		// identifier or null, while to-many relationships
+		// always receive a list of identifiers.
+		valid := hasData && ske.Data[0] == '['
+		if rel.ToOne {
+			valid = op.Op == "update" && (!hasData || ske.Data[0] == '{')
+		}
+
+		if !valid {
+			return Operation{}, newErrInvalidOperation(
+				"",
+				"The operation is not supported by the relationship.",
+			)
+		}
+
+		var err error
+
+		switch {
+		case !hasData:
+		case ske.Data[0] == '{':
+			op.Data, err = UnmarshalIdentifier(ske.Data, schema)
+		case ske.Data[0] == '[':
+			op.Data, err = UnmarshalIdentifiers(ske.Data, schema)
+		}
+
+		if err != nil {
+			return Operation{}, NewErrBadRequest("Invalid identifier", err.Error())
+		}
+
+		idens := Identifiers{}
+
+		switch d := op.Data.(type) {
+		case Identifier:
+			idens = append(idens, d)
+		case Identifiers:
+			idens = d
+		}
+
+		for _, iden := range idens {
+			if iden.Type != rel.ToType {
+				return Operation{}, newErrInvalidOperation(
+					"",
+					"The identifiers must be of type "+strconv.Quote(rel.ToType)+".",
+				)
+			}
+
+			if iden.ID == "" && lids[iden.LID] != iden.Type {
+				return Operation{}, newErrUnknownLID(iden.LID)
+			}
+		}
+
+		return op, nil
+	}
+
+	var err error
+
+	switch op.Op {
+	case "add":
+		if !hasData || ske.Data[0] != '{' {
+			return Operation{}, NewErrMissingDataMember()
+		}
+
+		op.Data, err = UnmarshalResource(ske.Data, schema)
+	case "update":
+		if !hasData || ske.Data[0] != '{' {
+			return Operation{}, NewErrMissingDataMember()
+		}
+
+		op.Data, err = UnmarshalPartialResource(ske.Data, schema)
+	case "remove":
+		if op.Ref.ID == "" && op.Ref.LID == "" {
+			return Operation{}, newErrInvalidOperation(
+				"",
+				"The resource to remove must be identified.",
+			)
+		}
+	}
+
+	if err != nil {
+		return Operation{}, err
+	}
+
+	if res, ok := op.Data.(Resource); ok {
+		if res.GetType().Name != op.Ref.Type || res.Get("id").(string) != op.Ref.ID {
+			return Operation{}, newErrInvalidOperation(
+				"",
+				"The resource does not match the ref member.",
+			)
+		}
+
+		if lh, ok := res.(LIDHolder); ok {
+			for _, rel := range res.Rels() {
+				for _, lid := range lh.RelLIDs(rel.FromName) {
+					if lids[lid] != rel.ToType {
+						return Operation{}, newErrUnknownLID(lid)
+					}
+				}
+			}
+		}
+	}
+
+	return op, nil
+}
+
+// marshalOperations returns the atomic:operations member of a payload.
+func marshalOperations(ops []Operation, prepath string) ([]json.RawMessage, error) {
+	raws := make([]json.RawMessage, 0, len(ops))
+
+	for _, op := range ops {
+		m := map[string]interface{}{
+			"op": op.Op,
+		}
+
+		if op.Href != "" {
+			m["href"] = op.Href
+		}
+
+		// The ref member is not needed when a resource is added
+		// since the resource itself is in the data member.
+		if op.Ref.Type != "" && (op.Op != "add" || op.Ref.Relationship != "") {
+			m["ref"] = op.Ref
+		}
+
+		switch d := op.Data.(type) {
+		case Resource:
+			pl, err := marshalFullResource(d, prepath)
+			if err != nil {
+				return nil, err
+			}
+
+			raw := json.RawMessage(pl)
+
+			if op.Ref.LID != "" && op.Ref.Relationship == "" {
+				res := map[string]json.RawMessage{}
+				_ = json.Unmarshal(raw, &res)
+
+				res["lid"], _ = json.Marshal(op.Ref.LID)
+				if d.Get("id").(string) == "" {
+					delete(res, "id")
+				}
+
+				raw, _ = json.Marshal(res)
+			}
+
+			m["data"] = raw
+		case Identifier, Identifiers:
+			m["data"] = d
+		case nil:
+			if op.Ref.Relationship != "" {
+				m["data"] = nil
+			}
+		}
+
+		if len(op.Meta) > 0 {
+			m["meta"] = op.Meta
+		}
+
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+		raws = append(raws, raw)
+	}
+
+	return raws, nil
+}
+
+// marshalResults returns the atomic:results member of a payload.
+func marshalResults(results []OperationResult, prepath string) ([]json.RawMessage, error) {
+	raws := make([]json.RawMessage, 0, len(results))
+
+	for _, result := range results {
+		m := map[string]interface{}{}
+
+		if result.Data != nil {
+			pl, err := marshalFullResource(result.Data, prepath)
+			if err != nil {
+				return nil, err
+			}
+
+			m["data"] = json.RawMessage(pl)
+		}
+
+		if len(result.Meta) > 0 {
+			m["meta"] = result.Meta
+		}
+
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+		raws = append(raws, raw)
+	}
+
+	return raws, nil
+}
+
+// marshalFullResource marshals res with all of its fields, including the data
+// of its relationships.
+func marshalFullResource(res Resource, prepath string) ([]byte, error) {
+	typ := res.GetType()
+
+	rels := make([]string, 0, len(res.Rels()))
+	for _, rel := range res.Rels() {
+		rels = append(rels, rel.FromName)
+	}
+
+	return marshalResource(
+		res,
+		prepath,
+		typ.Fields(),
+		map[string][]string{typ.Name: rels},
+	)
+}
+
+// newErrInvalidOperation returns a 400 Bad Request error about an invalid
+// operation.
+func newErrInvalidOperation(pointer, detail string) Error {
+	e := NewErrBadRequest("Invalid operation", detail)
+
+	if pointer != "" {
+		e.Source["pointer"] = pointer
+	}
+
+	return e
+}
+
+// newErrUnknownLID returns a 400 Bad Request error about a local ID that does
+// not identify any resource, like one not defined by a previous operation.
+func newErrUnknownLID(lid string) Error {
+	e := NewErrBadRequest(
+		"Unknown local ID",
+		"The local ID "+strconv.Quote(lid)+" does not identify any resource.",
+	)
+	e.Meta["unknown-lid"] = lid
+
+	return e
+}
@@ -0,0 +1,479 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalOperations(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	payload := `{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {
+					"type": "mocktypes1",
+					"lid": "a",
+					"attributes": {"str": "abc"}
+				}
+			}, {
+				"op": "add",
+				"data": {
+					"type": "mocktypes2",
+					"lid": "b"
+				},
+				"meta": {"key": "value"}
+			}, {
+				"op": "add",
+				"ref": {
+					"type": "mocktypes1",
+					"lid": "a",
+					"relationship": "to-many-from-many"
+				},
+				"data": [
+					{"type": "mocktypes2", "lid": "b"},
+					{"type": "mocktypes2", "id": "mt2-1"}
+				]
+			}, {
+				"op": "update",
+				"ref": {
+					"type": "mocktypes2",
+					"id": "mt2-1",
+					"relationship": "to-one-from-many"
+				},
+				"data": null
+			}, {
+				"op": "update",
+				"data": {
+					"type": "mocktypes1",
+					"id": "mt1-1",
+					"attributes": {"int": 3}
+				}
+			}, {
+				"op": "remove",
+				"ref": {"type": "mocktypes1", "id": "mt1-2"}
+			}
+		]
+	}`
+
+	doc, err := UnmarshalDocument([]byte(payload), schema)
+	assert.NoError(err)
+	assert.Nil(doc.Data)
+	assert.Len(doc.Operations, 6)
+
+	ops := doc.Operations
+
+	assert.Equal("add", ops[0].Op)
+	assert.Equal(OperationRef{Type: "mocktypes1", LID: "a"}, ops[0].Ref)
+	assert.Equal("abc", ops[0].Data.(Resource).Get("str"))
+
+	assert.Equal(Meta{"key": "value"}, ops[1].Meta)
+
+	assert.Equal(OperationRef{
+		Type:         "mocktypes1",
+		LID:          "a",
+		Relationship: "to-many-from-many",
+	}, ops[2].Ref)
+	assert.Equal(Identifiers{
+		{Type: "mocktypes2", LID: "b"},
+		{Type: "mocktypes2", ID: "mt2-1"},
+	}, ops[2].Data)
+
+	assert.Equal("to-one-from-many", ops[3].Ref.Relationship)
+	assert.Nil(ops[3].Data)
+
+	res := ops[4].Data.(*SoftResource)
+	assert.Equal("mt1-1", res.GetID())
+	assert.Equal([]string{"int"}, res.Type.Fields())
+
+	assert.Equal("remove", ops[5].Op)
+	assert.Equal(OperationRef{Type: "mocktypes1", ID: "mt1-2"}, ops[5].Ref)
+	assert.Nil(ops[5].Data)
+
+	// Invalid operations
+	tests := []struct {
+		name            string
+		operation       string
+		expectedDetail  string
+		expectedPointer string
+	}{
+		{
+			name:            "unknown op",
+			operation:       `{"op": "replace", "ref": {"type": "mocktypes1", "id": "a"}}`,
+			expectedDetail:  `The op member must be "add", "update", or "remove".`,
+			expectedPointer: "/atomic:operations/1",
+		}, {
+			name:           "unknown type",
+			operation:      `{"op": "remove", "ref": {"type": "unknown", "id": "a"}}`,
+			expectedDetail: `"unknown" is not a known type.`,
+		}, {
+			name:           "unknown local ID",
+			operation:      `{"op": "remove", "ref": {"type": "mocktypes1", "lid": "c"}}`,
+			expectedDetail: `The local ID "c" does not identify any resource.`,
+		}, {
+			name:           "local ID of another type",
+			operation:      `{"op": "remove", "ref": {"type": "mocktypes2", "lid": "a"}}`,
+			expectedDetail: `The local ID "a" does not identify any resource.`,
+		}, {
+			name:            "duplicate local ID",
+			operation:       `{"op": "add", "data": {"type": "mocktypes1", "lid": "a"}}`,
+			expectedDetail:  `The local ID "a" is used more than once.`,
+			expectedPointer: "/atomic:operations/1/data/lid",
+		}, {
+			name: "unknown local ID in identifiers",
+			operation: `{
+				"op": "add",
+				"ref": {"type": "mocktypes1", "id": "a", "relationship": "to-many"},
+				"data": [{"type": "mocktypes2", "lid": "c"}]
+			}`,
+			expectedDetail: `The local ID "c" does not identify any resource.`,
+		}, {
+			name: "identifiers of wrong type",
+			operation: `{
+				"op": "add",
+				"ref": {"type": "mocktypes1", "id": "a", "relationship": "to-many"},
+				"data": [{"type": "mocktypes1", "id": "b"}]
+			}`,
+			expectedDetail: `The identifiers must be of type "mocktypes2".`,
+		}, {
+			name: "add to to-one relationship",
+			operation: `{
+				"op": "add",
+				"ref": {"type": "mocktypes1", "id": "a", "relationship": "to-one"},
+				"data": {"type": "mocktypes2", "id": "b"}
+			}`,
+			expectedDetail: "The operation is not supported by the relationship.",
+		}, {
+			name: "update to-many relationship with one identifier",
+			operation: `{
+				"op": "update",
+				"ref": {"type": "mocktypes1", "id": "a", "relationship": "to-many"},
+				"data": {"type": "mocktypes2", "id": "b"}
+			}`,
+			expectedDetail: "The operation is not supported by the relationship.",
+		}, {
+			name: "relationship without resource",
+			operation: `{
+				"op": "update",
+				"ref": {"type": "mocktypes1", "relationship": "to-one"},
+				"data": null
+			}`,
+			expectedDetail: "The resource of the relationship must be identified.",
+		}, {
+			name:           "add without data",
+			operation:      `{"op": "add", "ref": {"type": "mocktypes1"}}`,
+			expectedDetail: "Missing data top-level member in payload.",
+		}, {
+			name:           "remove without ID",
+			operation:      `{"op": "remove", "ref": {"type": "mocktypes1"}}`,
+			expectedDetail: "The resource to remove must be identified.",
+		}, {
+			name: "resource not matching ref",
+			operation: `{
+				"op": "update",
+				"ref": {"type": "mocktypes1", "id": "a"},
+				"data": {"type": "mocktypes1", "id": "b"}
+			}`,
+			expectedDetail: "The resource does not match the ref member.",
+		},
+	}
+
+	for _, test := range tests {
+		payload := `{"atomic:operations": [
+			{"op": "add", "data": {"type": "mocktypes1", "lid": "a"}},
+			` + test.operation + `
+		]}`
+
+		_, err := UnmarshalDocument([]byte(payload), schema)
+
+		e, ok := err.(Error)
+		if assert.True(ok, test.name) {
+			assert.Equal("400", e.Status, test.name)
+			assert.Equal(test.expectedDetail, e.Detail, test.name)
+
+			if test.expectedPointer == "" {
+				test.expectedPointer = "/atomic:operations/1"
+			}
+
+			assert.Equal(test.expectedPointer, e.Source["pointer"], test.name)
+		}
+	}
+}
+
+func TestMarshalOperations(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	typ := schema.GetType("mocktypes3")
+	res := &SoftResource{}
+	res.SetType(&typ)
+	res.Set("attr1", "abc")
+	res.Set("rel2", []string{"mt1-1"})
+
+	doc := &Document{
+		Operations: []Operation{
+			{
+				Op:   "add",
+				Ref:  OperationRef{Type: "mocktypes3", LID: "a"},
+				Data: res,
+			}, {
+				Op: "update",
+				Ref: OperationRef{
+					Type:         "mocktypes3",
+					LID:          "a",
+					Relationship: "rel1",
+				},
+				Data: Identifier{Type: "mocktypes1", ID: "mt1-1"},
+			}, {
+				Op: "update",
+				Ref: OperationRef{
+					Type:         "mocktypes3",
+					ID:           "mt3-1",
+					Relationship: "rel1",
+				},
+			}, {
+				Op:   "remove",
+				Ref:  OperationRef{Type: "mocktypes3", ID: "mt3-2"},
+				Meta: Meta{"key": "value"},
+			},
+		},
+	}
+
+	payload, err := MarshalDocument(doc, nil)
+	assert.NoError(err)
+	assert.JSONEq(`{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {
+					"type": "mocktypes3",
+					"lid": "a",
+					"attributes": {"attr1": "abc", "attr2": 0},
+					"relationships": {
+						"rel1": {
+							"data": null,
+							"links": {
+								"related": "//rel1",
+								"self": "//relationships/rel1"
+							}
+						},
+						"rel2": {
+							"data": [{"id": "mt1-1", "type": "mocktypes1"}],
+							"links": {
+								"related": "//rel2",
+								"self": "//relationships/rel2"
+							}
+						}
+					},
+					"links": {"self": "/"}
+				}
+			}, {
+				"op": "update",
+				"ref": {"type": "mocktypes3", "lid": "a", "relationship": "rel1"},
+				"data": {"type": "mocktypes1", "id": "mt1-1"}
+			}, {
+				"op": "update",
+				"ref": {"type": "mocktypes3", "id": "mt3-1", "relationship": "rel1"},
+				"data": null
+			}, {
+				"op": "remove",
+				"ref": {"type": "mocktypes3", "id": "mt3-2"},
+				"meta": {"key": "value"}
+			}
+		],
+		"jsonapi": {"version": "1.0"}
+	}`, string(payload))
+
+	// Round trip
+	doc2, err := UnmarshalDocument(payload, schema)
+	assert.NoError(err)
+	assert.Len(doc2.Operations, 4)
+	assert.Equal(doc.Operations[0].Ref, doc2.Operations[0].Ref)
+	assert.Equal(doc.Operations[1].Data, doc2.Operations[1].Data)
+
+	// Results
+	res.SetID("mt3-1")
+
+	doc = &Document{
+		Results: []OperationResult{
+			{Data: res},
+			{},
+			{Meta: Meta{"key": "value"}},
+		},
+	}
+
+	payload, err = MarshalDocument(doc, nil)
+	assert.NoError(err)
+
+	ske := struct {
+		Results []map[string]json.RawMessage `json:"atomic:results"`
+	}{}
+	assert.NoError(json.Unmarshal(payload, &ske))
+	assert.Len(ske.Results, 3)
+	assert.Contains(string(ske.Results[0]["data"]), `"id":"mt3-1"`)
+	assert.Empty(ske.Results[1])
+	assert.JSONEq(`{"key": "value"}`, string(ske.Results[2]["meta"]))
+
+	doc2, err = UnmarshalDocument(payload, schema)
+	assert.NoError(err)
+	assert.Len(doc2.Results, 3)
+	assert.Equal("abc", doc2.Results[0].Data.Get("attr1"))
+	assert.Nil(doc2.Results[1].Data)
+}
+
+func TestHandlerOperations(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+	handler := NewHandler(schema, store)
+
+	mt2 := &SoftResource{}
+	typ := schema.GetType("mocktypes2")
+	mt2.SetType(&typ)
+	mt2.SetID("mt2-1")
+	_, _ = store.Create(mt2)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/operations", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", MediaType+`; ext="`+AtomicExtension+`"`)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := send(`{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {"type": "mocktypes1", "lid": "a", "attributes": {"str": "abc"}}
+			}, {
+				"op": "add",
+				"ref": {"type": "mocktypes1", "lid": "a", "relationship": "to-many-from-many"},
+				"data": [{"type": "mocktypes2", "id": "mt2-1"}]
+			}, {
+				"op": "update",
+				"ref": {"type": "mocktypes2", "id": "mt2-1", "relationship": "to-one-from-one"},
+				"data": {"type": "mocktypes1", "lid": "a"}
+			}
+		]
+	}`)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(MediaType+`; ext="`+AtomicExtension+`"`, rec.Header().Get("Content-Type"))
+
+	ske := struct {
+		Results []map[string]json.RawMessage `json:"atomic:results"`
+	}{}
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &ske))
+	assert.Len(ske.Results, 3)
+
+	created, err := UnmarshalResource(ske.Results[0]["data"], schema)
+	assert.NoError(err)
+
+	id := created.Get("id").(string)
+	assert.NotEmpty(id)
+
+	res, _ := store.Resource("mocktypes2", "mt2-1")
+	assert.Equal([]string{id}, res.Get("to-many-from-many"))
+	assert.Equal(id, res.Get("to-one-from-one"))
+
+	// Local IDs in relationships of resources
+	rec = send(`{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {"type": "mocktypes2", "lid": "b"}
+			}, {
+				"op": "add",
+				"data": {
+					"type": "mocktypes1",
+					"relationships": {
+						"to-one": {"data": {"type": "mocktypes2", "lid": "b"}},
+						"to-many": {
+							"data": [
+								{"type": "mocktypes2", "id": "mt2-1"},
+								{"type": "mocktypes2", "lid": "b"}
+							]
+						}
+					}
+				}
+			}
+		]
+	}`)
+	assert.Equal(http.StatusOK, rec.Code)
+
+	ske.Results = nil
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &ske))
+	assert.Len(ske.Results, 2)
+
+	created2, _ := UnmarshalResource(ske.Results[0]["data"], schema)
+	created1, _ := UnmarshalResource(ske.Results[1]["data"], schema)
+
+	id2 := created2.Get("id").(string)
+	assert.Equal(id2, created1.Get("to-one"))
+	assert.ElementsMatch([]string{"mt2-1", id2}, created1.Get("to-many"))
+
+	rec = send(`{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {
+					"type": "mocktypes1",
+					"relationships": {
+						"to-one": {"data": {"type": "mocktypes2", "lid": "b"}}
+					}
+				}
+			}
+		]
+	}`)
+	assert.Equal(http.StatusBadRequest, rec.Code)
+
+	// Nothing is applied if an operation fails.
+	rec = send(`{
+		"atomic:operations": [
+			{
+				"op": "add",
+				"data": {"type": "mocktypes1", "id": "mt1-new"}
+			}, {
+				"op": "remove",
+				"ref": {"type": "mocktypes1", "id": "unknown"}
+			}
+		]
+	}`)
+	assert.Equal(http.StatusNotFound, rec.Code)
+
+	res, _ = store.Resource("mocktypes1", "mt1-new")
+	assert.Nil(res)
+
+	// Stores that are not atomic
+	handler.Store = &mockStore{}
+	rec = send(`{
+		"atomic:operations": [{"op": "remove", "ref": {"type": "mocktypes1", "id": "a"}}]
+	}`)
+	assert.Equal(http.StatusNotImplemented, rec.Code)
+
+	// Wrong method
+	req := httptest.NewRequest("PATCH", "/operations", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", MediaType+`; ext="`+AtomicExtension+`"`)
+	_, err = NewRequest(req, schema)
+	assert.Equal(NewErrMethodNotAllowed(), err)
+
+	// Missing operations
+	req = httptest.NewRequest("POST", "/operations", bytes.NewBufferString(`{"data": null}`))
+	req.Header.Set("Content-Type", MediaType+`; ext="`+AtomicExtension+`"`)
+	_, err = NewRequest(req, schema)
+	assert.Error(err)
+}
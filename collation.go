@@ -0,0 +1,220 @@
+package jsonapi
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Collations define how strings are compared by filters (see Filter.Col) and
+// when sorting (see RangeOptions.Collation).
+//
+// CollationBinary compares the bytes of the strings and is the default.
+// CollationCaseInsensitive ignores the case of the letters and
+// CollationAccentInsensitive ignores their accents. CollationInsensitive
+// ignores both.
+//
+// CollationUnicode sorts strings the way people usually expect: the letters
+// are compared without their case and accents first, then with their accents,
+// and then with their case. Two strings are equal only if they are identical.
+//
+// Accents are removed from the Latin letters of the Latin-1 Supplement and
+// Latin Extended-A blocks, and combining marks are ignored.
+const (
+	CollationBinary            = ""
+	CollationCaseInsensitive   = "ci"
+	CollationAccentInsensitive = "ai"
+	CollationInsensitive       = "ci_ai"
+	CollationUnicode           = "unicode"
+)
+
+// isCollation reports whether col is the name of a known collation.
+func isCollation(col string) bool {
+	switch col {
+	case CollationBinary, CollationCaseInsensitive, CollationAccentInsensitive,
+		CollationInsensitive, CollationUnicode:
+		return true
+	default:
+		return false
+	}
+}
+
+// compareStrings compares a and b under the collation col and returns -1 if a
+// comes before b, 0 if they are equal, and +1 otherwise.
+func compareStrings(col, a, b string) int {
+	if col == CollationUnicode {
+		if c := strings.Compare(collationKey(CollationInsensitive, a),
+			collationKey(CollationInsensitive, b)); c != 0 {
+			return c
+		}
+
+		if c := strings.Compare(collationKey(CollationCaseInsensitive, a),
+			collationKey(CollationCaseInsensitive, b)); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+	}
+
+	return strings.Compare(collationKey(col, a), collationKey(col, b))
+}
+
+// collationKey returns s without the differences that the collation col
+// ignores. Two strings that are equal under col have the same key.
+func collationKey(col, s string) string {
+	switch col {
+	case CollationCaseInsensitive:
+		return strings.Map(foldRune, s)
+	case CollationAccentInsensitive:
+		return strings.Map(unaccentRune, s)
+	case CollationInsensitive:
+		return strings.Map(func(r rune) rune {
+			if r = unaccentRune(r); r < 0 {
+				return r
+			}
+
+			return foldRune(r)
+		}, s)
+	default:
+		return s
+	}
+}
+
+// checkText reports whether rval satisfies the text operator op (contains,
+// starts-with, ends-with, like, or regex) with cval under the collation col.
+func checkText(op, col, rval, cval string) bool {
+	return compileText(op, col, cval)(rval)
+}
+
+// compileText returns a function that reports whether a string satisfies the
+// text operator op with cval under the collation col.
+func compileText(op, col, cval string) func(string) bool {
+	if op == "regex" {
+		if col == CollationCaseInsensitive || col == CollationInsensitive {
+			cval = "(?i)" + cval
+		}
+
+		re, err := regexp.Compile(cval)
+		if err != nil {
+			return func(string) bool { return false }
+		}
+
+		return func(s string) bool {
+			return re.MatchString(collationKey(col, s))
+		}
+	}
+
+	cval = collationKey(col, cval)
+
+	switch op {
+	case "contains":
+		return func(s string) bool {
+			return strings.Contains(collationKey(col, s), cval)
+		}
+	case "starts-with":
+		return func(s string) bool {
+			return strings.HasPrefix(collationKey(col, s), cval)
+		}
+	case "ends-with":
+		return func(s string) bool {
+			return strings.HasSuffix(collationKey(col, s), cval)
+		}
+	case "like":
+		re := likeRegexp(cval)
+
+		return func(s string) bool {
+			return re.MatchString(collationKey(col, s))
+		}
+	default:
+		return func(string) bool { return false }
+	}
+}
+
+// isTextOp reports whether op is an operator that only applies to strings.
+func isTextOp(op string) bool {
+	switch op {
+	case "contains", "starts-with", "ends-with", "like", "regex":
+		return true
+	default:
+		return false
+	}
+}
+
+// likeRegexp returns a regular expression equivalent to pattern, a pattern of
+// the SQL LIKE operator.
+//
+// A percent sign matches any sequence of characters and an underscore matches
+// exactly one character. A backslash escapes the character that follows it.
+func likeRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+
+	b.WriteString("(?s)^")
+
+	escaped := false
+
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
+
+// foldRune returns the lower case version of r, including for letters whose
+// upper case version has many lower case versions (like the Greek sigma).
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// unaccentRune returns r without its accent, or -1 if r is a combining mark.
+func unaccentRune(r rune) rune {
+	switch {
+	case r >= 0xC0 && r < 0x100:
+		if b := latin1Supplement[r-0xC0]; b != '.' {
+			return rune(b)
+		}
+	case r >= 0x100 && r < 0x180:
+		if b := latinExtendedA[r-0x100]; b != '.' {
+			return rune(b)
+		}
+	case unicode.Is(unicode.Mn, r):
+		return -1
+	}
+
+	return r
+}
+
+// The following tables hold the base letters of the letters of the Latin-1
+// Supplement block (from U+00C0) and the Latin Extended-A block. A dot means
+// that the character is kept as is.
+const (
+	latin1Supplement = "" +
+		"AAAAAA.CEEEEIIII" +
+		"DNOOOOO.OUUUUY.." +
+		"aaaaaa.ceeeeiiii" +
+		"dnooooo.ouuuuy.y"
+
+	latinExtendedA = "" +
+		"AaAaAaCcCcCcCcDd" +
+		"DdEeEeEeEeEeGgGg" +
+		"GgGgHhHhIiIiIiIi" +
+		"Ii..JjKk.LlLlLlL" +
+		"lLlNnNnNn...OoOo" +
+		"Oo..RrRrRrSsSsSs" +
+		"SsTtTtTtUuUuUuUu" +
+		"UuUuWwYyYZzZzZz."
+)
@@ -0,0 +1,190 @@
+package jsonapi_test
+
+import (
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterTextOperators(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := &Type{Name: "words"}
+	_ = typ.AddAttr(Attr{Name: "str", Type: AttrTypeString})
+	_ = typ.AddAttr(Attr{Name: "strptr", Type: AttrTypeString, Nullable: true})
+
+	res := &SoftResource{Type: typ}
+	res.Set("str", "Crème Brûlée")
+
+	tests := []struct {
+		op, col, val string
+		expected     bool
+	}{
+		// Binary
+		{op: "=", val: "Crème Brûlée", expected: true},
+		{op: "=", val: "crème brûlée", expected: false},
+		{op: "<", val: "crème", expected: true},
+		{op: "contains", val: "me Br", expected: true},
+		{op: "contains", val: "brûlée", expected: false},
+		{op: "starts-with", val: "Crè", expected: true},
+		{op: "starts-with", val: "Cre", expected: false},
+		{op: "ends-with", val: "lée", expected: true},
+		{op: "like", val: "Cr_me%", expected: true},
+		{op: "like", val: "%Br_l_e", expected: true},
+		{op: "like", val: "Cr%e", expected: true},
+		{op: "like", val: "Cr%", expected: true},
+		{op: "like", val: "Crème", expected: false},
+		{op: "like", val: `Crème\%`, expected: false},
+		{op: "regex", val: `^Cr.me\s+B`, expected: true},
+		{op: "regex", val: `^crème`, expected: false},
+
+		// Case-insensitive
+		{op: "=", col: "ci", val: "CRÈME BRÛLÉE", expected: true},
+		{op: "=", col: "ci", val: "creme brulee", expected: false},
+		{op: ">", col: "ci", val: "CRÈME", expected: true},
+		{op: "contains", col: "ci", val: "BRÛ", expected: true},
+		{op: "like", col: "ci", val: "c%E", expected: true},
+		{op: "regex", col: "ci", val: `^crème`, expected: true},
+
+		// Accent-insensitive
+		{op: "=", col: "ai", val: "Creme Brulee", expected: true},
+		{op: "=", col: "ai", val: "creme brulee", expected: false},
+		{op: "starts-with", col: "ai", val: "Cre", expected: true},
+		{op: "ends-with", col: "ai", val: "lee", expected: true},
+
+		// Case and accent insensitive
+		{op: "=", col: "ci_ai", val: "CREME BRULEE", expected: true},
+		{op: "!=", col: "ci_ai", val: "crEme brulee", expected: false},
+		{op: "like", col: "ci_ai", val: "creme%", expected: true},
+		{op: "regex", col: "ci_ai", val: `^CREME`, expected: true},
+
+		// Unicode
+		{op: "=", col: "unicode", val: "crème brûlée", expected: false},
+		{op: "<", col: "unicode", val: "Creme", expected: false},
+		{op: "<", col: "unicode", val: "crèmf", expected: true},
+		{op: ">", col: "unicode", val: "Crème Brûlé", expected: true},
+		{op: "contains", col: "unicode", val: "me Br", expected: true},
+	}
+
+	for _, test := range tests {
+		filter := &Filter{Field: "str", Op: test.op, Val: test.val, Col: test.col}
+		assert.Equal(
+			test.expected,
+			filter.IsAllowed(res),
+			"%s %s %q", test.op, test.col, test.val,
+		)
+	}
+
+	// Nullable strings
+	filter := &Filter{Field: "strptr", Op: "contains", Val: "a"}
+	assert.False(filter.IsAllowed(res))
+
+	res.Set("strptr", ptr("abc"))
+	assert.True(filter.IsAllowed(res))
+
+	// Combining marks
+	res.Set("str", "Crème")
+	filter = &Filter{Field: "str", Op: "=", Val: "creme", Col: "ci_ai"}
+	assert.True(filter.IsAllowed(res))
+}
+
+func TestFilterTextValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	tests := []struct {
+		filter        Filter
+		expectedError error
+	}{
+		{
+			filter: Filter{Field: "str", Op: "like", Val: "a%", Col: "ci"},
+		}, {
+			filter: Filter{Field: "strptr", Op: "contains", Val: "a"},
+		}, {
+			filter:        Filter{Field: "str", Op: "=", Val: "a", Col: "unknown"},
+			expectedError: NewErrUnknownCollationInFilterParameter("unknown"),
+		}, {
+			filter:        Filter{Field: "int", Op: "contains", Val: "1"},
+			expectedError: NewErrUnknownOperatorInFilterParameter("contains"),
+		}, {
+			filter:        Filter{Field: "str", Op: "regex", Val: "(a"},
+			expectedError: NewErrInvalidValueInFilterParameter(`"(a"`, "regex"),
+		}, {
+			filter:        Filter{Field: "str", Op: "starts-with", Val: 1},
+			expectedError: NewErrInvalidValueInFilterParameter("1", "string"),
+		},
+	}
+
+	for _, test := range tests {
+		typ := "mocktypes1"
+		if test.filter.Field == "strptr" {
+			typ = "mocktypes2"
+		}
+
+		assert.Equal(test.expectedError, test.filter.Validate(schema, typ), test.filter.Op)
+	}
+
+	// The value of a text operator on a nullable string is not a pointer.
+	filter := &Filter{Field: "strptr", Op: "ends-with", Val: "a"}
+	coerced, err := filter.Coerce(schema, "mocktypes2")
+	assert.NoError(err)
+	assert.Equal("a", coerced.Val)
+}
+
+func TestRangeCollation(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := &Type{Name: "words"}
+	_ = typ.AddAttr(Attr{Name: "word", Type: AttrTypeString})
+
+	col := Resources{}
+
+	for i, word := range []string{"b", "É", "a", "e", "B", "é", "E", "f"} {
+		res := &SoftResource{Type: typ}
+		res.SetID(string(rune('0' + i)))
+		res.Set("word", word)
+		col = append(col, res)
+	}
+
+	words := func(c Collection) []string {
+		words := make([]string, c.Len())
+		for i := range words {
+			words[i] = c.At(i).Get("word").(string)
+		}
+
+		return words
+	}
+
+	tests := []struct {
+		collation string
+		expected  []string
+	}{
+		{
+			collation: CollationBinary,
+			expected:  []string{"B", "E", "a", "b", "e", "f", "É", "é"},
+		}, {
+			// Ties are broken by ID.
+			collation: CollationCaseInsensitive,
+			expected:  []string{"a", "b", "B", "e", "E", "f", "É", "é"},
+		}, {
+			collation: CollationInsensitive,
+			expected:  []string{"a", "b", "B", "É", "e", "é", "E", "f"},
+		}, {
+			collation: CollationUnicode,
+			expected:  []string{"a", "B", "b", "E", "e", "É", "é", "f"},
+		},
+	}
+
+	for _, test := range tests {
+		res, err := RangeWith(&col, RangeOptions{
+			Sort:      []string{"word"},
+			PageSize:  10,
+			Collation: test.collation,
+		})
+		assert.NoError(err)
+		assert.Equal(test.expected, words(res.Page), test.collation)
+	}
+}
@@ -19,25 +19,39 @@ type Collection interface {
 }
 
 // MarshalCollection marshals a Collection into a JSON-encoded payload.
+//
+// nil is returned if one of the resources cannot be marshaled (see
+// MarshalResource).
 func MarshalCollection(c Collection, prepath string, fields map[string][]string, relData map[string][]string) []byte {
+	pl, _ := marshalCollection(c, prepath, fields, relData)
+
+	return pl
+}
+
+// marshalCollection marshals c like MarshalCollection does, but returns the
+// error.
+func marshalCollection(
+	c Collection, prepath string, fields map[string][]string, relData map[string][]string,
+) ([]byte, error) {
 	var raws []*json.RawMessage
 
 	if c.Len() == 0 {
-		return []byte("[]")
+		return []byte("[]"), nil
 	}
 
 	for i := 0; i < c.Len(); i++ {
 		r := c.At(i)
-		raw := json.RawMessage(
-			MarshalResource(r, prepath, fields[r.GetType().Name], relData),
-		)
+
+		pl, err := marshalResource(r, prepath, fields[r.GetType().Name], relData)
+		if err != nil {
+			return nil, err
+		}
+
+		raw := json.RawMessage(pl)
 		raws = append(raws, &raw)
 	}
 
-	// NOTE An error should not happen.
-	pl, _ := json.Marshal(raws)
-
-	return pl
+	return json.Marshal(raws)
 }
 
 // UnmarshalCollection unmarshals a JSON-encoded payload into a Collection.
@@ -0,0 +1,110 @@
+package jsonapi
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// decimalRegexp matches the JSON representation of a number. The exponent is
+// limited to four digits to keep the exact values reasonably small.
+var decimalRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]{1,4})?$`)
+
+// A Decimal is a decimal number stored without any loss of precision, unlike
+// float32 and float64.
+//
+// It is represented as a JSON number with all of its digits. The zero value is
+// 0.
+//
+// Two decimals can represent the same number with different digits (1.5 and
+// 1.50), so Cmp should be used to compare them instead of ==.
+type Decimal struct {
+	s string
+}
+
+// ParseDecimal parses s, a number in the JSON format, and returns the Decimal
+// it represents.
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalRegexp.MatchString(s) {
+		return Decimal{}, errors.New("jsonapi: invalid decimal " + strconv.Quote(s))
+	}
+
+	return Decimal{s: s}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on error.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// String returns the representation of the decimal with all of its digits.
+func (d Decimal) String() string {
+	if d.s == "" {
+		return "0"
+	}
+
+	return d.s
+}
+
+// Rat returns the exact value of the decimal.
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// Float64 returns the nearest float64 value of the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares d and d2 and returns -1 if d < d2, 0 if d == d2, and +1 if d >
+// d2.
+func (d Decimal) Cmp(d2 Decimal) int {
+	if d.s == d2.s {
+		return 0
+	}
+
+	return d.Rat().Cmp(d2.Rat())
+}
+
+// MarshalJSON returns the decimal as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON parses a decimal from a JSON number or a JSON string that holds
+// a number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+
+	if len(data) > 0 && data[0] == '"' {
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return err
+		}
+	}
+
+	dec, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+
+	*d = dec
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface. The decimal is passed to the
+// database as a string to preserve its precision.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
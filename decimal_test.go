@@ -0,0 +1,58 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimal(t *testing.T) {
+	assert := assert.New(t)
+
+	// Parsing
+	for _, s := range []string{"0", "-1", "1.50", "12345678901234567890.1", "1e10", "2.5E-3"} {
+		d, err := ParseDecimal(s)
+		assert.NoError(err)
+		assert.Equal(s, d.String())
+	}
+
+	for _, s := range []string{"", "abc", "01", "1.", ".5", "+1", "1e12345", "1 "} {
+		_, err := ParseDecimal(s)
+		assert.Error(err)
+	}
+
+	assert.Panics(func() { _ = MustParseDecimal("abc") })
+
+	// Zero value
+	assert.Equal("0", Decimal{}.String())
+	assert.Equal(0, Decimal{}.Cmp(MustParseDecimal("0.00")))
+
+	// Comparison
+	assert.Equal(0, MustParseDecimal("1.5").Cmp(MustParseDecimal("1.50")))
+	assert.Equal(0, MustParseDecimal("1.5").Cmp(MustParseDecimal("15e-1")))
+	assert.Equal(1, MustParseDecimal("0.3").Cmp(MustParseDecimal("0.1")))
+	assert.Equal(-1, MustParseDecimal("-2").Cmp(MustParseDecimal("1e-4")))
+	assert.Equal(0.25, MustParseDecimal("2.5e-1").Float64())
+
+	// JSON
+	payload, err := json.Marshal(MustParseDecimal("12345678901234567890.123"))
+	assert.NoError(err)
+	assert.Equal(`12345678901234567890.123`, string(payload))
+
+	var d Decimal
+
+	assert.NoError(json.Unmarshal([]byte(`1.25`), &d))
+	assert.Equal("1.25", d.String())
+	assert.NoError(json.Unmarshal([]byte(`"3.75"`), &d))
+	assert.Equal("3.75", d.String())
+	assert.Error(json.Unmarshal([]byte(`"abc"`), &d))
+	assert.Error(json.Unmarshal([]byte(`true`), &d))
+
+	// Database value
+	v, err := MustParseDecimal("1.10").Value()
+	assert.NoError(err)
+	assert.Equal("1.10", v)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"sort"
+	"strconv"
 )
 
 // A Document represents a JSON:API document.
@@ -24,11 +25,42 @@ type Document struct {
 	// Top-level members
 	Meta Meta
 
+	// Pagination
+	//
+	// Total is the number of resources in the whole collection, if
+	// known. Otherwise, HasMore reports whether there are more
+	// resources after the current page. With cursor pagination,
+	// HasMore and HasPrev report whether there are resources after
+	// and before the current page.
+	Total   *uint
+	HasMore bool
+	HasPrev bool
+
 	// Errors
 	Errors []Error
 
+	// Atomic Operations extension
+	//
+	// Operations and Results hold the atomic:operations and
+	// atomic:results members of documents that use the extension
+	// (see AtomicExtension). Such documents have no data member.
+	Operations []Operation
+	Results    []OperationResult
+
 	// Internal
 	PrePath string
+
+	// types holds the types found in the payload the document was
+	// unmarshaled from, which are lost once the resources are made.
+	types []payloadType
+}
+
+// A payloadType is the type of a resource or of a resource linkage found in a
+// payload. rel is empty for a resource.
+type payloadType struct {
+	typ     string
+	rel     string
+	pointer string
 }
 
 // Include adds res to the set of resources to be included under the included
@@ -73,9 +105,301 @@ func (d *Document) Include(res Resource) {
 	d.Included = append(d.Included, res)
 }
 
+// ResolveLIDs replaces the local IDs referenced by the document by the IDs of
+// the resources they identify, usually once the server has created them.
+//
+// ids maps local IDs to IDs and can be nil. The resources found in the main
+// data, the included resources, and the resources added by operations that
+// have both a local ID and an ID complete the mapping.
+//
+// The local IDs are replaced in the relationships of those resources, in
+// identifiers, and in the targets of operations. An error is returned if a
+// local ID cannot be resolved, but all the others are still replaced.
+func (d *Document) ResolveLIDs(ids map[string]string) error {
+	resources := []Resource{}
+
+	switch data := d.Data.(type) {
+	case Resource:
+		resources = append(resources, data)
+	case Collection:
+		for i := 0; i < data.Len(); i++ {
+			resources = append(resources, data.At(i))
+		}
+	}
+
+	resources = append(resources, d.Included...)
+
+	for _, op := range d.Operations {
+		if res, ok := op.Data.(Resource); ok {
+			resources = append(resources, res)
+		}
+	}
+
+	// Mapping
+	lids := map[string]string{}
+
+	for lid, id := range ids {
+		lids[lid] = id
+	}
+
+	for _, res := range resources {
+		if lh, ok := res.(LIDHolder); ok && lh.LID() != "" {
+			if id := res.Get("id").(string); id != "" {
+				lids[lh.LID()] = id
+			}
+		}
+	}
+
+	var err error
+
+	keepErr := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+
+	resolve := func(iden *Identifier) {
+		if iden.ID != "" || iden.LID == "" {
+			return
+		}
+
+		if id, ok := lids[iden.LID]; ok {
+			iden.ID = id
+		} else {
+			keepErr(newErrUnknownLID(iden.LID))
+		}
+	}
+
+	// Resources
+	for _, res := range resources {
+		keepErr(resolveRelLIDs(res, lids))
+	}
+
+	// Identifiers
+	switch data := d.Data.(type) {
+	case Identifier:
+		resolve(&data)
+		d.Data = data
+	case Identifiers:
+		for i := range data {
+			resolve(&data[i])
+		}
+	}
+
+	// Operations
+	for i := range d.Operations {
+		op := &d.Operations[i]
+
+		iden := Identifier{ID: op.Ref.ID, LID: op.Ref.LID}
+		resolve(&iden)
+		op.Ref.ID = iden.ID
+
+		switch data := op.Data.(type) {
+		case Identifier:
+			resolve(&data)
+			op.Data = data
+		case Identifiers:
+			for i := range data {
+				resolve(&data[i])
+			}
+		}
+	}
+
+	return err
+}
+
+// Validate checks the resources of a compound document and returns an error
+// for each problem found.
+//
+// Every included resource must be reachable from the primary data through the
+// relationships of the resources of the document, which the specification
+// calls full linkage. A type and ID pair cannot appear more than once among the
+// primary and included resources, and neither the resources nor the data of
+// their relationships can be of types that schema does not define. For a
+// document returned by UnmarshalDocument, the types are the ones found in the
+// payload.
+//
+// Only the relationships listed in RelData carry data, like in the payload made
+// by MarshalDocument, so only those are followed. When a resource of the
+// document has a relationship without data, for example because of sparse
+// fieldsets, the included resources of the type it points to could be linked
+// through it, so they are never reported as orphans. The source pointers of
+// the errors locate the resources by their position in Data and Included.
+//
+// Validate is not called by UnmarshalDocument.
+func (d *Document) Validate(schema *Schema) []Error {
+	type located struct {
+		res     Resource
+		pointer string
+	}
+
+	resources := []located{}
+	roots := []string{}
+
+	switch data := d.Data.(type) {
+	case Resource:
+		resources = append(resources, located{res: data, pointer: "/data"})
+	case Collection:
+		for i := 0; i < data.Len(); i++ {
+			resources = append(resources, located{
+				res:     data.At(i),
+				pointer: "/data/" + strconv.Itoa(i),
+			})
+		}
+	case Identifier:
+		roots = append(roots, data.ID+" "+data.Type)
+	case Identifiers:
+		for _, iden := range data {
+			roots = append(roots, iden.ID+" "+iden.Type)
+		}
+	}
+
+	primary := len(resources)
+
+	for i, res := range d.Included {
+		resources = append(resources, located{
+			res:     res,
+			pointer: "/included/" + strconv.Itoa(i),
+		})
+	}
+
+	errs := []Error{}
+	byKey := map[string]Resource{}
+	unique := make([]bool, len(resources))
+	omitted := map[string]bool{}
+
+	for _, pt := range d.types {
+		if schema.HasType(pt.typ) {
+			continue
+		}
+
+		if pt.rel == "" {
+			errs = append(errs, NewErrUnknownTypeInDocument(pt.typ, pt.pointer))
+		} else {
+			errs = append(errs, NewErrUnknownTypeInRelationship(pt.rel, pt.typ, pt.pointer))
+		}
+	}
+
+	for i, loc := range resources {
+		key := resourceKey(loc.res)
+		typ := loc.res.GetType()
+
+		if !schema.HasType(typ.Name) {
+			// The types of a payload were already checked.
+			if d.types == nil {
+				errs = append(errs, NewErrUnknownTypeInDocument(typ.Name, loc.pointer+"/type"))
+			}
+
+			continue
+		}
+
+		if _, ok := byKey[key]; ok {
+			errs = append(errs, NewErrDuplicateResource(
+				typ.Name, loc.res.Get("id").(string), loc.pointer,
+			))
+
+			continue
+		}
+
+		byKey[key] = loc.res
+		unique[i] = true
+
+		if i < primary {
+			roots = append(roots, key)
+		}
+
+		for _, name := range typ.Fields() {
+			rel, ok := typ.Rels[name]
+			if !ok {
+				continue
+			}
+
+			if !hasRelData(d.RelData, typ.Name, name) {
+				omitted[rel.ToType] = true
+				continue
+			}
+
+			if len(relIDs(loc.res, name)) == 0 {
+				continue
+			}
+
+			if !schema.HasType(rel.ToType) {
+				errs = append(errs, NewErrUnknownTypeInRelationship(
+					name, rel.ToType, loc.pointer+"/relationships/"+name+"/data",
+				))
+			}
+		}
+	}
+
+	// Linkage
+	reached := map[string]bool{}
+
+	for len(roots) > 0 {
+		key := roots[len(roots)-1]
+		roots = roots[:len(roots)-1]
+
+		res, ok := byKey[key]
+		if !ok || reached[key] {
+			continue
+		}
+
+		reached[key] = true
+		typ := res.GetType()
+
+		for name, rel := range typ.Rels {
+			if !hasRelData(d.RelData, typ.Name, name) {
+				continue
+			}
+
+			for _, id := range relIDs(res, name) {
+				roots = append(roots, id+" "+rel.ToType)
+			}
+		}
+	}
+
+	for i := primary; i < len(resources); i++ {
+		loc := resources[i]
+
+		typ := loc.res.GetType().Name
+
+		if unique[i] && !reached[resourceKey(loc.res)] && !omitted[typ] {
+			errs = append(errs, NewErrOrphanIncludedResource(
+				typ, loc.res.Get("id").(string), loc.pointer,
+			))
+		}
+	}
+
+	return errs
+}
+
+// hasRelData reports whether relData says that the data of the relationship rel
+// of type typ is part of the payload.
+func hasRelData(relData map[string][]string, typ, rel string) bool {
+	for _, name := range relData[typ] {
+		if name == rel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addRelData adds the relationship rel of type typ to relData unless it is
+// already there.
+func addRelData(relData map[string][]string, typ, rel string) {
+	if !hasRelData(relData, typ, rel) {
+		relData[typ] = append(relData[typ], rel)
+	}
+}
+
 // MarshalDocument marshals a document according to the JSON:API speficication.
 //
 // Both doc and url must not be nil.
+//
+// If url points to a collection and defines a page size, the first, prev, next,
+// and last links are added based on doc.Total or doc.HasMore. With cursor
+// pagination, the prev and next links hold cursors built from the first and
+// last resources of the page.
 func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 	var err error
 
@@ -83,14 +407,14 @@ func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 	var data json.RawMessage
 	switch d := doc.Data.(type) {
 	case Resource:
-		data = MarshalResource(
+		data, err = marshalResource(
 			d,
 			doc.PrePath,
 			url.Params.Fields[d.GetType().Name],
 			doc.RelData,
 		)
 	case Collection:
-		data = MarshalCollection(
+		data, err = marshalCollection(
 			d,
 			doc.PrePath,
 			url.Params.Fields,
@@ -123,19 +447,20 @@ func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 	var inclusions []*json.RawMessage
 
 	if len(doc.Included) > 0 {
-		sort.Slice(doc.Included, func(i, j int) bool {
-			return doc.Included[i].Get("id").(string) < doc.Included[j].Get("id").(string)
-		})
-
 		if len(data) > 0 {
-			for key := range doc.Included {
-				typ := doc.Included[key].GetType().Name
-				raw := MarshalResource(
-					doc.Included[key],
+			for _, res := range sortedIncluded(doc) {
+				typ := res.GetType().Name
+
+				raw, err := marshalResource(
+					res,
 					doc.PrePath,
 					url.Params.Fields[typ],
 					doc.RelData,
 				)
+				if err != nil {
+					return []byte{}, err
+				}
+
 				rawm := json.RawMessage(raw)
 				inclusions = append(inclusions, &rawm)
 			}
@@ -145,9 +470,26 @@ func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 	// Marshaling
 	plMap := map[string]interface{}{}
 
-	if len(errors) > 0 {
+	atomic := len(errors) == 0 && (doc.Operations != nil || doc.Results != nil)
+
+	switch {
+	case len(errors) > 0:
 		plMap["errors"] = errors
-	} else if len(data) > 0 {
+	case atomic:
+		if doc.Operations != nil {
+			plMap["atomic:operations"], err = marshalOperations(doc.Operations, doc.PrePath)
+			if err != nil {
+				return []byte{}, err
+			}
+		}
+
+		if doc.Results != nil {
+			plMap["atomic:results"], err = marshalResults(doc.Results, doc.PrePath)
+			if err != nil {
+				return []byte{}, err
+			}
+		}
+	case len(data) > 0:
 		plMap["data"] = data
 
 		if len(inclusions) > 0 {
@@ -159,10 +501,25 @@ func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 		plMap["meta"] = doc.Meta
 	}
 
-	if url != nil {
-		plMap["links"] = map[string]string{
+	if url != nil && !atomic {
+		links := map[string]string{
 			"self": doc.PrePath + url.String(),
 		}
+
+		if len(errors) == 0 {
+			first, last := collectionBounds(doc.Data)
+
+			pages, err := paginationLinks(doc, url, first, last)
+			if err != nil {
+				return []byte{}, err
+			}
+
+			for name, link := range pages {
+				links[name] = link
+			}
+		}
+
+		plMap["links"] = links
 	}
 
 	plMap["jsonapi"] = map[string]string{"version": "1.0"}
@@ -170,10 +527,155 @@ func MarshalDocument(doc *Document, url *URL) ([]byte, error) {
 	return json.Marshal(plMap)
 }
 
+// paginationLinks returns the first, prev, next, and last links of a
+// paginated collection.
+//
+// The last link is only known if doc.Total is set. Otherwise, the next link is
+// only returned if doc.HasMore is true. firstRes and lastRes are the first and
+// last resources of the page, or nil if it is empty.
+//
+// An error is returned if the cursors cannot be encoded.
+func paginationLinks(
+	doc *Document, url *URL, firstRes, lastRes Resource,
+) (map[string]string, error) {
+	size := url.Params.PageSize
+	if !url.IsCol || size == 0 {
+		return nil, nil
+	}
+
+	if url.Params.CursorPagination {
+		return cursorLinks(doc, url, firstRes, lastRes)
+	}
+
+	page := func(num uint) string {
+		u := *url
+		params := *url.Params
+		params.PageNumber = num
+		u.Params = &params
+
+		return doc.PrePath + u.String()
+	}
+
+	num := url.Params.PageNumber
+	links := map[string]string{
+		"first": page(0),
+	}
+
+	if doc.Total != nil {
+		last := uint(0)
+		if *doc.Total > 0 {
+			last = (*doc.Total - 1) / size
+		}
+
+		links["last"] = page(last)
+
+		if num > last {
+			links["prev"] = page(last)
+		} else if num > 0 {
+			links["prev"] = page(num - 1)
+		}
+
+		if num < last {
+			links["next"] = page(num + 1)
+		}
+
+		return links, nil
+	}
+
+	if num > 0 {
+		links["prev"] = page(num - 1)
+	}
+
+	if doc.HasMore {
+		links["next"] = page(num + 1)
+	}
+
+	return links, nil
+}
+
+// cursorLinks returns the first, prev, and next links of a collection paginated
+// with cursors.
+func cursorLinks(
+	doc *Document, url *URL, first, last Resource,
+) (map[string]string, error) {
+	page := func(after, before string) string {
+		u := *url
+		params := *url.Params
+		params.PageAfter = after
+		params.PageBefore = before
+		u.Params = &params
+
+		return doc.PrePath + u.String()
+	}
+
+	links := map[string]string{
+		"first": page("", ""),
+	}
+
+	if first == nil {
+		return links, nil
+	}
+
+	rules := url.Params.SortingRules
+
+	if doc.HasPrev {
+		cursor, err := EncodeCursor(first, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		links["prev"] = page("", cursor)
+	}
+
+	if doc.HasMore {
+		cursor, err := EncodeCursor(last, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		links["next"] = page(cursor, "")
+	}
+
+	return links, nil
+}
+
+// collectionBounds returns the first and last resources of data if it is a
+// non-empty collection, or nil otherwise.
+func collectionBounds(data interface{}) (Resource, Resource) {
+	col, ok := data.(Collection)
+	if !ok || col.Len() == 0 {
+		return nil, nil
+	}
+
+	return col.At(0), col.At(col.Len() - 1)
+}
+
+// sortedIncluded returns a copy of the included resources of doc sorted by ID.
+// doc.Included is left as is.
+func sortedIncluded(doc *Document) []Resource {
+	included := make([]Resource, len(doc.Included))
+	copy(included, doc.Included)
+
+	sort.Slice(included, func(i, j int) bool {
+		return included[i].Get("id").(string) < included[j].Get("id").(string)
+	})
+
+	return included
+}
+
 // UnmarshalDocument reads a payload to build and return a Document object.
 //
 // schema must not be nil.
+//
+// The relationships that carry data in the payload are added to RelData, which
+// makes the document ready for Validate.
 func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
+	return unmarshalDocument(payload, schema, false)
+}
+
+// unmarshalDocument is like UnmarshalDocument, but a resource in the primary
+// data is unmarshaled with UnmarshalPartialResource if partial is true.
+func unmarshalDocument(payload []byte, schema *Schema, partial bool) (*Document, error) {
 	doc := &Document{
 		Included:  []Resource{},
 		Resources: map[string]map[string]struct{}{},
@@ -193,6 +695,15 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 	switch {
 	case len(ske.Data) > 0:
 		switch {
+		case ske.Data[0] == '{' && partial:
+			res, err := UnmarshalPartialResource(ske.Data, schema)
+			if err != nil {
+				return nil, err
+			}
+
+			doc.Data = res
+
+			doc.addPayload(ske.Data, "/data")
 		case ske.Data[0] == '{':
 			// Resource
 			res, err := UnmarshalResource(ske.Data, schema)
@@ -201,6 +712,8 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 			}
 
 			doc.Data = res
+
+			doc.addPayload(ske.Data, "/data")
 		case ske.Data[0] == '[':
 			col, err := UnmarshalCollection(ske.Data, schema)
 			if err != nil {
@@ -208,6 +721,14 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 			}
 
 			doc.Data = col
+
+			var raws []json.RawMessage
+
+			_ = json.Unmarshal(ske.Data, &raws)
+
+			for i, raw := range raws {
+				doc.addPayload(raw, "/data/"+strconv.Itoa(i))
+			}
 		case string(ske.Data) == "null":
 			doc.Data = nil
 		default:
@@ -216,6 +737,26 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 		}
 	case len(ske.Errors) > 0:
 		doc.Errors = ske.Errors
+	case ske.Operations != nil:
+		doc.Operations, err = unmarshalOperations(ske.Operations, schema)
+		if err != nil {
+			return nil, err
+		}
+	case ske.Results != nil:
+		doc.Results = make([]OperationResult, 0, len(ske.Results))
+
+		for _, rske := range ske.Results {
+			result := OperationResult{Meta: rske.Meta}
+
+			if len(rske.Data) > 0 && string(rske.Data) != "null" {
+				result.Data, err = UnmarshalResource(rske.Data, schema)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			doc.Results = append(doc.Results, result)
+		}
 	}
 
 	// Included
@@ -236,6 +777,8 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 			}
 
 			doc.Included = append(doc.Included, res)
+
+			doc.addPayload(ske.Included[i], "/included/"+strconv.Itoa(i))
 		}
 	}
 
@@ -244,3 +787,56 @@ func UnmarshalDocument(payload []byte, schema *Schema) (*Document, error) {
 
 	return doc, nil
 }
+
+// addPayload adds to d.RelData the relationships that carry data in raw, the
+// payload of a resource that was already unmarshaled successfully, and keeps
+// the types found in it. pointer locates the resource.
+func (d *Document) addPayload(raw json.RawMessage, pointer string) {
+	var rske resourceSkeleton
+
+	_ = json.Unmarshal(raw, &rske)
+
+	d.types = append(d.types, payloadType{typ: rske.Type, pointer: pointer + "/type"})
+
+	names := make([]string, 0, len(rske.Relationships))
+
+	for name, rel := range rske.Relationships {
+		if len(rel.Data) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		addRelData(d.RelData, rske.Type, name)
+
+		// The linkage is either one identifier or an array.
+		data := rske.Relationships[name].Data
+		dataPointer := pointer + "/relationships/" + name + "/data"
+
+		if data[0] == '[' {
+			var idens []Identifier
+
+			_ = json.Unmarshal(data, &idens)
+
+			for i, iden := range idens {
+				d.types = append(d.types, payloadType{
+					typ:     iden.Type,
+					rel:     name,
+					pointer: dataPointer + "/" + strconv.Itoa(i) + "/type",
+				})
+			}
+		} else if string(data) != "null" {
+			var iden Identifier
+
+			_ = json.Unmarshal(data, &iden)
+
+			d.types = append(d.types, payloadType{
+				typ:     iden.Type,
+				rel:     name,
+				pointer: dataPointer + "/type",
+			})
+		}
+	}
+}
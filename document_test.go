@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"math"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -90,6 +91,75 @@ func TestInclude(t *testing.T) {
 	assert.Equal(expect, ids)
 }
 
+func TestResolveLIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	typ1 := schema.GetType("mocktypes1")
+	res1 := &SoftResource{}
+	res1.SetType(&typ1)
+	res1.SetID("mt1-1")
+	res1.SetLID("a")
+	res1.SetRelLIDs("to-one", []string{"b"})
+	res1.SetRelLIDs("to-many", []string{"b", "c"})
+
+	typ2 := schema.GetType("mocktypes2")
+	res2 := &SoftResource{}
+	res2.SetType(&typ2)
+	res2.SetID("mt2-1")
+	res2.SetLID("b")
+	res2.SetRelLIDs("to-many-from-many", []string{"a"})
+
+	doc := &Document{
+		Data:     res1,
+		Included: []Resource{res2},
+		Operations: []Operation{
+			{
+				Op:  "remove",
+				Ref: OperationRef{Type: "mocktypes1", LID: "a"},
+			}, {
+				Op: "update",
+				Ref: OperationRef{
+					Type:         "mocktypes1",
+					ID:           "mt1-2",
+					Relationship: "to-one",
+				},
+				Data: Identifier{Type: "mocktypes2", LID: "b"},
+			},
+		},
+	}
+
+	err := doc.ResolveLIDs(map[string]string{"c": "mt2-2"})
+	assert.NoError(err)
+
+	assert.Equal("mt2-1", res1.Get("to-one"))
+	assert.Equal([]string{"mt2-1", "mt2-2"}, res1.Get("to-many"))
+	assert.Nil(res1.RelLIDs("to-one"))
+	assert.Nil(res1.RelLIDs("to-many"))
+	assert.Equal([]string{"mt1-1"}, res2.Get("to-many-from-many"))
+	assert.Equal("mt1-1", doc.Operations[0].Ref.ID)
+	assert.Equal(
+		Identifier{Type: "mocktypes2", ID: "mt2-1", LID: "b"},
+		doc.Operations[1].Data,
+	)
+
+	// Unknown local IDs
+	doc = &Document{
+		Data: Identifiers{
+			{Type: "mocktypes1", LID: "a"},
+			{Type: "mocktypes1", LID: "b"},
+		},
+	}
+
+	err = doc.ResolveLIDs(map[string]string{"b": "mt1-2"})
+	assert.Equal(`The local ID "a" does not identify any resource.`, err.(Error).Detail)
+	assert.Equal(Identifiers{
+		{Type: "mocktypes1", LID: "a"},
+		{Type: "mocktypes1", ID: "mt1-2", LID: "b"},
+	}, doc.Data)
+}
+
 func TestMarshalDocument(t *testing.T) {
 	// TODO Describe how this test suite works
 	// Setup
@@ -143,9 +213,12 @@ func TestMarshalDocument(t *testing.T) {
 
 	// Test struct
 	tests := []struct {
-		name   string
-		doc    *Document
-		fields []string
+		name       string
+		doc        *Document
+		fields     []string
+		pageSize   uint
+		pageNumber uint
+		cursors    bool
 	}{
 		{
 			name: "empty data",
@@ -181,6 +254,43 @@ func TestMarshalDocument(t *testing.T) {
 			fields: []string{
 				"str", "uint64", "bool", "int", "time", "to-1", "to-x-from-1",
 			},
+		}, {
+			name: "collection with total",
+			doc: &Document{
+				Data:    Range(col, nil, nil, []string{}, 2, 1),
+				PrePath: "https://example.org",
+				Total:   func() *uint { n := uint(7); return &n }(),
+			},
+			fields:     []string{"str"},
+			pageSize:   2,
+			pageNumber: 1,
+		}, {
+			name: "collection with more pages",
+			doc: &Document{
+				Data:    Range(col, nil, nil, []string{}, 2, 0),
+				HasMore: true,
+			},
+			fields:   []string{"str"},
+			pageSize: 2,
+		}, {
+			name: "collection on last page",
+			doc: &Document{
+				Data:  Range(col, nil, nil, []string{}, 2, 1),
+				Total: func() *uint { n := uint(4); return &n }(),
+			},
+			fields:     []string{"str"},
+			pageSize:   2,
+			pageNumber: 1,
+		}, {
+			name: "collection with cursors",
+			doc: &Document{
+				Data:    Range(col, nil, nil, []string{}, 2, 1),
+				HasMore: true,
+				HasPrev: true,
+			},
+			fields:   []string{"str"},
+			pageSize: 2,
+			cursors:  true,
 		}, {
 			name: "meta",
 			doc: &Document{
@@ -269,7 +379,10 @@ func TestMarshalDocument(t *testing.T) {
 			url := &URL{
 				Fragments: []string{"fake", "path"},
 				Params: &Params{
-					Fields: map[string][]string{"mocktype": test.fields},
+					Fields:           map[string][]string{"mocktype": test.fields},
+					PageSize:         test.pageSize,
+					PageNumber:       test.pageNumber,
+					CursorPagination: test.cursors,
 				},
 			}
 			if _, ok := test.doc.Data.(Collection); ok {
@@ -338,11 +451,19 @@ func TestMarshalInvalidDocuments(t *testing.T) {
 	}))
 	col.Add(Wrap(&mocktype{ID: "id3"}))
 
+	// Floats that are not numbers cannot be marshaled.
+	ftyp := &Type{Name: "mocktype"}
+	_ = ftyp.AddAttr(Attr{Name: "float", Type: AttrTypeFloat64})
+	nan := &SoftResource{Type: ftyp}
+	nan.SetID("nan")
+	nan.Set("float", math.NaN())
+
 	// Test struct
 	tests := []struct {
 		name   string
 		doc    *Document
 		fields []string
+		sort   []string
 		err    string
 	}{
 		{
@@ -351,6 +472,40 @@ func TestMarshalInvalidDocuments(t *testing.T) {
 				Data: "just a string",
 			},
 			err: "data contains an unknown type",
+		}, {
+			name: "path in cursor",
+			doc: &Document{
+				Data:    col,
+				HasMore: true,
+			},
+			sort: []string{"to-1.str", "id"},
+			err:  "jsonapi: sorting rule \"to-1.str\" cannot be used in a cursor",
+		}, {
+			name:   "NaN in resource",
+			doc:    &Document{Data: nan},
+			fields: []string{"float"},
+			err:    "json: unsupported value: NaN",
+		}, {
+			name:   "NaN in collection",
+			doc:    &Document{Data: &Resources{nan}},
+			fields: []string{"float"},
+			err:    "json: unsupported value: NaN",
+		}, {
+			name: "NaN in included resource",
+			doc: &Document{
+				Data:     col.At(2),
+				Included: []Resource{nan},
+			},
+			fields: []string{"float"},
+			err:    "json: unsupported value: NaN",
+		}, {
+			name: "NaN in cursor",
+			doc: &Document{
+				Data:    &Resources{nan},
+				HasMore: true,
+			},
+			sort: []string{"float"},
+			err:  "json: unsupported value: NaN",
 		},
 	}
 
@@ -371,6 +526,12 @@ func TestMarshalInvalidDocuments(t *testing.T) {
 				url.IsCol = true
 			}
 
+			if len(test.sort) > 0 {
+				url.Params.SortingRules = test.sort
+				url.Params.PageSize = 10
+				url.Params.CursorPagination = true
+			}
+
 			// Marshaling
 			_, err := MarshalDocument(test.doc, url)
 			assert.EqualError(err, test.err)
@@ -449,6 +610,47 @@ func TestUnmarshalDocument(t *testing.T) {
 		// TODO Make all the necessary assertions.
 	})
 
+	t.Run("sparse fieldsets", func(t *testing.T) {
+		assert := assert.New(t)
+
+		doc := &Document{
+			Data: col.At(0),
+			RelData: map[string][]string{
+				"mocktype": typ.Fields(),
+			},
+			Included: []Resource{
+				col.At(1),
+				col.At(2),
+			},
+		}
+
+		// The linkage is only kept for to-1, so id2 is linked
+		// to the primary data but id3 cannot be.
+		for _, fields := range []string{"str", "str,to-1"} {
+			url, _ := NewURLFromRaw(schema, "/mocktype/id1?fields[mocktype]="+fields)
+
+			payload, err := MarshalDocument(doc, url)
+			assert.NoError(err)
+
+			doc2, err := UnmarshalDocument(payload, schema)
+			assert.NoError(err)
+			assert.Len(doc2.Included, 2)
+			assert.Equal([]Error{}, doc2.Validate(schema), fields)
+		}
+
+		url, _ := NewURLFromRaw(schema, "/mocktype/id1")
+
+		payload, err := MarshalDocument(doc, url)
+		assert.NoError(err)
+
+		doc2, err := UnmarshalDocument(payload, schema)
+		assert.NoError(err)
+		assert.Equal([]string{
+			"to-1", "to-1-from-1", "to-1-from-x", "to-x", "to-x-from-1", "to-x-from-x",
+		}, doc2.RelData["mocktype"])
+		assert.Equal([]Error{}, doc2.Validate(schema))
+	})
+
 	t.Run("collection with inclusions", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -569,6 +771,170 @@ func TestUnmarshalDocument(t *testing.T) {
 	})
 }
 
+func TestDocumentValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ1 := schema.GetType("mocktypes1")
+	typ2 := schema.GetType("mocktypes2")
+
+	// A type whose relationship points to a type that is
+	// not part of the schema.
+	typ3 := Type{Name: "mocktypes3"}
+	_ = typ3.AddRel(Rel{FromName: "rel", ToType: "unknown", ToOne: true})
+
+	res1 := newResource(&typ1, "mt1-1")
+	res1.Set("to-one", "mt2-1")
+	res1.Set("to-many", []string{"mt2-2"})
+
+	res2 := newResource(&typ1, "mt1-2")
+	res2.Set("to-one", "mt2-3")
+
+	inc1 := newResource(&typ2, "mt2-1")
+	inc1.Set("to-one-from-one", "mt1-9")
+
+	inc2 := newResource(&typ2, "mt2-2")
+	inc2.Set("to-many-from-many", []string{"mt1-3"})
+
+	inc3 := newResource(&typ1, "mt1-3")
+	orphan := newResource(&typ2, "mt2-9")
+
+	unknown := newResource(&typ3, "mt3-1")
+	unknown.Set("rel", "abc")
+
+	unicorn := newResource(&Type{Name: "unicorns"}, "u1")
+
+	col := &Resources{}
+	col.Add(res1)
+	col.Add(res2)
+
+	// Every relationship carries data.
+	relData := map[string][]string{
+		"mocktypes1": typ1.Fields(),
+		"mocktypes2": typ2.Fields(),
+		"mocktypes3": typ3.Fields(),
+	}
+
+	tests := []struct {
+		name     string
+		doc      *Document
+		expected []Error
+	}{
+		{
+			name:     "empty",
+			doc:      &Document{},
+			expected: []Error{},
+		}, {
+			name: "linked through included resources",
+			doc: &Document{
+				Data:     res1,
+				Included: []Resource{inc3, inc2, inc1},
+				RelData:  relData,
+			},
+			expected: []Error{},
+		}, {
+			name: "omitted linkage",
+			doc: &Document{
+				Data:     res1,
+				Included: []Resource{inc1, inc2, orphan},
+				RelData: map[string][]string{
+					"mocktypes1": {"to-one"},
+				},
+			},
+			expected: []Error{},
+		}, {
+			name: "collection",
+			doc: &Document{
+				Data:     col,
+				Included: []Resource{inc1, orphan},
+				RelData:  relData,
+			},
+			expected: []Error{
+				NewErrOrphanIncludedResource("mocktypes2", "mt2-9", "/included/1"),
+			},
+		}, {
+			name: "identifiers",
+			doc: &Document{
+				Data: Identifiers{
+					{ID: "mt2-2", Type: "mocktypes2"},
+				},
+				Included: []Resource{inc2, inc3, inc1},
+				RelData:  relData,
+			},
+			expected: []Error{
+				NewErrOrphanIncludedResource("mocktypes2", "mt2-1", "/included/2"),
+			},
+		}, {
+			name: "duplicates",
+			doc: &Document{
+				Data:     res1,
+				Included: []Resource{inc1, res1, inc1},
+				RelData:  relData,
+			},
+			expected: []Error{
+				NewErrDuplicateResource("mocktypes1", "mt1-1", "/included/1"),
+				NewErrDuplicateResource("mocktypes2", "mt2-1", "/included/2"),
+			},
+		}, {
+			name: "unknown type",
+			doc: &Document{
+				Data:    unknown,
+				RelData: relData,
+			},
+			expected: []Error{
+				NewErrUnknownTypeInRelationship(
+					"rel", "unknown", "/data/relationships/rel/data",
+				),
+			},
+		}, {
+			name: "resources of unknown types",
+			doc: &Document{
+				Data:     unicorn,
+				Included: []Resource{inc1, unicorn},
+				RelData:  relData,
+			},
+			expected: []Error{
+				NewErrUnknownTypeInDocument("unicorns", "/data/type"),
+				NewErrUnknownTypeInDocument("unicorns", "/included/1/type"),
+				NewErrOrphanIncludedResource("mocktypes2", "mt2-1", "/included/0"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, test.doc.Validate(schema), test.name)
+	}
+
+	// The types of a payload
+	doc, err := UnmarshalDocument([]byte(`{
+		"data": [{
+			"type": "mocktypes1",
+			"id": "mt1-1",
+			"relationships": {
+				"to-one": {"data": {"type": "unicorns", "id": "u1"}},
+				"to-many": {"data": [
+					{"type": "mocktypes2", "id": "mt2-1"},
+					{"type": "unicorns", "id": "u2"}
+				]}
+			}
+		}],
+		"included": [
+			{"type": "mocktypes2", "id": "mt2-1"},
+			{"type": "unicorns", "id": "u1"}
+		]
+	}`), schema)
+	assert.NoError(err)
+	assert.Equal([]Error{
+		NewErrUnknownTypeInRelationship(
+			"to-many", "unicorns", "/data/0/relationships/to-many/data/1/type",
+		),
+		NewErrUnknownTypeInRelationship(
+			"to-one", "unicorns", "/data/0/relationships/to-one/data/type",
+		),
+		NewErrUnknownTypeInDocument("unicorns", "/included/1/type"),
+	}, doc.Validate(schema))
+}
+
 func newResource(typ *Type, id string) Resource {
 	res := &SoftResource{}
 	res.SetType(typ)
@@ -0,0 +1,189 @@
+package jsonapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// A ResourceIterator returns the resources of a collection one at a time.
+//
+// Next returns the next resource, or nil once all of them have been returned.
+type ResourceIterator interface {
+	Next() (Resource, error)
+}
+
+// An Encoder writes documents to an output stream.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes doc to the stream. The output is exactly the one returned by
+// MarshalDocument for the same document and URL.
+//
+// When the primary data is a collection, the resources are marshaled and
+// written one at a time instead of building the whole payload in memory.
+// doc.Data can also be a ResourceIterator, in which case the resources are
+// pulled from it as they are written. The pagination links that need cursors
+// are made from the first and last resources pulled. When doc.Errors is not
+// empty, only the errors are written and the iterator is not used.
+//
+// If an error occurs once the document has started to be written, what was
+// written so far is not valid JSON.
+func (e *Encoder) Encode(doc *Document, url *URL) error {
+	var next func() (Resource, error)
+
+	switch data := doc.Data.(type) {
+	case Collection:
+		i := 0
+		next = func() (Resource, error) {
+			if i >= data.Len() {
+				return nil, nil
+			}
+
+			i++
+
+			return data.At(i - 1), nil
+		}
+	case ResourceIterator:
+		next = data.Next
+	}
+
+	atomic := doc.Operations != nil || doc.Results != nil
+
+	if len(doc.Errors) > 0 && next != nil {
+		// Only the errors are written, the resources are not pulled.
+		d := *doc
+		d.Data = nil
+		doc, next = &d, nil
+	}
+
+	if next == nil || atomic {
+		// Only collections benefit from streaming.
+		pl, err := MarshalDocument(doc, url)
+		if err != nil {
+			return err
+		}
+
+		_, err = e.w.Write(pl)
+
+		return err
+	}
+
+	w := bufio.NewWriter(e.w)
+
+	// The members are written in the order in which
+	// json.Marshal writes the keys of a map.
+	_, _ = w.WriteString(`{"data":[`)
+
+	var first, last Resource
+
+	for {
+		res, err := next()
+		if err != nil {
+			return err
+		}
+
+		if res == nil {
+			break
+		}
+
+		if first != nil {
+			_ = w.WriteByte(',')
+		} else {
+			first = res
+		}
+
+		last = res
+
+		pl, err := marshalResourceIn(res, doc, url)
+		if err != nil {
+			return err
+		}
+
+		_, _ = w.Write(pl)
+	}
+
+	_ = w.WriteByte(']')
+
+	if len(doc.Included) > 0 {
+		_, _ = w.WriteString(`,"included":[`)
+
+		for i, res := range sortedIncluded(doc) {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+
+			pl, err := marshalResourceIn(res, doc, url)
+			if err != nil {
+				return err
+			}
+
+			_, _ = w.Write(pl)
+		}
+
+		_ = w.WriteByte(']')
+	}
+
+	_, _ = w.WriteString(`,"jsonapi":{"version":"1.0"}`)
+
+	if url != nil {
+		links := map[string]string{
+			"self": doc.PrePath + url.String(),
+		}
+
+		pages, err := paginationLinks(doc, url, first, last)
+		if err != nil {
+			return err
+		}
+
+		for name, link := range pages {
+			links[name] = link
+		}
+
+		if err := writeMember(w, "links", links); err != nil {
+			return err
+		}
+	}
+
+	if len(doc.Meta) > 0 {
+		if err := writeMember(w, "meta", doc.Meta); err != nil {
+			return err
+		}
+	}
+
+	_ = w.WriteByte('}')
+
+	// Errors from the underlying writer are kept by w
+	// and returned here.
+	return w.Flush()
+}
+
+// marshalResourceIn marshals res as a resource of doc.
+func marshalResourceIn(res Resource, doc *Document, url *URL) ([]byte, error) {
+	var fields []string
+	if url != nil {
+		fields = url.Params.Fields[res.GetType().Name]
+	}
+
+	return marshalResource(res, doc.PrePath, fields, doc.RelData)
+}
+
+// writeMember writes a member named name whose value is v, preceded by a
+// comma.
+func writeMember(w *bufio.Writer, name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, _ = w.WriteString(`,"` + name + `":`)
+	_, _ = w.Write(raw)
+
+	return nil
+}
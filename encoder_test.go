@@ -0,0 +1,159 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ1 := schema.GetType("mocktypes1")
+	typ2 := schema.GetType("mocktypes2")
+
+	col := &Resources{}
+
+	for _, id := range []string{"mt1-3", "mt1-1", "mt1-2"} {
+		res := &SoftResource{Type: &typ1}
+		res.SetID(id)
+		res.Set("str", "<"+id+"> & co")
+		res.Set("int", col.Len())
+		res.Set("to-one", "mt2-1")
+		col.Add(res)
+	}
+
+	inc1 := &SoftResource{Type: &typ2}
+	inc1.SetID("mt2-2")
+
+	inc2 := &SoftResource{Type: &typ2}
+	inc2.SetID("mt2-1")
+	inc2.Set("strptr", ptr("abc"))
+
+	total := uint(7)
+
+	tests := []struct {
+		name string
+		doc  *Document
+		url  string
+	}{
+		{
+			name: "empty collection",
+			doc:  &Document{Data: &Resources{}},
+			url:  "/mocktypes1",
+		}, {
+			name: "collection",
+			doc: &Document{
+				Data:     col,
+				Included: []Resource{inc1, inc2},
+				RelData:  map[string][]string{"mocktypes1": {"to-one"}},
+				Meta:     Meta{"key": "<value>"},
+				PrePath:  "https://example.com",
+			},
+			url: "/mocktypes1?include=to-one&fields[mocktypes1]=str,to-one",
+		}, {
+			name: "pages",
+			doc:  &Document{Data: col, Total: &total},
+			url:  "/mocktypes1?page[size]=3&page[number]=1",
+		}, {
+			name: "cursors",
+			doc:  &Document{Data: col, HasMore: true, HasPrev: true},
+			url:  "/mocktypes1?page[size]=3&page[after]=&sort=-int",
+		}, {
+			name: "resource",
+			doc:  &Document{Data: col.At(0), Included: []Resource{inc2}},
+			url:  "/mocktypes1/mt1-3",
+		}, {
+			name: "errors",
+			doc:  &Document{Errors: []Error{NewErrNotFound()}},
+			url:  "/mocktypes1/mt1-9",
+		},
+	}
+
+	for _, test := range tests {
+		url, err := NewURLFromRaw(schema, test.url)
+		assert.NoError(err, test.name)
+
+		included := append([]Resource(nil), test.doc.Included...)
+
+		expected, err := MarshalDocument(test.doc, url)
+		assert.NoError(err, test.name)
+
+		buf := &bytes.Buffer{}
+		err = NewEncoder(buf).Encode(test.doc, url)
+		assert.NoError(err, test.name)
+		assert.Equal(string(expected), buf.String(), test.name)
+
+		// The included resources are sorted without modifying the document.
+		assert.Equal(included, test.doc.Included, test.name)
+
+		// The same resources pulled from an iterator
+		if c, ok := test.doc.Data.(Collection); ok {
+			doc := *test.doc
+			doc.Data = &mockIterator{col: c}
+
+			buf.Reset()
+			err = NewEncoder(buf).Encode(&doc, url)
+			assert.NoError(err, test.name)
+			assert.Equal(string(expected), buf.String(), test.name)
+		}
+	}
+
+	// Errors from the iterator
+	url, _ := NewURLFromRaw(schema, "/mocktypes1")
+	iter := &mockIterator{col: col, err: errors.New("unavailable")}
+	err := NewEncoder(&bytes.Buffer{}).Encode(&Document{Data: iter}, url)
+	assert.Equal(iter.err, err)
+
+	// Errors with an iterator, which is not used
+	doc := &Document{Data: iter, Errors: []Error{NewErrNotFound()}}
+	expected, _ := MarshalDocument(&Document{Errors: doc.Errors}, url)
+	buf := &bytes.Buffer{}
+	err = NewEncoder(buf).Encode(doc, url)
+	assert.NoError(err)
+	assert.Equal(string(expected), buf.String())
+	assert.Equal(iter, doc.Data)
+
+	// Resources that cannot be marshaled
+	ftyp := Type{Name: "floats"}
+	_ = ftyp.AddAttr(Attr{Name: "float", Type: AttrTypeFloat64})
+	url, _ = NewURLFromRaw(&Schema{Types: []Type{ftyp}}, "/floats")
+
+	nan := &SoftResource{Type: &ftyp}
+	nan.SetID("nan")
+	nan.Set("float", math.NaN())
+
+	for _, doc := range []*Document{
+		{Data: &Resources{nan}},
+		{Data: &Resources{}, Included: []Resource{nan}},
+	} {
+		err = NewEncoder(&bytes.Buffer{}).Encode(doc, url)
+		assert.EqualError(err, "json: unsupported value: NaN")
+	}
+}
+
+type mockIterator struct {
+	col Collection
+	i   int
+	err error
+}
+
+func (m *mockIterator) Next() (Resource, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if m.i >= m.col.Len() {
+		return nil, nil
+	}
+
+	m.i++
+
+	return m.col.At(m.i - 1), nil
+}
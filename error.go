@@ -133,6 +133,19 @@ func NewErrInvalidPageNumberParameter(badPageNumber string) Error {
 	return e
 }
 
+// NewErrInvalidPageCursorParameter (400) returns the corresponding error.
+func NewErrInvalidPageCursorParameter(param, badCursor string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Invalid page cursor parameter"
+	e.Detail = "The page cursor parameter is not a valid cursor."
+	e.Source["parameter"] = param
+	e.Meta["bad-page-cursor"] = badCursor
+
+	return e
+}
+
 // NewErrInvalidPageSizeParameter (400) returns the corresponding error.
 func NewErrInvalidPageSizeParameter(badPageSize string) Error {
 	e := NewError()
@@ -266,6 +279,36 @@ func NewErrUnknownFieldInFilterParameter(field string) Error {
 	return e
 }
 
+// NewErrUnknownFieldInSortParameter (400) returns the corresponding error.
+func NewErrUnknownFieldInSortParameter(field string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Unknown field in sort parameter"
+	e.Detail = fmt.Sprintf("%q is not a known field.", field)
+	e.Source["parameter"] = "sort"
+	e.Meta["unknown-field"] = field
+
+	return e
+}
+
+// NewErrSortRuleWithCursors (400) returns the corresponding error.
+//
+// It is returned when the sorting rule rule goes through relationships while
+// the collection is paginated with cursors, since cursors only hold the values
+// of the resources themselves.
+func NewErrSortRuleWithCursors(rule string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Sort parameter incompatible with page cursors"
+	e.Detail = fmt.Sprintf("%q cannot be used with cursor pagination.", rule)
+	e.Source["parameter"] = "sort"
+	e.Meta["bad-sort-rule"] = rule
+
+	return e
+}
+
 // NewErrUnknownOperatorInFilterParameter (400) returns the corresponding error.
 func NewErrUnknownOperatorInFilterParameter(op string) Error {
 	e := NewError()
@@ -318,6 +361,75 @@ func NewErrUnknownFilterParameterLabel(label string) Error {
 	return e
 }
 
+// NewErrDuplicateResource (400) returns the corresponding error.
+//
+// It is returned when the resource of type typ identified by id appears more
+// than once in a compound document. pointer locates the duplicate.
+func NewErrDuplicateResource(typ, id, pointer string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Duplicate resource"
+	e.Detail = fmt.Sprintf("Resource %q of type %q appears more than once.", id, typ)
+	e.Source["pointer"] = pointer
+	e.Meta["type"] = typ
+	e.Meta["id"] = id
+
+	return e
+}
+
+// NewErrOrphanIncludedResource (400) returns the corresponding error.
+//
+// It is returned when the included resource of type typ identified by id
+// cannot be reached from the primary data. pointer locates the resource.
+func NewErrOrphanIncludedResource(typ, id, pointer string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Orphan included resource"
+	e.Detail = fmt.Sprintf(
+		"Resource %q of type %q is not linked to the primary data.", id, typ,
+	)
+	e.Source["pointer"] = pointer
+	e.Meta["type"] = typ
+	e.Meta["id"] = id
+
+	return e
+}
+
+// NewErrUnknownTypeInDocument (400) returns the corresponding error.
+//
+// It is returned when a resource of a document is of typ, which is not a known
+// type. pointer locates the type of the resource.
+func NewErrUnknownTypeInDocument(typ, pointer string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Unknown type in document"
+	e.Detail = fmt.Sprintf("%q is not a known type.", typ)
+	e.Source["pointer"] = pointer
+	e.Meta["unknown-type"] = typ
+
+	return e
+}
+
+// NewErrUnknownTypeInRelationship (400) returns the corresponding error.
+//
+// It is returned when the data of the relationship rel points to resources of
+// typ, which is not a known type. pointer locates the relationship data.
+func NewErrUnknownTypeInRelationship(rel, typ, pointer string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusBadRequest)
+	e.Title = "Unknown type in relationship"
+	e.Detail = fmt.Sprintf("%q is not a known type.", typ)
+	e.Source["pointer"] = pointer
+	e.Meta["relationship"] = rel
+	e.Meta["unknown-type"] = typ
+
+	return e
+}
+
 // NewErrUnauthorized (401) returns the corresponding error.
 func NewErrUnauthorized() Error {
 	e := NewError()
@@ -351,6 +463,39 @@ func NewErrNotFound() Error {
 	return e
 }
 
+// NewErrMethodNotAllowed (405) returns the corresponding error.
+func NewErrMethodNotAllowed() Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusMethodNotAllowed)
+	e.Title = "Method not allowed"
+	e.Detail = "The method is not supported by the URI."
+
+	return e
+}
+
+// NewErrNotAcceptable (406) returns the corresponding error.
+func NewErrNotAcceptable() Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusNotAcceptable)
+	e.Title = "Not acceptable"
+	e.Detail = "None of the media types of the Accept header can be served."
+
+	return e
+}
+
+// NewErrConflict (409) returns the corresponding error.
+func NewErrConflict() Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusConflict)
+	e.Title = "Conflict"
+	e.Detail = "The request conflicts with the current state of the resource."
+
+	return e
+}
+
 // NewErrPayloadTooLarge (413) returns the corresponding error.
 func NewErrPayloadTooLarge() Error {
 	e := NewError()
@@ -382,6 +527,22 @@ func NewErrUnsupportedMediaType() Error {
 	return e
 }
 
+// NewErrInvalidAttrValue (422) returns the corresponding error.
+//
+// It is returned when the value of the attribute named attr does not satisfy
+// its validation rules. detail describes the rule that is not satisfied.
+func NewErrInvalidAttrValue(attr, detail string) Error {
+	e := NewError()
+
+	e.Status = strconv.Itoa(http.StatusUnprocessableEntity)
+	e.Title = "Invalid attribute value"
+	e.Detail = detail
+	e.Source["pointer"] = "/data/attributes/" + attr
+	e.Meta["attribute"] = attr
+
+	return e
+}
+
 // NewErrTooManyRequests (429) returns the corresponding error.
 func NewErrTooManyRequests() Error {
 	e := NewError()
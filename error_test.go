@@ -114,6 +114,14 @@ func TestErrorConstructors(t *testing.T) {
 			}(),
 			expected: "400 Bad Request: " +
 				"The page number parameter is not positive integer (including 0).",
+		}, {
+			name: "NewErrInvalidPageCursorParameter",
+			err: func() Error {
+				e := NewErrInvalidPageCursorParameter("page[after]", "abc")
+				return e
+			}(),
+			expected: "400 Bad Request: " +
+				"The page cursor parameter is not a valid cursor.",
 		}, {
 			name: "NewErrInvalidPageSizeParameter",
 			err: func() Error {
@@ -187,6 +195,21 @@ func TestErrorConstructors(t *testing.T) {
 				return e
 			}(),
 			expected: "400 Bad Request: \"field\" is not a known field.",
+		}, {
+			name: "NewErrUnknownFieldInSortParameter",
+			err: func() Error {
+				e := NewErrUnknownFieldInSortParameter("field")
+				return e
+			}(),
+			expected: "400 Bad Request: \"field\" is not a known field.",
+		}, {
+			name: "NewErrSortRuleWithCursors",
+			err: func() Error {
+				e := NewErrSortRuleWithCursors("rel.attr")
+				return e
+			}(),
+			expected: "400 Bad Request: " +
+				"\"rel.attr\" cannot be used with cursor pagination.",
 		}, {
 			name: "NewErrUnknownOperatorInFilterParameter",
 			err: func() Error {
@@ -215,6 +238,36 @@ func TestErrorConstructors(t *testing.T) {
 				return e
 			}(),
 			expected: "400 Bad Request: \"label\" is not a known filter query label.",
+		}, {
+			name: "NewErrDuplicateResource",
+			err: func() Error {
+				e := NewErrDuplicateResource("type", "id", "/included/0")
+				return e
+			}(),
+			expected: "400 Bad Request: " +
+				"Resource \"id\" of type \"type\" appears more than once.",
+		}, {
+			name: "NewErrOrphanIncludedResource",
+			err: func() Error {
+				e := NewErrOrphanIncludedResource("type", "id", "/included/0")
+				return e
+			}(),
+			expected: "400 Bad Request: " +
+				"Resource \"id\" of type \"type\" is not linked to the primary data.",
+		}, {
+			name: "NewErrUnknownTypeInDocument",
+			err: func() Error {
+				e := NewErrUnknownTypeInDocument("type", "/data/type")
+				return e
+			}(),
+			expected: "400 Bad Request: \"type\" is not a known type.",
+		}, {
+			name: "NewErrUnknownTypeInRelationship",
+			err: func() Error {
+				e := NewErrUnknownTypeInRelationship("rel", "type", "/data")
+				return e
+			}(),
+			expected: "400 Bad Request: \"type\" is not a known type.",
 		}, {
 			name: "NewErrUnauthorized",
 			err: func() Error {
@@ -236,6 +289,29 @@ func TestErrorConstructors(t *testing.T) {
 				return e
 			}(),
 			expected: "404 Not Found: The URI does not exist.",
+		}, {
+			name: "NewErrMethodNotAllowed",
+			err: func() Error {
+				e := NewErrMethodNotAllowed()
+				return e
+			}(),
+			expected: "405 Method Not Allowed: The method is not supported by the URI.",
+		}, {
+			name: "NewErrNotAcceptable",
+			err: func() Error {
+				e := NewErrNotAcceptable()
+				return e
+			}(),
+			expected: "406 Not Acceptable: " +
+				"None of the media types of the Accept header can be served.",
+		}, {
+			name: "NewErrConflict",
+			err: func() Error {
+				e := NewErrConflict()
+				return e
+			}(),
+			expected: "409 Conflict: " +
+				"The request conflicts with the current state of the resource.",
 		}, {
 			name: "NewErrPayloadTooLarge",
 			err: func() Error {
@@ -257,6 +333,13 @@ func TestErrorConstructors(t *testing.T) {
 				return e
 			}(),
 			expected: "415 Unsupported Media Type: Unsupported media type",
+		}, {
+			name: "NewErrInvalidAttrValue",
+			err: func() Error {
+				e := NewErrInvalidAttrValue("attr", "The value is required.")
+				return e
+			}(),
+			expected: "422 Unprocessable Entity: The value is required.",
 		}, {
 			name: "NewErrTooManyRequests",
 			err: func() Error {
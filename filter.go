@@ -1,12 +1,23 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 )
 
 // A Filter is used to define filters when querying collections.
+//
+// Op is a comparison operator (=, !=, <, <=, >, or >=), a text operator
+// (contains, starts-with, ends-with, like, or regex), a set operator (in,
+// not-in, has, has-any, or has-all), a null check (is-null or is-not-null), or
+// a logical operator (and, or, or not). The value of and and or is a []*Filter
+// and the value of not is a *Filter.
 type Filter struct {
 	Field string      `json:"f"`
 	Op    string      `json:"o"`
@@ -47,6 +58,17 @@ func (f *Filter) UnmarshalJSON(data []byte) error {
 		}
 
 		f.Val = filters
+	case "not":
+		f.Field = ""
+
+		sub := &Filter{}
+
+		err := json.Unmarshal(tmpFilter.Val, sub)
+		if err != nil {
+			return err
+		}
+
+		f.Val = sub
 	default:
 		// Error checking ignored since it cannot fail at this
 		// point. The first unmarshaling step of this function
@@ -57,57 +79,528 @@ func (f *Filter) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// A Resolver returns the resource of type typ identified by id, or nil if it
+// does not exist.
+//
+// It is used to load related resources when a filter goes through
+// relationships. Every Store is a Resolver.
+type Resolver interface {
+	Resource(typ, id string) (Resource, error)
+}
+
 // IsAllowed reports whether res is valid under the rules defined in the filter.
+//
+// Since related resources cannot be loaded, a filter on a field of related
+// resources (like author.name) is never satisfied. IsAllowedWith can be used
+// instead.
 func (f *Filter) IsAllowed(res Resource) bool {
-	var (
-		val interface{}
-		// typ string
-	)
+	return f.IsAllowedWith(res, nil)
+}
 
-	if _, ok := res.Attrs()[f.Field]; ok {
-		val = res.Get(f.Field)
-	}
+// IsAllowedWith is like IsAllowed, but r is used to load the related resources
+// of res when the field of the filter goes through relationships.
+//
+// The field of a filter can be a path of names separated by dots (see
+// Schema.ResolvePath). When a to-many relationship is part of the path, the
+// filter is satisfied if at least one of the related resources satisfies it.
+// A related resource that cannot be loaded is ignored.
+//
+// Like in SQL, a condition on a null attribute is neither satisfied nor
+// unsatisfied, unless it checks whether the attribute is null (with is-null,
+// is-not-null, or = and != with a nil value). The unknown result propagates
+// through and, or, and not, and a resource is only allowed if the result is
+// true. For example, a resource whose age is null satisfies neither age > 30
+// nor not(age > 30).
+func (f *Filter) IsAllowedWith(res Resource, r Resolver) bool {
+	return f.eval(res, r) == triTrue
+}
 
-	if rel, ok := res.Rels()[f.Field]; ok {
-		if rel.ToOne {
-			val = res.Get(f.Field).(string)
-		} else {
-			val = res.Get(f.Field).([]string)
-		}
+// A tri is the result of a condition in three-valued logic.
+type tri int
+
+const (
+	triFalse tri = iota
+	triUnknown
+	triTrue
+)
+
+// toTri returns the tri equivalent to b.
+func toTri(b bool) tri {
+	if b {
+		return triTrue
 	}
 
+	return triFalse
+}
+
+// eval returns the result of the filter for res.
+func (f *Filter) eval(res Resource, r Resolver) tri {
 	switch f.Op {
 	case "and":
+		result := triTrue
+
 		filters := f.Val.([]*Filter)
 		for i := range filters {
-			if !filters[i].IsAllowed(res) {
-				return false
+			if t := filters[i].eval(res, r); t < result {
+				result = t
 			}
 		}
 
-		return true
+		return result
 	case "or":
+		result := triFalse
+
 		filters := f.Val.([]*Filter)
 		for i := range filters {
-			if filters[i].IsAllowed(res) {
-				return true
+			if t := filters[i].eval(res, r); t > result {
+				result = t
 			}
 		}
 
-		return false
-	case "in":
-		return checkIn(val.(string), f.Val.([]string))
-	case "has":
+		return result
+	case "not":
+		return triTrue - f.Val.(*Filter).eval(res, r)
+	}
+
+	if !strings.Contains(f.Field, ".") {
+		return f.checkTri(fieldValue(res, f.Field))
+	}
+
+	result := triFalse
+
+	for _, val := range pathValues(res, strings.Split(f.Field, "."), r) {
+		if t := f.checkTri(val); t > result {
+			result = t
+		}
+	}
+
+	return result
+}
+
+// checkTri is like check, but the result is unknown if val is null and the
+// operation is not a null check.
+func (f *Filter) checkTri(val interface{}) tri {
+	switch f.Op {
+	case "is-null", "is-not-null":
+		return toTri(f.check(val))
+	case "=", "!=":
+		if isNull(f.Val) {
+			return toTri(f.check(val))
+		}
+	}
+
+	if isNull(val) {
+		return triUnknown
+	}
+
+	return toTri(f.check(val))
+}
+
+// isNull reports whether v is nil or a nil pointer.
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// Validate checks the filter and its subfilters against the type named typ of
+// schema and returns an error for the first field, operator, or value that is
+// invalid.
+//
+// See Coerce for more information.
+func (f *Filter) Validate(schema *Schema, typ string) error {
+	_, err := f.Coerce(schema, typ)
+	return err
+}
+
+// Coerce checks the filter and its subfilters against the type named typ of
+// schema and returns a copy where each value is converted to the Go type of
+// the field it is compared to (like int64, time.Time, or *string). The filter
+// itself is not modified.
+//
+// This is necessary for filters that are unmarshaled from JSON since numbers
+// are float64 values and times are strings. Strings are also accepted in
+// their text form for other types (like "30" for an integer). Values that are
+// already of the right type are kept as is.
+//
+// The values of in and not-in are a []string for string attributes and to-one
+// relationships, and a []interface{} of values of the type of the attribute
+// otherwise. They cannot be null. Like other comparisons, in and not-in are
+// neither satisfied nor unsatisfied when the attribute is null.
+//
+// The returned error is an Error that reports an unknown field, an unknown
+// operator, or an invalid value.
+func (f *Filter) Coerce(schema *Schema, typ string) (*Filter, error) {
+	cf := &Filter{
+		Field: f.Field,
+		Op:    f.Op,
+		Col:   f.Col,
+	}
+
+	if !isCollation(f.Col) {
+		return nil, NewErrUnknownCollationInFilterParameter(f.Col)
+	}
+
+	if f.Op == "not" {
+		sub, ok := f.Val.(*Filter)
+		if !ok || sub == nil {
+			return nil, NewErrInvalidValueInFilterParameter(filterValString(f.Val), "filter")
+		}
+
+		csub, err := sub.Coerce(schema, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		cf.Val = csub
+
+		return cf, nil
+	}
+
+	if f.Op == "and" || f.Op == "or" {
+		filters, ok := f.Val.([]*Filter)
+		if !ok {
+			return nil, NewErrInvalidValueInFilterParameter(filterValString(f.Val), "filters")
+		}
+
+		cfilters := make([]*Filter, len(filters))
+
+		for i := range filters {
+			cfilter, err := filters[i].Coerce(schema, typ)
+			if err != nil {
+				return nil, err
+			}
+
+			cfilters[i] = cfilter
+		}
+
+		cf.Val = cfilters
+
+		return cf, nil
+	}
+
+	attr, rel, err := schema.ResolvePath(typ, f.Field)
+	if err != nil {
+		return nil, NewErrUnknownFieldInFilterParameter(f.Field)
+	}
+
+	if !containsID(filterOps(attr, rel), f.Op) {
+		return nil, NewErrUnknownOperatorInFilterParameter(f.Op)
+	}
+
+	val, kind, ok := coerceFilterVal(f.Op, attr, rel, f.Val)
+	if !ok {
+		return nil, NewErrInvalidValueInFilterParameter(filterValString(f.Val), kind)
+	}
+
+	cf.Val = val
+
+	return cf, nil
+}
+
+// check reports whether val, the value of the field of the filter, satisfies
+// the operation.
+func (f *Filter) check(val interface{}) bool {
+	switch {
+	case f.Op == "is-null":
+		return isNull(val)
+	case f.Op == "is-not-null":
+		return !isNull(val)
+	case f.Op == "in":
+		return checkInList(val, f.Val)
+	case f.Op == "not-in":
+		return !checkInList(val, f.Val)
+	case f.Op == "has":
 		return checkIn(f.Val.(string), val.([]string))
+	case f.Op == "has-any":
+		return checkHasAny(val.([]string), f.Val.([]string))
+	case f.Op == "has-all":
+		return checkHasAll(val.([]string), f.Val.([]string))
+	case isTextOp(f.Op):
+		switch val := val.(type) {
+		case string:
+			return checkText(f.Op, f.Col, val, f.Val.(string))
+		case *string:
+			return val != nil && checkText(f.Op, f.Col, *val, f.Val.(string))
+		default:
+			return false
+		}
 	default:
-		return checkVal(f.Op, val, f.Val)
+		return checkVal(f.Op, f.Col, val, f.Val)
+	}
+}
+
+// fieldValue returns the value of the attribute or the relationship of res
+// named after name, or nil if there is none.
+func fieldValue(res Resource, name string) interface{} {
+	if _, ok := res.Attrs()[name]; ok {
+		return res.Get(name)
+	}
+
+	if rel, ok := res.Rels()[name]; ok {
+		if rel.ToOne {
+			return res.Get(name).(string)
+		}
+
+		return res.Get(name).([]string)
+	}
+
+	return nil
+}
+
+// pathValues returns the values found at the end of path from res. There can
+// be many when the path goes through to-many relationships.
+func pathValues(res Resource, path []string, r Resolver) []interface{} {
+	name := path[0]
+
+	if len(path) == 1 {
+		return []interface{}{fieldValue(res, name)}
 	}
+
+	if attr, ok := res.Attrs()[name]; ok {
+		if attr.Type != AttrTypeObject {
+			return nil
+		}
+
+		return objectValues(res.Get(name), path[1:])
+	}
+
+	rel, ok := res.Rels()[name]
+	if !ok || r == nil {
+		return nil
+	}
+
+	vals := []interface{}{}
+
+	for _, id := range relIDs(res, name) {
+		related, err := r.Resource(rel.ToType, id)
+		if err != nil || related == nil {
+			continue
+		}
+
+		vals = append(vals, pathValues(related, path[1:], r)...)
+	}
+
+	return vals
+}
+
+// objectValues returns the value found at the end of path from obj, the value
+// of an object attribute.
+func objectValues(obj interface{}, path []string) []interface{} {
+	val, ok := objectField(obj, path[0])
+	if !ok {
+		return nil
+	}
+
+	if len(path) == 1 {
+		return []interface{}{val}
+	}
+
+	return objectValues(val, path[1:])
+}
+
+// objectField returns the value of the field named after name of obj, an
+// object held as a map or as a struct.
+func objectField(obj interface{}, name string) (interface{}, bool) {
+	if m, ok := obj.(map[string]interface{}); ok {
+		val, ok := m[name]
+		return val, ok
+	}
+
+	val := reflect.ValueOf(obj)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		sf := val.Type().Field(i)
+
+		fname := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if fname == "" {
+			fname = sf.Name
+		}
+
+		if sf.PkgPath == "" && fname == name {
+			return val.Field(i).Interface(), true
+		}
+	}
+
+	return nil, false
 }
 
-func checkVal(op string, rval, cval interface{}) bool {
+// filterOps returns the operators that can be used on the attribute or the
+// relationship.
+func filterOps(attr Attr, rel Rel) []string {
+	ops := []string{"=", "!=", "<", "<=", ">", ">="}
+	textOps := []string{"contains", "starts-with", "ends-with", "like", "regex"}
+
+	setOps := []string{"in", "not-in"}
+	nullOps := []string{"is-null", "is-not-null"}
+
+	switch {
+	case rel.FromName != "" && rel.ToOne:
+		return append(ops, setOps...)
+	case rel.FromName != "":
+		return []string{"=", "!=", "has", "has-any", "has-all"}
+	case !isScalarAttrType(attr.Type):
+		return nil
+	case attr.Type == AttrTypeBool:
+		ops = ops[:2]
+	case attr.Type == AttrTypeString:
+		ops = append(ops, textOps...)
+	}
+
+	ops = append(ops, setOps...)
+
+	if attr.Nullable {
+		ops = append(ops, nullOps...)
+	}
+
+	return ops
+}
+
+// coerceFilterVal converts v into the Go type expected by op on the attribute
+// or the relationship. It also returns the name of that type, and false if the
+// conversion is impossible.
+func coerceFilterVal(op string, attr Attr, rel Rel, v interface{}) (interface{}, string, bool) {
+	switch {
+	case op == "is-null" || op == "is-not-null":
+		return nil, "null", v == nil
+	case op == "in" || op == "not-in":
+		if rel.FromName == "" && attr.Type != AttrTypeString {
+			return coerceFilterList(attr, v)
+		}
+
+		ids, ok := toStrings(v)
+
+		return ids, "[]string", ok
+	case rel.FromName != "" && !rel.ToOne && op != "has":
+		ids, ok := toStrings(v)
+		return ids, "[]string", ok
+	case rel.FromName != "":
+		id, ok := v.(string)
+		return id, "string", ok
+	case op == "regex":
+		str, ok := v.(string)
+		if ok {
+			_, err := regexp.Compile(str)
+			ok = err == nil
+		}
+
+		return str, "regex", ok
+	case isTextOp(op):
+		str, ok := v.(string)
+		return str, "string", ok
+	}
+
+	kind := GetAttrTypeString(attr.Type, attr.Nullable)
+
+	if v == nil {
+		return attr.zeroValue(), kind, attr.Nullable
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, kind, false
+	}
+
+	if attr.Type == AttrTypeBytes {
+		// UnmarshalToType panics on invalid bytes, so they are
+		// checked first.
+		var b []byte
+		if json.Unmarshal(payload, &b) != nil {
+			return nil, kind, false
+		}
+	}
+
+	val, err := attr.UnmarshalToType(payload)
+
+	if str, ok := v.(string); ok && err != nil && attr.Type != AttrTypeString {
+		// The value may be in text form, like the values of the
+		// bracket syntax of the filter parameter.
+		val, err = attr.UnmarshalToType([]byte(str))
+	}
+
+	return val, kind, err == nil
+}
+
+// coerceFilterList converts v, the list of values of an in or not-in filter on
+// the attribute, into a []interface{} where each value has the Go type of the
+// attribute. It also returns the name of the type of the list, and false if a
+// value cannot be converted or is null.
+func coerceFilterList(attr Attr, v interface{}) (interface{}, string, bool) {
+	kind := "[]" + GetAttrTypeString(attr.Type, false)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, kind, false
+	}
+
+	vals := make([]interface{}, rv.Len())
+
+	for i := range vals {
+		elem := rv.Index(i).Interface()
+		if isNull(elem) {
+			return nil, kind, false
+		}
+
+		val, _, ok := coerceFilterVal("=", attr, Rel{}, elem)
+		if !ok {
+			return nil, kind, false
+		}
+
+		vals[i] = val
+	}
+
+	return vals, kind, true
+}
+
+// toStrings returns v as a slice of strings if it is a []string or a
+// []interface{} that only holds strings.
+func toStrings(v interface{}) ([]string, bool) {
+	switch v := v.(type) {
+	case []string:
+		return append([]string(nil), v...), true
+	case []interface{}:
+		strs := make([]string, len(v))
+
+		for i := range v {
+			str, ok := v[i].(string)
+			if !ok {
+				return nil, false
+			}
+
+			strs[i] = str
+		}
+
+		return strs, true
+	default:
+		return nil, false
+	}
+}
+
+// filterValString returns a representation of v for error messages.
+func filterValString(v interface{}) string {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(payload)
+}
+
+func checkVal(op, col string, rval, cval interface{}) bool {
 	switch rval := rval.(type) {
 	case string:
-		return checkStr(op, rval, cval.(string))
+		return checkStr(op, col, rval, cval.(string))
 	case int:
 		return checkInt(op, int64(rval), int64(cval.(int)))
 	case int8:
@@ -134,6 +627,14 @@ func checkVal(op string, rval, cval interface{}) bool {
 		return checkTime(op, rval, cval.(time.Time))
 	case []byte:
 		return checkBytes(op, rval, cval.([]byte))
+	case float32:
+		return checkFloat(op, float64(rval), float64(cval.(float32)))
+	case float64:
+		return checkFloat(op, rval, cval.(float64))
+	case Decimal:
+		return checkDecimal(op, rval, cval.(Decimal))
+	case UUID:
+		return checkUUID(op, rval, cval.(UUID))
 	case *string:
 		if rval == nil || cval.(*string) == nil {
 			switch op {
@@ -146,7 +647,7 @@ func checkVal(op string, rval, cval interface{}) bool {
 			}
 		}
 
-		return checkStr(op, *rval, *cval.(*string))
+		return checkStr(op, col, *rval, *cval.(*string))
 	case *int:
 		if rval == nil || cval.(*int) == nil {
 			switch op {
@@ -316,6 +817,58 @@ func checkVal(op string, rval, cval interface{}) bool {
 		}
 
 		return checkBytes(op, *rval, *cval.(*[]byte))
+	case *float32:
+		if rval == nil || cval.(*float32) == nil {
+			switch op {
+			case "=":
+				return rval == cval.(*float32)
+			case "!=":
+				return rval != cval.(*float32)
+			default:
+				return false
+			}
+		}
+
+		return checkFloat(op, float64(*rval), float64(*cval.(*float32)))
+	case *float64:
+		if rval == nil || cval.(*float64) == nil {
+			switch op {
+			case "=":
+				return rval == cval.(*float64)
+			case "!=":
+				return rval != cval.(*float64)
+			default:
+				return false
+			}
+		}
+
+		return checkFloat(op, *rval, *cval.(*float64))
+	case *Decimal:
+		if rval == nil || cval.(*Decimal) == nil {
+			switch op {
+			case "=":
+				return rval == cval.(*Decimal)
+			case "!=":
+				return rval != cval.(*Decimal)
+			default:
+				return false
+			}
+		}
+
+		return checkDecimal(op, *rval, *cval.(*Decimal))
+	case *UUID:
+		if rval == nil || cval.(*UUID) == nil {
+			switch op {
+			case "=":
+				return rval == cval.(*UUID)
+			case "!=":
+				return rval != cval.(*UUID)
+			default:
+				return false
+			}
+		}
+
+		return checkUUID(op, *rval, *cval.(*UUID))
 	case []string:
 		return checkSlice(op, rval, cval.([]string))
 	default:
@@ -323,7 +876,11 @@ func checkVal(op string, rval, cval interface{}) bool {
 	}
 }
 
-func checkStr(op string, rval, cval string) bool {
+func checkStr(op, col string, rval, cval string) bool {
+	return checkInt(op, int64(compareStrings(col, rval, cval)), 0)
+}
+
+func checkInt(op string, rval, cval int64) bool {
 	switch op {
 	case "=":
 		return rval == cval
@@ -342,7 +899,7 @@ func checkStr(op string, rval, cval string) bool {
 	}
 }
 
-func checkInt(op string, rval, cval int64) bool {
+func checkUint(op string, rval, cval uint64) bool {
 	switch op {
 	case "=":
 		return rval == cval
@@ -361,7 +918,7 @@ func checkInt(op string, rval, cval int64) bool {
 	}
 }
 
-func checkUint(op string, rval, cval uint64) bool {
+func checkFloat(op string, rval, cval float64) bool {
 	switch op {
 	case "=":
 		return rval == cval
@@ -380,6 +937,14 @@ func checkUint(op string, rval, cval uint64) bool {
 	}
 }
 
+func checkDecimal(op string, rval, cval Decimal) bool {
+	return checkInt(op, int64(rval.Cmp(cval)), 0)
+}
+
+func checkUUID(op string, rval, cval UUID) bool {
+	return checkInt(op, int64(bytes.Compare(rval[:], cval[:])), 0)
+}
+
 func checkBool(op string, rval, cval bool) bool {
 	switch op {
 	case "=":
@@ -492,6 +1057,28 @@ func checkSlice(op string, rval, cval []string) bool {
 	}
 }
 
+// checkHasAny reports whether at least one of ids is in rval.
+func checkHasAny(rval, ids []string) bool {
+	for _, id := range ids {
+		if checkIn(id, rval) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkHasAll reports whether all of ids are in rval.
+func checkHasAll(rval, ids []string) bool {
+	for _, id := range ids {
+		if !checkIn(id, rval) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func checkIn(id string, ids []string) bool {
 	for i := range ids {
 		if id == ids[i] {
@@ -501,3 +1088,44 @@ func checkIn(id string, ids []string) bool {
 
 	return false
 }
+
+// checkInList reports whether val, which must not be null, is equal to one of
+// the values of list, a slice. Pointers are compared by the values they point
+// to and null values in list are ignored.
+func checkInList(val, list interface{}) bool {
+	val = derefValue(val)
+
+	if ids, ok := list.([]string); ok {
+		id, _ := val.(string)
+		return checkIn(id, ids)
+	}
+
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice {
+		return false
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		elem := derefValue(rv.Index(i).Interface())
+		if elem != nil && checkVal("=", "", val, elem) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// derefValue returns the value v points to if it is a pointer, nil if it is a
+// nil pointer, or v itself otherwise.
+func derefValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+
+	if rv.IsNil() {
+		return nil
+	}
+
+	return rv.Elem().Interface()
+}
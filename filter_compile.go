@@ -0,0 +1,411 @@
+package jsonapi
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Compile validates the filter against the type named typ of schema and
+// returns a predicate that reports whether a resource of that type is allowed
+// by the filter, exactly like IsAllowed.
+//
+// The filter is coerced once (see Coerce), and the fields, the operators, and
+// the values are resolved in advance. This makes the predicate much faster
+// than IsAllowed when many resources are checked. Later changes to the filter
+// do not affect the predicate.
+//
+// The returned error is the one returned by Coerce.
+func (f *Filter) Compile(schema *Schema, typ string) (func(Resource) bool, error) {
+	return f.CompileWith(schema, typ, nil)
+}
+
+// CompileWith is like Compile, but r is used to load the related resources
+// when a field goes through relationships, like IsAllowedWith does.
+func (f *Filter) CompileWith(
+	schema *Schema, typ string, r Resolver,
+) (func(Resource) bool, error) {
+	cf, err := f.Coerce(schema, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	pred := compileFilter(schema, typ, cf, r)
+
+	return func(res Resource) bool {
+		return pred(res) == triTrue
+	}, nil
+}
+
+// compileFilter returns a function that evaluates f, a coerced filter, for a
+// resource.
+func compileFilter(schema *Schema, typ string, f *Filter, r Resolver) func(Resource) tri {
+	switch f.Op {
+	case "and", "or":
+		filters := f.Val.([]*Filter)
+
+		preds := make([]func(Resource) tri, len(filters))
+		for i := range filters {
+			preds[i] = compileFilter(schema, typ, filters[i], r)
+		}
+
+		// The result of and is the smallest one and the result
+		// of or is the greatest one, so the evaluation stops as
+		// soon as it is known.
+		stop, result := triFalse, triTrue
+		if f.Op == "or" {
+			stop, result = triTrue, triFalse
+		}
+
+		return func(res Resource) tri {
+			t := result
+
+			for _, pred := range preds {
+				switch pred(res) {
+				case stop:
+					return stop
+				case triUnknown:
+					t = triUnknown
+				}
+			}
+
+			return t
+		}
+	case "not":
+		pred := compileFilter(schema, typ, f.Val.(*Filter), r)
+
+		return func(res Resource) tri {
+			return triTrue - pred(res)
+		}
+	}
+
+	if strings.Contains(f.Field, ".") {
+		// Paths load related resources, which costs much more
+		// than walking the filter.
+		return func(res Resource) tri {
+			return f.eval(res, r)
+		}
+	}
+
+	// The field exists since the filter is coerced.
+	attr, rel, _ := schema.ResolvePath(typ, f.Field)
+	check := compileCheck(f, attr, rel)
+	name := f.Field
+
+	return func(res Resource) tri {
+		return check(res.Get(name))
+	}
+}
+
+// compileCheck returns a function that reports whether the value of the
+// attribute or the relationship satisfies f, a coerced filter.
+func compileCheck(f *Filter, attr Attr, rel Rel) func(interface{}) tri {
+	op := f.Op
+
+	switch {
+	case op == "is-null" || op == "is-not-null":
+		null := op == "is-null"
+
+		return func(v interface{}) tri {
+			return toTri(isNull(v) == null)
+		}
+	case (op == "=" || op == "!=") && isNull(f.Val):
+		null := op == "="
+
+		return func(v interface{}) tri {
+			return toTri(isNull(v) == null)
+		}
+	case op == "in" || op == "not-in":
+		return compileInCheck(op == "in", f.Val)
+	case rel.FromName != "" && !rel.ToOne:
+		return compileToManyCheck(op, f.Val)
+	case rel.FromName != "" || attr.Type == AttrTypeString:
+		return compileStringCheck(op, f.Col, f.Val)
+	}
+
+	cmp := compileComparison(attr.Type, f.Val)
+
+	return func(v interface{}) tri {
+		if isNull(v) {
+			return triUnknown
+		}
+
+		return toTri(cmp(op, v))
+	}
+}
+
+// compileInCheck returns a function that checks whether a value is part of
+// list, or not part of it if in is false. The result is unknown if the value is
+// null.
+func compileInCheck(in bool, list interface{}) func(interface{}) tri {
+	if ids, ok := list.([]string); ok {
+		set := stringSet(ids)
+
+		return func(v interface{}) tri {
+			s, ok := asString(v)
+			if !ok {
+				return triUnknown
+			}
+
+			_, found := set[s]
+
+			return toTri(found == in)
+		}
+	}
+
+	return func(v interface{}) tri {
+		if isNull(v) {
+			return triUnknown
+		}
+
+		return toTri(checkInList(v, list) == in)
+	}
+}
+
+// compileToManyCheck returns a function that checks the IDs of a to-many
+// relationship.
+func compileToManyCheck(op string, cval interface{}) func(interface{}) tri {
+	switch op {
+	case "has":
+		id := cval.(string)
+
+		return func(v interface{}) tri {
+			return toTri(checkIn(id, v.([]string)))
+		}
+	case "has-any":
+		set := stringSet(cval.([]string))
+
+		return func(v interface{}) tri {
+			for _, id := range v.([]string) {
+				if _, ok := set[id]; ok {
+					return triTrue
+				}
+			}
+
+			return triFalse
+		}
+	case "has-all":
+		ids := cval.([]string)
+
+		return func(v interface{}) tri {
+			return toTri(checkHasAll(v.([]string), ids))
+		}
+	default:
+		ids := append([]string(nil), cval.([]string)...)
+		sort.Strings(ids)
+
+		return func(v interface{}) tri {
+			rval := append([]string(nil), v.([]string)...)
+
+			return toTri(checkSlice(op, rval, ids))
+		}
+	}
+}
+
+// compileStringCheck returns a function that checks a string attribute or a
+// to-one relationship with op and the collation col.
+func compileStringCheck(op, col string, cval interface{}) func(interface{}) tri {
+	cv, _ := asString(cval)
+
+	var check func(string) bool
+
+	switch {
+	case isTextOp(op):
+		check = compileText(op, col, cv)
+	case col == CollationBinary:
+		check = func(s string) bool {
+			return checkInt(op, int64(strings.Compare(s, cv)), 0)
+		}
+	default:
+		check = func(s string) bool {
+			return checkStr(op, col, s, cv)
+		}
+	}
+
+	return func(v interface{}) tri {
+		s, ok := asString(v)
+		if !ok {
+			return triUnknown
+		}
+
+		return toTri(check(s))
+	}
+}
+
+// compileComparison returns a function that compares a value of an attribute
+// of type typ with cval using an operator. The value must not be null.
+func compileComparison(typ int, cval interface{}) func(string, interface{}) bool {
+	switch typ {
+	case AttrTypeInt, AttrTypeInt8, AttrTypeInt16, AttrTypeInt32, AttrTypeInt64:
+		cv, _ := asInt64(cval)
+
+		return func(op string, v interface{}) bool {
+			rv, _ := asInt64(v)
+			return checkInt(op, rv, cv)
+		}
+	case AttrTypeUint, AttrTypeUint8, AttrTypeUint16, AttrTypeUint32, AttrTypeUint64:
+		cv, _ := asUint64(cval)
+
+		return func(op string, v interface{}) bool {
+			rv, _ := asUint64(v)
+			return checkUint(op, rv, cv)
+		}
+	case AttrTypeFloat32, AttrTypeFloat64:
+		cv, _ := asFloat64(cval)
+
+		return func(op string, v interface{}) bool {
+			rv, _ := asFloat64(v)
+			return checkFloat(op, rv, cv)
+		}
+	case AttrTypeTime:
+		cv, _ := asTime(cval)
+
+		return func(op string, v interface{}) bool {
+			rv, _ := asTime(v)
+			return checkTime(op, rv, cv)
+		}
+	default:
+		// Booleans, bytes, decimals, and UUIDs are rarely
+		// compared, so checkVal is good enough.
+		return func(op string, v interface{}) bool {
+			return checkVal(op, "", v, cval)
+		}
+	}
+}
+
+// stringSet returns a set that holds strs.
+func stringSet(strs []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(strs))
+	for _, str := range strs {
+		set[str] = struct{}{}
+	}
+
+	return set
+}
+
+// asString returns v as a string, or false if it is a nil *string.
+func asString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case *string:
+		if v != nil {
+			return *v, true
+		}
+	}
+
+	return "", false
+}
+
+// asInt64 returns v, a signed integer or a pointer to one, as an int64, or
+// false if it is nil.
+func asInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case *int:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int8:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int16:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int32:
+		if v != nil {
+			return int64(*v), true
+		}
+	case *int64:
+		if v != nil {
+			return *v, true
+		}
+	}
+
+	return 0, false
+}
+
+// asUint64 returns v, an unsigned integer or a pointer to one, as a uint64, or
+// false if it is nil.
+func asUint64(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case *uint:
+		if v != nil {
+			return uint64(*v), true
+		}
+	case *uint8:
+		if v != nil {
+			return uint64(*v), true
+		}
+	case *uint16:
+		if v != nil {
+			return uint64(*v), true
+		}
+	case *uint32:
+		if v != nil {
+			return uint64(*v), true
+		}
+	case *uint64:
+		if v != nil {
+			return *v, true
+		}
+	}
+
+	return 0, false
+}
+
+// asFloat64 returns v, a float or a pointer to one, as a float64, or false if
+// it is nil.
+func asFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case *float32:
+		if v != nil {
+			return float64(*v), true
+		}
+	case *float64:
+		if v != nil {
+			return *v, true
+		}
+	}
+
+	return 0, false
+}
+
+// asTime returns v as a time.Time, or false if it is a nil *time.Time.
+func asTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,121 @@
+package jsonapi_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterCompile(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ := schema.GetType("mocktypes2")
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	col := &SoftCollection{}
+	col.SetType(&typ)
+
+	for i := 0; i < 6; i++ {
+		res := &SoftResource{Type: &typ}
+		res.SetID(string(rune('a' + i)))
+
+		if i%3 != 0 {
+			res.Set("strptr", ptr([]string{"", "abc", "ABC", "déf", "xyz", "Abc"}[i]))
+			res.Set("intptr", ptr(i-3))
+			res.Set("uint8ptr", ptr(uint8(i)))
+			res.Set("boolptr", ptr(i%2 == 0))
+			res.Set("timeptr", ptr(now.Add(time.Duration(i)*time.Hour)))
+		}
+
+		res.Set("to-one-from-many", []string{"", "a", "b"}[i%3])
+		res.Set("to-many-from-many", [][]string{{}, {"a"}, {"a", "b"}}[i%3])
+
+		col.Add(res)
+	}
+
+	filters := []*Filter{
+		{Field: "strptr", Op: "=", Val: ptr("abc")},
+		{Field: "strptr", Op: "=", Val: ptr("abc"), Col: "ci"},
+		{Field: "strptr", Op: ">=", Val: ptr("abd"), Col: "unicode"},
+		{Field: "strptr", Op: "=", Val: nilptr("string")},
+		{Field: "strptr", Op: "!=", Val: nilptr("string")},
+		{Field: "strptr", Op: "like", Val: "_b%", Col: "ci"},
+		{Field: "strptr", Op: "regex", Val: "^d.f$", Col: "ai"},
+		{Field: "strptr", Op: "is-null"},
+		{Field: "intptr", Op: ">", Val: ptr(-2)},
+		{Field: "intptr", Op: "!=", Val: ptr(0)},
+		{Field: "intptr", Op: "is-not-null"},
+		{Field: "uint8ptr", Op: "<=", Val: ptr(uint8(4))},
+		{Field: "boolptr", Op: "=", Val: ptr(true)},
+		{Field: "timeptr", Op: ">", Val: ptr(now.Add(2 * time.Hour))},
+		{Field: "strptr", Op: "in", Val: []string{"abc", "xyz"}},
+		{Field: "strptr", Op: "not-in", Val: []string{"abc"}},
+		{Field: "intptr", Op: "in", Val: []interface{}{ptr(-1), ptr(2)}},
+		{Field: "intptr", Op: "not-in", Val: []int{-1}},
+		{Field: "uint8ptr", Op: "in", Val: []interface{}{ptr(uint8(4))}},
+		{Field: "boolptr", Op: "not-in", Val: []bool{true}},
+		{Field: "timeptr", Op: "in", Val: []time.Time{now.Add(time.Hour)}},
+		{Op: "not", Val: &Filter{Field: "intptr", Op: "in", Val: []int{}}},
+		{Field: "to-one-from-many", Op: "=", Val: "a"},
+		{Field: "to-one-from-many", Op: "not-in", Val: []string{"a", "b"}},
+		{Field: "to-many-from-many", Op: "=", Val: []string{"b", "a"}},
+		{Field: "to-many-from-many", Op: "has", Val: "b"},
+		{Field: "to-many-from-many", Op: "has-any", Val: []string{"b", "c"}},
+		{Field: "to-many-from-many", Op: "has-all", Val: []string{"a", "b"}},
+		{Op: "and", Val: []*Filter{}},
+		{Op: "or", Val: []*Filter{}},
+		{Op: "not", Val: &Filter{Field: "intptr", Op: "<", Val: ptr(0)}},
+		{Op: "or", Val: []*Filter{
+			{Field: "intptr", Op: "<", Val: ptr(0)},
+			{Op: "not", Val: &Filter{Op: "and", Val: []*Filter{
+				{Field: "uint8ptr", Op: ">", Val: ptr(uint8(1))},
+				{Field: "to-many-from-many", Op: "has", Val: "a"},
+			}}},
+		}},
+	}
+
+	for _, filter := range filters {
+		allowed, err := filter.Compile(schema, "mocktypes2")
+		assert.NoError(err)
+
+		for i := 0; i < col.Len(); i++ {
+			res := col.At(i)
+			assert.Equal(
+				filter.IsAllowed(res),
+				allowed(res),
+				"%s %s %v on %s", filter.Field, filter.Op, filter.Val, res.Get("id"),
+			)
+		}
+	}
+
+	// Values are coerced once and the filter can change afterwards.
+	filter := &Filter{Field: "intptr", Op: ">=", Val: float64(1)}
+	allowed, err := filter.Compile(schema, "mocktypes2")
+	assert.NoError(err)
+
+	filter.Val = "not a number"
+
+	ids := []string{}
+
+	for i := 0; i < col.Len(); i++ {
+		if allowed(col.At(i)) {
+			ids = append(ids, col.At(i).Get("id").(string))
+		}
+	}
+
+	assert.Equal([]string{"e", "f"}, ids)
+
+	// Range compiles the filter with the type of the collection.
+	filter.Val = float64(1)
+	page := Range(col, nil, filter, []string{}, 10, 0)
+	assert.Equal(2, page.Len())
+
+	// Invalid filter
+	filter = &Filter{Field: "unknown", Op: "=", Val: "a"}
+	_, err = filter.Compile(schema, "mocktypes2")
+	assert.Equal(NewErrUnknownFieldInFilterParameter("unknown"), err)
+}
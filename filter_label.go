@@ -0,0 +1,201 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A FilterTemplate is the filter that a label stands for.
+//
+// A label can be used in a URL instead of a JSON filter, like filter=recent.
+// It can also take arguments, like filter=recent(7) or filter=between(1,10).
+// Params holds the names of those arguments, in order.
+//
+// Each value of Filter (or element of a value that is a list) that is a
+// string of the form $name is replaced by the argument with that name. The
+// argument is kept as a string if the field is a string attribute or a
+// relationship, or if the operator is a text operator. Otherwise, it is
+// decoded as JSON so that numbers and booleans can be given, and kept as a
+// string if that fails. The filter is then coerced like any other filter.
+type FilterTemplate struct {
+	Params []string
+	Filter *Filter
+}
+
+// AddFilterLabel registers the template tmpl under label for the type named
+// typ. The label is then resolved by NewParams.
+func (s *Schema) AddFilterLabel(typ, label string, tmpl FilterTemplate) error {
+	if !s.HasType(typ) {
+		return fmt.Errorf("jsonapi: type %q does not exist", typ)
+	}
+
+	if label == "" || strings.ContainsAny(label, "(),{") {
+		return fmt.Errorf("jsonapi: filter label %q is invalid", label)
+	}
+
+	if tmpl.Filter == nil {
+		return fmt.Errorf("jsonapi: filter label %q has no filter", label)
+	}
+
+	for i, param := range tmpl.Params {
+		for _, param2 := range tmpl.Params[i+1:] {
+			if param == param2 {
+				return fmt.Errorf("jsonapi: parameter %q of filter label %q is duplicated",
+					param, label)
+			}
+		}
+	}
+
+	if _, ok := s.filterLabels[typ][label]; ok {
+		return fmt.Errorf("jsonapi: filter label %q of type %q is already used", label, typ)
+	}
+
+	if s.filterLabels == nil {
+		s.filterLabels = map[string]map[string]FilterTemplate{}
+	}
+
+	if s.filterLabels[typ] == nil {
+		s.filterLabels[typ] = map[string]FilterTemplate{}
+	}
+
+	s.filterLabels[typ][label] = tmpl
+
+	return nil
+}
+
+// RemoveFilterLabel removes the filter label named label from the type named
+// typ.
+func (s *Schema) RemoveFilterLabel(typ, label string) {
+	delete(s.filterLabels[typ], label)
+}
+
+// FilterLabel returns the template registered under label for the type named
+// typ and whether it was found.
+func (s *Schema) FilterLabel(typ, label string) (FilterTemplate, bool) {
+	tmpl, ok := s.filterLabels[typ][label]
+	return tmpl, ok
+}
+
+// ResolveFilterLabel returns the filter that label stands for in the type
+// named typ, coerced like Filter.Coerce does.
+//
+// The label is the raw value of the filter parameter, arguments included. The
+// returned error is an Error that reports an unknown label, a malformed label,
+// or an invalid filter.
+func (s *Schema) ResolveFilterLabel(typ, label string) (*Filter, error) {
+	name, args, ok := parseFilterLabel(label)
+	if !ok {
+		return nil, NewErrMalformedFilterParameter(label)
+	}
+
+	tmpl, ok := s.FilterLabel(typ, name)
+	if !ok {
+		return nil, NewErrUnknownFilterParameterLabel(name)
+	}
+
+	if len(args) != len(tmpl.Params) {
+		return nil, NewErrMalformedFilterParameter(label)
+	}
+
+	vals := make(map[string]string, len(args))
+	for i := range args {
+		vals["$"+tmpl.Params[i]] = args[i]
+	}
+
+	return s.expandFilter(typ, tmpl.Filter, vals).Coerce(s, typ)
+}
+
+// expandFilter returns a copy of f where the placeholders found in vals are
+// replaced by their arguments.
+func (s *Schema) expandFilter(typ string, f *Filter, vals map[string]string) *Filter {
+	ef := &Filter{
+		Field: f.Field,
+		Op:    f.Op,
+		Val:   f.Val,
+		Col:   f.Col,
+	}
+
+	if sub, ok := f.Val.(*Filter); ok {
+		ef.Val = s.expandFilter(typ, sub, vals)
+		return ef
+	}
+
+	if filters, ok := f.Val.([]*Filter); ok {
+		efilters := make([]*Filter, len(filters))
+		for i := range filters {
+			efilters[i] = s.expandFilter(typ, filters[i], vals)
+		}
+
+		ef.Val = efilters
+
+		return ef
+	}
+
+	attr, rel, _ := s.ResolvePath(typ, f.Field)
+	raw := rel.FromName != "" || attr.Type == AttrTypeString || isTextOp(f.Op)
+
+	arg := func(v interface{}) interface{} {
+		str, ok := v.(string)
+		if !ok {
+			return v
+		}
+
+		val, ok := vals[str]
+		if !ok {
+			return v
+		}
+
+		var dec interface{}
+		if !raw && json.Unmarshal([]byte(val), &dec) == nil {
+			return dec
+		}
+
+		return val
+	}
+
+	switch v := f.Val.(type) {
+	case []string:
+		evals := make([]interface{}, len(v))
+		for i := range v {
+			evals[i] = arg(v[i])
+		}
+
+		ef.Val = evals
+	case []interface{}:
+		evals := make([]interface{}, len(v))
+		for i := range v {
+			evals[i] = arg(v[i])
+		}
+
+		ef.Val = evals
+	default:
+		ef.Val = arg(v)
+	}
+
+	return ef
+}
+
+// parseFilterLabel splits label into its name and its arguments. The arguments
+// are given between parentheses and separated by commas.
+func parseFilterLabel(label string) (string, []string, bool) {
+	open := strings.IndexByte(label, '(')
+	if open == -1 {
+		return label, nil, !strings.ContainsAny(label, "),")
+	}
+
+	if open == 0 || !strings.HasSuffix(label, ")") {
+		return "", nil, false
+	}
+
+	name, list := label[:open], label[open+1:len(label)-1]
+	if strings.ContainsAny(list, "()") {
+		return "", nil, false
+	}
+
+	if list == "" {
+		return name, nil, true
+	}
+
+	return name, strings.Split(list, ","), true
+}
@@ -0,0 +1,152 @@
+package jsonapi_test
+
+import (
+	"net/url"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaFilterLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	// Registration
+	err := schema.AddFilterLabel("mocktypes1", "active", FilterTemplate{
+		Filter: &Filter{Field: "bool", Op: "=", Val: true},
+	})
+	assert.NoError(err)
+
+	err = schema.AddFilterLabel("mocktypes1", "between", FilterTemplate{
+		Params: []string{"min", "max"},
+		Filter: &Filter{
+			Op: "and",
+			Val: []*Filter{
+				{Field: "int", Op: ">=", Val: "$min"},
+				{Field: "int", Op: "<=", Val: "$max"},
+			},
+		},
+	})
+	assert.NoError(err)
+
+	err = schema.AddFilterLabel("mocktypes1", "named", FilterTemplate{
+		Params: []string{"name"},
+		Filter: &Filter{Field: "str", Op: "in", Val: []interface{}{"$name", "default"}},
+	})
+	assert.NoError(err)
+
+	err = schema.AddFilterLabel("mocktypes1", "active", FilterTemplate{
+		Filter: &Filter{Field: "bool", Op: "=", Val: false},
+	})
+	assert.EqualError(err, `jsonapi: filter label "active" of type "mocktypes1" is already used`)
+
+	err = schema.AddFilterLabel("unknown", "active", FilterTemplate{Filter: &Filter{}})
+	assert.EqualError(err, `jsonapi: type "unknown" does not exist`)
+
+	err = schema.AddFilterLabel("mocktypes1", "a(b)", FilterTemplate{Filter: &Filter{}})
+	assert.EqualError(err, `jsonapi: filter label "a(b)" is invalid`)
+
+	err = schema.AddFilterLabel("mocktypes1", "empty", FilterTemplate{})
+	assert.EqualError(err, `jsonapi: filter label "empty" has no filter`)
+
+	err = schema.AddFilterLabel("mocktypes1", "dup", FilterTemplate{
+		Params: []string{"a", "a"},
+		Filter: &Filter{},
+	})
+	assert.EqualError(err, `jsonapi: parameter "a" of filter label "dup" is duplicated`)
+
+	tmpl, ok := schema.FilterLabel("mocktypes1", "active")
+	assert.True(ok)
+	assert.Equal(&Filter{Field: "bool", Op: "=", Val: true}, tmpl.Filter)
+
+	_, ok = schema.FilterLabel("mocktypes2", "active")
+	assert.False(ok)
+
+	// Resolution
+	tests := []struct {
+		name           string
+		label          string
+		expectedFilter *Filter
+		expectedError  error
+	}{
+		{
+			name:           "without parameters",
+			label:          "active",
+			expectedFilter: &Filter{Field: "bool", Op: "=", Val: true},
+		}, {
+			name:  "with parameters",
+			label: "between(2,8)",
+			expectedFilter: &Filter{
+				Op: "and",
+				Val: []*Filter{
+					{Field: "int", Op: ">=", Val: 2},
+					{Field: "int", Op: "<=", Val: 8},
+				},
+			},
+		}, {
+			name:           "string parameter",
+			label:          "named(123)",
+			expectedFilter: &Filter{Field: "str", Op: "in", Val: []string{"123", "default"}},
+		}, {
+			name:          "unknown label",
+			label:         "inactive",
+			expectedError: NewErrUnknownFilterParameterLabel("inactive"),
+		}, {
+			name:          "unknown label with parameters",
+			label:         "inactive(1)",
+			expectedError: NewErrUnknownFilterParameterLabel("inactive"),
+		}, {
+			name:          "wrong number of parameters",
+			label:         "between(2)",
+			expectedError: NewErrMalformedFilterParameter("between(2)"),
+		}, {
+			name:          "unclosed parenthesis",
+			label:         "between(2,8",
+			expectedError: NewErrMalformedFilterParameter("between(2,8"),
+		}, {
+			name:          "invalid parameter",
+			label:         "between(a,8)",
+			expectedError: NewErrInvalidValueInFilterParameter(`"a"`, "int"),
+		},
+	}
+
+	for _, test := range tests {
+		filter, err := schema.ResolveFilterLabel("mocktypes1", test.label)
+		assert.Equal(test.expectedError, err, test.name)
+
+		if test.expectedError == nil {
+			assert.Equal(test.expectedFilter, filter, test.name)
+		}
+	}
+
+	// NewParams
+	u, _ := url.Parse("/mocktypes1?filter=between(2,8)")
+	su, err := NewSimpleURL(u)
+	assert.NoError(err)
+
+	params, err := NewParams(schema, su, "mocktypes1")
+	assert.NoError(err)
+	assert.Equal("between(2,8)", params.FilterLabel)
+	assert.Equal(&Filter{
+		Op: "and",
+		Val: []*Filter{
+			{Field: "int", Op: ">=", Val: 2},
+			{Field: "int", Op: "<=", Val: 8},
+		},
+	}, params.Filter)
+
+	u, _ = url.Parse("/mocktypes2?filter=active")
+	su, err = NewSimpleURL(u)
+	assert.NoError(err)
+
+	_, err = NewParams(schema, su, "mocktypes2")
+	assert.Equal(NewErrUnknownFilterParameterLabel("active"), err)
+
+	// Removal
+	schema.RemoveFilterLabel("mocktypes1", "active")
+	_, ok = schema.FilterLabel("mocktypes1", "active")
+	assert.False(ok)
+}
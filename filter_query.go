@@ -0,0 +1,243 @@
+package jsonapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterOpAliases maps the operator names of the bracket syntax to the
+// operators of a Filter. Other operators (like in or contains) are used as is.
+var filterOpAliases = map[string]string{
+	"eq":  "=",
+	"ne":  "!=",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+// Filter styles are the ways URL.String can write the filter of a URL (see
+// Params.FilterStyle).
+//
+// FilterStyleJSON writes the filter as a JSON object, like
+// filter={"f":"name","o":"=","v":"abc","c":""}. FilterStyleBrackets uses the
+// bracket syntax, like filter[name]=abc, when the filter can be written that
+// way and JSON otherwise.
+const (
+	FilterStyleJSON     = ""
+	FilterStyleBrackets = "brackets"
+)
+
+// parseFilterParams builds a filter from the filter parameters of values that
+// use the bracket syntax, or returns nil if there are none.
+//
+// A parameter like filter[name]=x checks whether the field is equal to the
+// value and filter[age][gt]=30 uses the given operator. A third name sets the
+// collation, like filter[name][like][ci]=a%. The values of the in, not-in,
+// has-any, and has-all operators are separated by commas, and the values of
+// is-null and is-not-null are ignored. When many filters are given, they must
+// all be satisfied.
+//
+// The values are strings and are converted later by Filter.Coerce.
+func parseFilterParams(values url.Values) (*Filter, error) {
+	names := []string{}
+
+	for name := range values {
+		if strings.HasPrefix(name, "filter[") {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	filters := []*Filter{}
+
+	for _, name := range names {
+		keys, ok := parseBrackets(name[len("filter"):])
+		if !ok || len(keys) > 3 {
+			return nil, NewErrMalformedFilterParameter(name)
+		}
+
+		f := Filter{Field: keys[0], Op: "="}
+
+		if len(keys) >= 2 {
+			f.Op = keys[1]
+			if op, ok := filterOpAliases[f.Op]; ok {
+				f.Op = op
+			}
+		}
+
+		if len(keys) == 3 {
+			f.Col = keys[2]
+		}
+
+		for _, val := range values[name] {
+			cf := f
+
+			switch f.Op {
+			case "in", "not-in", "has-any", "has-all":
+				cf.Val = parseCommaList(val)
+			case "is-null", "is-not-null":
+				cf.Val = nil
+			default:
+				cf.Val = val
+			}
+
+			filters = append(filters, &cf)
+		}
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil, nil
+	case 1:
+		return filters[0], nil
+	default:
+		return &Filter{Op: "and", Val: filters}, nil
+	}
+}
+
+// parseBrackets returns the names found in s, a list of non-empty names
+// enclosed in brackets like [a][b].
+func parseBrackets(s string) ([]string, bool) {
+	keys := []string{}
+
+	for s != "" {
+		end := strings.IndexByte(s, ']')
+		if s[0] != '[' || end < 2 || strings.IndexByte(s[1:end], '[') != -1 {
+			return nil, false
+		}
+
+		keys = append(keys, s[1:end])
+		s = s[end+1:]
+	}
+
+	return keys, len(keys) > 0
+}
+
+// filterParams returns the escaped query parameters that represent f in the
+// bracket syntax, or false if f cannot be represented that way.
+//
+// This is the case when f has nested conditions other than a single top level
+// and, or when one of its values has no unambiguous text form.
+func filterParams(f *Filter) ([]string, bool) {
+	if f == nil {
+		return nil, false
+	}
+
+	filters := []*Filter{f}
+
+	if f.Op == "and" {
+		var ok bool
+		if filters, ok = f.Val.([]*Filter); !ok || len(filters) == 0 {
+			return nil, false
+		}
+	}
+
+	params := make([]string, 0, len(filters))
+
+	for _, f := range filters {
+		if f.Field == "" || strings.ContainsAny(f.Field, "[]") || f.Op == "and" ||
+			f.Op == "or" || f.Op == "not" {
+			return nil, false
+		}
+
+		val, ok := "", f.Val == nil
+		if f.Op != "is-null" && f.Op != "is-not-null" {
+			val, ok = filterValText(f.Op, f.Val)
+		}
+
+		if !ok {
+			return nil, false
+		}
+
+		op := f.Op
+
+		for alias, op2 := range filterOpAliases {
+			if op == op2 {
+				op = alias
+			}
+		}
+
+		key := "filter%5B" + f.Field + "%5D"
+		if op != "eq" || f.Col != "" {
+			key += "%5B" + op + "%5D"
+		}
+
+		if f.Col != "" {
+			key += "%5B" + f.Col + "%5D"
+		}
+
+		val = strings.ReplaceAll(url.QueryEscape(val), "+", "%20")
+		params = append(params, key+"="+val)
+	}
+
+	sort.Strings(params)
+
+	return params, true
+}
+
+// filterValText returns the text form of v, a value of a filter with the
+// operator op, as parsed by Filter.Coerce.
+func filterValText(op string, v interface{}) (string, bool) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false
+		}
+
+		v = rv.Elem().Interface()
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []string:
+		if op != "in" && op != "not-in" && op != "has-any" && op != "has-all" {
+			return "", false
+		}
+
+		for _, s := range v {
+			if s == "" || strings.Contains(s, ",") {
+				return "", false
+			}
+		}
+
+		return strings.Join(v, ","), true
+	case []interface{}:
+		if op != "in" && op != "not-in" {
+			return "", false
+		}
+
+		texts := make([]string, len(v))
+
+		for i := range v {
+			text, ok := filterValText("=", v[i])
+			if !ok || text == "" || strings.Contains(text, ",") {
+				return "", false
+			}
+
+			texts[i] = text
+		}
+
+		return strings.Join(texts, ","), true
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool,
+		Decimal, UUID:
+		return fmt.Sprint(v), true
+	}
+
+	return "", false
+}
@@ -17,6 +17,11 @@ func TestFilterResource(t *testing.T) {
 
 	now := time.Now()
 
+	dec1 := MustParseDecimal("1")
+	uuid1 := MustParseUUID("00000000-0000-0000-0000-000000000001")
+	uuid2 := MustParseUUID("00000000-0000-0000-0000-000000000002")
+	uuid3 := MustParseUUID("00000000-0000-0000-0000-000000000003")
+
 	// Tests for attributes
 	attrTests := []struct {
 		rval     interface{}
@@ -301,7 +306,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr("bbb"), op: "=", cval: ptr("aaa"), expected: false},
 		{rval: ptr("bbb"), op: "=", cval: ptr("bbb"), expected: true},
 		{rval: nilptr("string"), op: "!=", cval: nilptr("string"), expected: false},
-		{rval: nilptr("string"), op: "!=", cval: ptr("aaa"), expected: true},
+		{rval: nilptr("string"), op: "!=", cval: ptr("aaa"), expected: false},
 		{rval: ptr("bbb"), op: "!=", cval: nilptr("string"), expected: true},
 		{rval: ptr("bbb"), op: "!=", cval: ptr("aaa"), expected: true},
 		{rval: ptr("bbb"), op: "!=", cval: ptr("bbb"), expected: false},
@@ -337,7 +342,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(0), op: "=", cval: ptr(-1), expected: false},
 		{rval: ptr(0), op: "=", cval: ptr(0), expected: true},
 		{rval: nilptr("int"), op: "!=", cval: nilptr("int"), expected: false},
-		{rval: nilptr("int"), op: "!=", cval: ptr(-1), expected: true},
+		{rval: nilptr("int"), op: "!=", cval: ptr(-1), expected: false},
 		{rval: ptr(0), op: "!=", cval: nilptr("int"), expected: true},
 		{rval: ptr(0), op: "!=", cval: ptr(-1), expected: true},
 		{rval: ptr(0), op: "!=", cval: ptr(0), expected: false},
@@ -373,7 +378,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(int8(0)), op: "=", cval: ptr(int8(-1)), expected: false},
 		{rval: ptr(int8(0)), op: "=", cval: ptr(int8(0)), expected: true},
 		{rval: nilptr("int8"), op: "!=", cval: nilptr("int8"), expected: false},
-		{rval: nilptr("int8"), op: "!=", cval: ptr(int8(-1)), expected: true},
+		{rval: nilptr("int8"), op: "!=", cval: ptr(int8(-1)), expected: false},
 		{rval: ptr(int8(0)), op: "!=", cval: nilptr("int8"), expected: true},
 		{rval: ptr(int8(0)), op: "!=", cval: ptr(int8(-1)), expected: true},
 		{rval: ptr(int8(0)), op: "!=", cval: ptr(int8(0)), expected: false},
@@ -409,7 +414,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(int16(0)), op: "=", cval: ptr(int16(-1)), expected: false},
 		{rval: ptr(int16(0)), op: "=", cval: ptr(int16(0)), expected: true},
 		{rval: nilptr("int16"), op: "!=", cval: nilptr("int16"), expected: false},
-		{rval: nilptr("int16"), op: "!=", cval: ptr(int16(-1)), expected: true},
+		{rval: nilptr("int16"), op: "!=", cval: ptr(int16(-1)), expected: false},
 		{rval: ptr(int16(0)), op: "!=", cval: nilptr("int16"), expected: true},
 		{rval: ptr(int16(0)), op: "!=", cval: ptr(int16(-1)), expected: true},
 		{rval: ptr(int16(0)), op: "!=", cval: ptr(int16(0)), expected: false},
@@ -445,7 +450,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(int32(0)), op: "=", cval: ptr(int32(-1)), expected: false},
 		{rval: ptr(int32(0)), op: "=", cval: ptr(int32(0)), expected: true},
 		{rval: nilptr("int32"), op: "!=", cval: nilptr("int32"), expected: false},
-		{rval: nilptr("int32"), op: "!=", cval: ptr(int32(-1)), expected: true},
+		{rval: nilptr("int32"), op: "!=", cval: ptr(int32(-1)), expected: false},
 		{rval: ptr(int32(0)), op: "!=", cval: nilptr("int32"), expected: true},
 		{rval: ptr(int32(0)), op: "!=", cval: ptr(int32(-1)), expected: true},
 		{rval: ptr(int32(0)), op: "!=", cval: ptr(int32(0)), expected: false},
@@ -481,7 +486,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(int64(0)), op: "=", cval: ptr(int64(-1)), expected: false},
 		{rval: ptr(int64(0)), op: "=", cval: ptr(int64(0)), expected: true},
 		{rval: nilptr("int64"), op: "!=", cval: nilptr("int64"), expected: false},
-		{rval: nilptr("int64"), op: "!=", cval: ptr(int64(-1)), expected: true},
+		{rval: nilptr("int64"), op: "!=", cval: ptr(int64(-1)), expected: false},
 		{rval: ptr(int64(0)), op: "!=", cval: nilptr("int64"), expected: true},
 		{rval: ptr(int64(0)), op: "!=", cval: ptr(int64(-1)), expected: true},
 		{rval: ptr(int64(0)), op: "!=", cval: ptr(int64(0)), expected: false},
@@ -517,7 +522,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(uint(1)), op: "=", cval: ptr(uint(0)), expected: false},
 		{rval: ptr(uint(1)), op: "=", cval: ptr(uint(1)), expected: true},
 		{rval: nilptr("uint"), op: "!=", cval: nilptr("uint"), expected: false},
-		{rval: nilptr("uint"), op: "!=", cval: ptr(uint(0)), expected: true},
+		{rval: nilptr("uint"), op: "!=", cval: ptr(uint(0)), expected: false},
 		{rval: ptr(uint(1)), op: "!=", cval: nilptr("uint"), expected: true},
 		{rval: ptr(uint(1)), op: "!=", cval: ptr(uint(0)), expected: true},
 		{rval: ptr(uint(1)), op: "!=", cval: ptr(uint(1)), expected: false},
@@ -553,7 +558,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(uint8(1)), op: "=", cval: ptr(uint8(0)), expected: false},
 		{rval: ptr(uint8(1)), op: "=", cval: ptr(uint8(1)), expected: true},
 		{rval: nilptr("uint8"), op: "!=", cval: nilptr("uint8"), expected: false},
-		{rval: nilptr("uint8"), op: "!=", cval: ptr(uint8(0)), expected: true},
+		{rval: nilptr("uint8"), op: "!=", cval: ptr(uint8(0)), expected: false},
 		{rval: ptr(uint8(1)), op: "!=", cval: nilptr("uint8"), expected: true},
 		{rval: ptr(uint8(1)), op: "!=", cval: ptr(uint8(0)), expected: true},
 		{rval: ptr(uint8(1)), op: "!=", cval: ptr(uint8(1)), expected: false},
@@ -589,7 +594,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(uint16(1)), op: "=", cval: ptr(uint16(0)), expected: false},
 		{rval: ptr(uint16(1)), op: "=", cval: ptr(uint16(1)), expected: true},
 		{rval: nilptr("uint16"), op: "!=", cval: nilptr("uint16"), expected: false},
-		{rval: nilptr("uint16"), op: "!=", cval: ptr(uint16(0)), expected: true},
+		{rval: nilptr("uint16"), op: "!=", cval: ptr(uint16(0)), expected: false},
 		{rval: ptr(uint16(1)), op: "!=", cval: nilptr("uint16"), expected: true},
 		{rval: ptr(uint16(1)), op: "!=", cval: ptr(uint16(0)), expected: true},
 		{rval: ptr(uint16(1)), op: "!=", cval: ptr(uint16(1)), expected: false},
@@ -625,7 +630,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(uint32(1)), op: "=", cval: ptr(uint32(0)), expected: false},
 		{rval: ptr(uint32(1)), op: "=", cval: ptr(uint32(1)), expected: true},
 		{rval: nilptr("uint32"), op: "!=", cval: nilptr("uint32"), expected: false},
-		{rval: nilptr("uint32"), op: "!=", cval: ptr(uint32(0)), expected: true},
+		{rval: nilptr("uint32"), op: "!=", cval: ptr(uint32(0)), expected: false},
 		{rval: ptr(uint32(1)), op: "!=", cval: nilptr("uint32"), expected: true},
 		{rval: ptr(uint32(1)), op: "!=", cval: ptr(uint32(0)), expected: true},
 		{rval: ptr(uint32(1)), op: "!=", cval: ptr(uint32(1)), expected: false},
@@ -661,7 +666,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(uint64(1)), op: "=", cval: ptr(uint64(0)), expected: false},
 		{rval: ptr(uint64(1)), op: "=", cval: ptr(uint64(1)), expected: true},
 		{rval: nilptr("uint64"), op: "!=", cval: nilptr("uint64"), expected: false},
-		{rval: nilptr("uint64"), op: "!=", cval: ptr(uint64(0)), expected: true},
+		{rval: nilptr("uint64"), op: "!=", cval: ptr(uint64(0)), expected: false},
 		{rval: ptr(uint64(1)), op: "!=", cval: nilptr("uint64"), expected: true},
 		{rval: ptr(uint64(1)), op: "!=", cval: ptr(uint64(0)), expected: true},
 		{rval: ptr(uint64(1)), op: "!=", cval: ptr(uint64(1)), expected: false},
@@ -697,7 +702,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(true), op: "=", cval: ptr(true), expected: true},
 		{rval: ptr(true), op: "=", cval: ptr(false), expected: false},
 		{rval: nilptr("bool"), op: "!=", cval: nilptr("bool"), expected: false},
-		{rval: nilptr("bool"), op: "!=", cval: ptr(false), expected: true},
+		{rval: nilptr("bool"), op: "!=", cval: ptr(false), expected: false},
 		{rval: ptr(true), op: "!=", cval: nilptr("bool"), expected: true},
 		{rval: ptr(true), op: "!=", cval: ptr(true), expected: false},
 		{rval: ptr(true), op: "!=", cval: ptr(false), expected: true},
@@ -710,7 +715,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr(now), op: "=", cval: ptr(now.Add(-time.Second)), expected: false},
 		{rval: ptr(now), op: "=", cval: ptr(now), expected: true},
 		{rval: nilptr("time.Time"), op: "!=", cval: nilptr("time.Time"), expected: false},
-		{rval: nilptr("time.Time"), op: "!=", cval: ptr(now), expected: true},
+		{rval: nilptr("time.Time"), op: "!=", cval: ptr(now), expected: false},
 		{rval: ptr(now), op: "!=", cval: nilptr("time.Time"), expected: true},
 		{rval: ptr(now), op: "!=", cval: ptr(now.Add(-time.Second)), expected: true},
 		{rval: ptr(now), op: "!=", cval: ptr(now), expected: false},
@@ -746,7 +751,7 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr([]byte{1}), op: "=", cval: ptr([]byte{0}), expected: false},
 		{rval: ptr([]byte{1}), op: "=", cval: ptr([]byte{1}), expected: true},
 		{rval: nilptr("[]byte"), op: "!=", cval: nilptr("[]byte"), expected: false},
-		{rval: nilptr("[]byte"), op: "!=", cval: ptr([]byte{0}), expected: true},
+		{rval: nilptr("[]byte"), op: "!=", cval: ptr([]byte{0}), expected: false},
 		{rval: ptr([]byte{1}), op: "!=", cval: nilptr("[]byte"), expected: true},
 		{rval: ptr([]byte{1}), op: "!=", cval: ptr([]byte{0}), expected: true},
 		{rval: ptr([]byte{1}), op: "!=", cval: ptr([]byte{1}), expected: false},
@@ -775,6 +780,96 @@ func TestFilterResource(t *testing.T) {
 		{rval: ptr([]byte{1}), op: ">=", cval: ptr([]byte{1}), expected: true},
 		{rval: ptr([]byte{1}), op: ">=", cval: ptr([]byte{2}), expected: false},
 
+		// float32
+		{rval: float32(1.5), op: "=", cval: float32(0.5), expected: false},
+		{rval: float32(1.5), op: "=", cval: float32(1.5), expected: true},
+		{rval: float32(1.5), op: "=", cval: float32(2.5), expected: false},
+		{rval: float32(1.5), op: "!=", cval: float32(0.5), expected: true},
+		{rval: float32(1.5), op: "!=", cval: float32(1.5), expected: false},
+		{rval: float32(1.5), op: "!=", cval: float32(2.5), expected: true},
+		{rval: float32(1.5), op: "<", cval: float32(0.5), expected: false},
+		{rval: float32(1.5), op: "<", cval: float32(1.5), expected: false},
+		{rval: float32(1.5), op: "<", cval: float32(2.5), expected: true},
+		{rval: float32(1.5), op: "<=", cval: float32(0.5), expected: false},
+		{rval: float32(1.5), op: "<=", cval: float32(1.5), expected: true},
+		{rval: float32(1.5), op: "<=", cval: float32(2.5), expected: true},
+		{rval: float32(1.5), op: ">", cval: float32(0.5), expected: true},
+		{rval: float32(1.5), op: ">", cval: float32(1.5), expected: false},
+		{rval: float32(1.5), op: ">", cval: float32(2.5), expected: false},
+		{rval: float32(1.5), op: ">=", cval: float32(0.5), expected: true},
+		{rval: float32(1.5), op: ">=", cval: float32(1.5), expected: true},
+		{rval: float32(1.5), op: ">=", cval: float32(2.5), expected: false},
+
+		// float64
+		{rval: 1.5, op: "=", cval: 0.5, expected: false},
+		{rval: 1.5, op: "=", cval: 1.5, expected: true},
+		{rval: 1.5, op: "=", cval: 2.5, expected: false},
+		{rval: 1.5, op: "!=", cval: 0.5, expected: true},
+		{rval: 1.5, op: "!=", cval: 1.5, expected: false},
+		{rval: 1.5, op: "!=", cval: 2.5, expected: true},
+		{rval: 1.5, op: "<", cval: 0.5, expected: false},
+		{rval: 1.5, op: "<", cval: 1.5, expected: false},
+		{rval: 1.5, op: "<", cval: 2.5, expected: true},
+		{rval: 1.5, op: "<=", cval: 0.5, expected: false},
+		{rval: 1.5, op: "<=", cval: 1.5, expected: true},
+		{rval: 1.5, op: "<=", cval: 2.5, expected: true},
+		{rval: 1.5, op: ">", cval: 0.5, expected: true},
+		{rval: 1.5, op: ">", cval: 1.5, expected: false},
+		{rval: 1.5, op: ">", cval: 2.5, expected: false},
+		{rval: 1.5, op: ">=", cval: 0.5, expected: true},
+		{rval: 1.5, op: ">=", cval: 1.5, expected: true},
+		{rval: 1.5, op: ">=", cval: 2.5, expected: false},
+
+		// Decimal
+		{rval: MustParseDecimal("1.50"), op: "=", cval: MustParseDecimal("0.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "=", cval: MustParseDecimal("1.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: "=", cval: MustParseDecimal("1e1"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "!=", cval: MustParseDecimal("0.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: "!=", cval: MustParseDecimal("1.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "!=", cval: MustParseDecimal("1e1"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: "<", cval: MustParseDecimal("0.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "<", cval: MustParseDecimal("1.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "<", cval: MustParseDecimal("1e1"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: "<=", cval: MustParseDecimal("0.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: "<=", cval: MustParseDecimal("1.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: "<=", cval: MustParseDecimal("1e1"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: ">", cval: MustParseDecimal("0.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: ">", cval: MustParseDecimal("1.5"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: ">", cval: MustParseDecimal("1e1"), expected: false},
+		{rval: MustParseDecimal("1.50"), op: ">=", cval: MustParseDecimal("0.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: ">=", cval: MustParseDecimal("1.5"), expected: true},
+		{rval: MustParseDecimal("1.50"), op: ">=", cval: MustParseDecimal("1e1"), expected: false},
+
+		// UUID
+		{rval: uuid2, op: "=", cval: uuid1, expected: false},
+		{rval: uuid2, op: "=", cval: uuid2, expected: true},
+		{rval: uuid2, op: "=", cval: uuid3, expected: false},
+		{rval: uuid2, op: "!=", cval: uuid1, expected: true},
+		{rval: uuid2, op: "!=", cval: uuid2, expected: false},
+		{rval: uuid2, op: "!=", cval: uuid3, expected: true},
+		{rval: uuid2, op: "<", cval: uuid1, expected: false},
+		{rval: uuid2, op: "<", cval: uuid2, expected: false},
+		{rval: uuid2, op: "<", cval: uuid3, expected: true},
+		{rval: uuid2, op: "<=", cval: uuid1, expected: false},
+		{rval: uuid2, op: "<=", cval: uuid2, expected: true},
+		{rval: uuid2, op: "<=", cval: uuid3, expected: true},
+		{rval: uuid2, op: ">", cval: uuid1, expected: true},
+		{rval: uuid2, op: ">", cval: uuid2, expected: false},
+		{rval: uuid2, op: ">", cval: uuid3, expected: false},
+		{rval: uuid2, op: ">=", cval: uuid1, expected: true},
+		{rval: uuid2, op: ">=", cval: uuid2, expected: true},
+		{rval: uuid2, op: ">=", cval: uuid3, expected: false},
+
+		// *float64, *Decimal, and *UUID
+		{rval: nilptr("float64"), op: "=", cval: nilptr("float64"), expected: true},
+		{rval: ptr(1.5), op: "=", cval: nilptr("float64"), expected: false},
+		{rval: ptr(1.5), op: "<", cval: ptr(2.5), expected: true},
+		{rval: ptr(float32(1.5)), op: ">=", cval: ptr(float32(2.5)), expected: false},
+		{rval: nilptr("decimal"), op: "!=", cval: ptr(dec1), expected: false},
+		{rval: ptr(MustParseDecimal("1.0")), op: "=", cval: ptr(dec1), expected: true},
+		{rval: nilptr("uuid"), op: "<", cval: nilptr("uuid"), expected: false},
+		{rval: ptr(uuid1), op: "<", cval: ptr(uuid2), expected: true},
+
 		// Invalid type
 		{rval: func() {}, op: "=", cval: func() {}, expected: false},
 	}
@@ -1066,6 +1161,38 @@ func TestFilterUnmarshaling(t *testing.T) {
 				},
 			},
 			expectedError: false,
+		}, {
+			name: "not",
+			query: `{
+				"o": "not",
+				"v": {"f": "field", "o": "is-null", "v": null}
+			}`,
+			expectedFilter: Filter{
+				Op:  "not",
+				Val: &Filter{Field: "field", Op: "is-null"},
+			},
+			expectedError: false,
+		}, {
+			name: "has-any",
+			query: `{
+				"f": "field",
+				"o": "has-any",
+				"v": ["a", "b"]
+			}`,
+			expectedFilter: Filter{
+				Field: "field",
+				Op:    "has-any",
+				Val:   []interface{}{"a", "b"},
+			},
+			expectedError: false,
+		}, {
+			name: "invalid not",
+			query: `{
+				"o": "not",
+				"v": ["should", "not", "be", "an", "array"]
+			}`,
+			expectedFilter: Filter{},
+			expectedError:  true,
 		}, {
 			name: "invalid or",
 			query: `{
@@ -1089,3 +1216,338 @@ func TestFilterUnmarshaling(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterThreeValuedLogic(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := &Type{Name: "people"}
+	_ = typ.AddAttr(Attr{Name: "name", Type: AttrTypeString, Nullable: true})
+	_ = typ.AddAttr(Attr{Name: "age", Type: AttrTypeInt, Nullable: true})
+	_ = typ.AddAttr(Attr{Name: "city", Type: AttrTypeString})
+	_ = typ.AddRel(Rel{FromName: "friends", ToType: "people"})
+
+	res := &SoftResource{Type: typ}
+	res.Set("name", ptr("Alice"))
+	res.Set("city", "Montreal")
+	res.Set("friends", []string{"p1", "p2", "p3"})
+
+	// The age is null.
+	known := &Filter{Field: "name", Op: "=", Val: ptr("Alice")}
+	unknown := &Filter{Field: "age", Op: ">", Val: ptr(30)}
+	falsy := &Filter{Field: "city", Op: "=", Val: "Quebec"}
+
+	not := func(f *Filter) *Filter { return &Filter{Op: "not", Val: f} }
+	and := func(f ...*Filter) *Filter { return &Filter{Op: "and", Val: f} }
+	or := func(f ...*Filter) *Filter { return &Filter{Op: "or", Val: f} }
+
+	tests := []struct {
+		name     string
+		filter   *Filter
+		expected bool
+	}{
+		// Null checks
+		{"is null", &Filter{Field: "age", Op: "is-null"}, true},
+		{"is not null", &Filter{Field: "age", Op: "is-not-null"}, false},
+		{"is null (set)", &Filter{Field: "name", Op: "is-null"}, false},
+		{"is not null (set)", &Filter{Field: "name", Op: "is-not-null"}, true},
+		{"equal to null", &Filter{Field: "age", Op: "=", Val: (*int)(nil)}, true},
+		{"different from null", &Filter{Field: "age", Op: "!=", Val: (*int)(nil)}, false},
+
+		// Comparisons with null
+		{"unknown", unknown, false},
+		{"different", &Filter{Field: "age", Op: "!=", Val: ptr(30)}, false},
+		{"not unknown", not(unknown), false},
+		{"not not unknown", not(not(unknown)), false},
+		{"not false", not(falsy), true},
+		{"not true", not(known), false},
+
+		// Kleene logic
+		{"true and unknown", and(known, unknown), false},
+		{"not (true and unknown)", not(and(known, unknown)), false},
+		{"not (false and unknown)", not(and(falsy, unknown)), true},
+		{"true or unknown", or(known, unknown), true},
+		{"false or unknown", or(falsy, unknown), false},
+		{"not (false or unknown)", not(or(falsy, unknown)), false},
+		{"not (false or false)", not(or(falsy, falsy)), true},
+
+		// Set operators
+		{"not in", &Filter{Field: "city", Op: "not-in", Val: []string{"Quebec"}}, true},
+		{"not in (found)", &Filter{Field: "city", Op: "not-in", Val: []string{"Montreal"}}, false},
+		{"in (null)", &Filter{Field: "age", Op: "in", Val: []int{30}}, false},
+		{"not in (null)", &Filter{Field: "age", Op: "not-in", Val: []int{30}}, false},
+		{"not (in (null))", not(&Filter{Field: "age", Op: "in", Val: []int{30}}), false},
+		{"not (not in (null))", not(&Filter{Field: "age", Op: "not-in", Val: []int{30}}), false},
+		{"in (nullable)", &Filter{Field: "name", Op: "in", Val: []string{"Bob", "Alice"}}, true},
+		{"not in (nullable)", &Filter{Field: "name", Op: "not-in", Val: []string{"Bob"}}, true},
+		{"has any", &Filter{Field: "friends", Op: "has-any", Val: []string{"p0", "p2"}}, true},
+		{"has any (none)", &Filter{Field: "friends", Op: "has-any", Val: []string{"p0"}}, false},
+		{"has any (empty)", &Filter{Field: "friends", Op: "has-any", Val: []string{}}, false},
+		{"has all", &Filter{Field: "friends", Op: "has-all", Val: []string{"p1", "p3"}}, true},
+		{"has all (missing)", &Filter{Field: "friends", Op: "has-all", Val: []string{"p1", "p4"}},
+			false},
+		{"has all (empty)", &Filter{Field: "friends", Op: "has-all", Val: []string{}}, true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, test.filter.IsAllowed(res), test.name)
+	}
+
+	// JSON
+	filter := not(or(
+		&Filter{Field: "age", Op: "is-null"},
+		&Filter{Field: "friends", Op: "has-all", Val: []string{"p1"}},
+	))
+
+	payload, err := json.Marshal(filter)
+	assert.NoError(err)
+	assert.Equal(
+		`{"f":"","o":"not","v":{"f":"","o":"or","v":[`+
+			`{"f":"age","o":"is-null","v":null,"c":""},`+
+			`{"f":"friends","o":"has-all","v":["p1"],"c":""}],"c":""},"c":""}`,
+		string(payload),
+	)
+
+	filter2 := &Filter{}
+	err = json.Unmarshal(payload, filter2)
+	assert.NoError(err)
+
+	schema := &Schema{}
+	_ = schema.AddType(*typ)
+
+	filter2, err = filter2.Coerce(schema, "people")
+	assert.NoError(err)
+	assert.Equal(filter, filter2)
+
+	// Validation
+	errTests := []struct {
+		filter        *Filter
+		expectedError error
+	}{
+		{
+			filter:        &Filter{Field: "city", Op: "is-null"},
+			expectedError: NewErrUnknownOperatorInFilterParameter("is-null"),
+		}, {
+			filter:        &Filter{Field: "age", Op: "is-null", Val: ptr(1)},
+			expectedError: NewErrInvalidValueInFilterParameter("1", "null"),
+		}, {
+			filter:        &Filter{Field: "age", Op: "not-in", Val: []string{"a"}},
+			expectedError: NewErrInvalidValueInFilterParameter(`["a"]`, "[]int"),
+		}, {
+			filter:        &Filter{Field: "age", Op: "in", Val: []interface{}{1, nil}},
+			expectedError: NewErrInvalidValueInFilterParameter(`[1,null]`, "[]int"),
+		}, {
+			filter:        &Filter{Field: "age", Op: "in", Val: 1},
+			expectedError: NewErrInvalidValueInFilterParameter(`1`, "[]int"),
+		}, {
+			filter:        &Filter{Field: "friends", Op: "has-any", Val: "p1"},
+			expectedError: NewErrInvalidValueInFilterParameter(`"p1"`, "[]string"),
+		}, {
+			filter: &Filter{Op: "not", Val: []*Filter{known}},
+			expectedError: NewErrInvalidValueInFilterParameter(
+				`[{"f":"name","o":"=","v":"Alice","c":""}]`, "filter",
+			),
+		}, {
+			filter:        not(&Filter{Field: "unknown", Op: "=", Val: "a"}),
+			expectedError: NewErrUnknownFieldInFilterParameter("unknown"),
+		},
+	}
+
+	for _, test := range errTests {
+		assert.Equal(test.expectedError, test.filter.Validate(schema, "people"), test.filter.Op)
+	}
+}
+
+func TestFilterPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	type address struct {
+		City string `json:"city"`
+	}
+
+	type article struct {
+		ID       string   `json:"id" api:"articles"`
+		Title    string   `json:"title" api:"attr"`
+		Author   string   `json:"author" api:"rel,people"`
+		Comments []string `json:"comments" api:"rel,comments"`
+	}
+
+	type person struct {
+		ID      string  `json:"id" api:"people"`
+		Name    string  `json:"name" api:"attr"`
+		Address address `json:"address" api:"attr"`
+	}
+
+	type comment struct {
+		ID   string `json:"id" api:"comments"`
+		Body string `json:"body" api:"attr"`
+	}
+
+	schema := &Schema{}
+	_ = schema.AddType(MustBuildType(article{}))
+	_ = schema.AddType(MustBuildType(person{}))
+	_ = schema.AddType(MustBuildType(comment{}))
+
+	store := NewMemoryStore(schema)
+
+	for _, res := range []Resource{
+		Wrap(&person{ID: "p1", Name: "Jane", Address: address{City: "Montreal"}}),
+		Wrap(&person{ID: "p2", Name: "John", Address: address{City: "Paris"}}),
+		Wrap(&comment{ID: "c1", Body: "first"}),
+		Wrap(&comment{ID: "c2", Body: "second"}),
+		Wrap(&article{ID: "a1", Title: "A", Author: "p1", Comments: []string{"c1", "c2"}}),
+		Wrap(&article{ID: "a2", Title: "B", Author: "p2"}),
+		Wrap(&article{ID: "a3", Title: "C", Author: "p3"}),
+	} {
+		_, err := store.Create(res)
+		assert.NoError(err)
+	}
+
+	a1, _ := store.Resource("articles", "a1")
+	a2, _ := store.Resource("articles", "a2")
+	a3, _ := store.Resource("articles", "a3")
+	p1, _ := store.Resource("people", "p1")
+
+	tests := []struct {
+		filter   Filter
+		expected []bool // Results for a1, a2, and a3
+	}{
+		{
+			filter:   Filter{Field: "author.name", Op: "=", Val: "Jane"},
+			expected: []bool{true, false, false},
+		}, {
+			filter:   Filter{Field: "author.name", Op: "!=", Val: "Jane"},
+			expected: []bool{false, true, false},
+		}, {
+			filter:   Filter{Field: "author.address.city", Op: "=", Val: "Paris"},
+			expected: []bool{false, true, false},
+		}, {
+			filter:   Filter{Field: "comments.body", Op: "=", Val: "second"},
+			expected: []bool{true, false, false},
+		}, {
+			filter: Filter{Op: "or", Val: []*Filter{
+				{Field: "title", Op: "=", Val: "C"},
+				{Field: "author.name", Op: "=", Val: "John"},
+			}},
+			expected: []bool{false, true, true},
+		},
+	}
+
+	for _, test := range tests {
+		for i, res := range []Resource{a1, a2, a3} {
+			assert.Equal(
+				test.expected[i],
+				test.filter.IsAllowedWith(res, store),
+				fmt.Sprintf("%s on %s", test.filter.Field, res.Get("id")),
+			)
+		}
+	}
+
+	// Without a resolver, related resources cannot be loaded.
+	filter := &Filter{Field: "author.name", Op: "=", Val: "Jane"}
+	assert.False(filter.IsAllowed(a1))
+
+	// Object attributes do not need a resolver.
+	filter = &Filter{Field: "address.city", Op: "=", Val: "Montreal"}
+	assert.True(filter.IsAllowed(p1))
+	assert.True(filter.IsAllowed(Wrap(&person{Address: address{City: "Montreal"}})))
+
+	filter = &Filter{Field: "address.unknown", Op: "=", Val: "Montreal"}
+	assert.False(filter.IsAllowed(p1))
+
+	// Range
+	col, err := store.Collection(&URL{
+		ResType: "articles",
+		Params: &Params{
+			Filter:       &Filter{Field: "comments.body", Op: "=", Val: "first"},
+			SortingRules: []string{"id"},
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(1, col.Len())
+	assert.Equal("a1", col.At(0).Get("id"))
+
+	// Validation
+	valid := []string{"title", "author", "author.name", "author.address.city", "comments.body"}
+	for _, field := range valid {
+		filter := &Filter{Field: field, Op: "=", Val: "x"}
+		assert.NoError(filter.Validate(schema, "articles"), field)
+	}
+
+	invalid := []string{"", "unknown", "title.x", "author.unknown", "author.address.x", "author."}
+	for _, field := range invalid {
+		filter := &Filter{Field: field, Op: "=", Val: "x"}
+		assert.Equal(
+			NewErrUnknownFieldInFilterParameter(field),
+			filter.Validate(schema, "articles"),
+			field,
+		)
+	}
+
+	filter = &Filter{Op: "and", Val: []*Filter{
+		{Field: "title", Op: "=", Val: "A"},
+		{Field: "author.age", Op: "=", Val: 30},
+	}}
+	assert.Equal(
+		NewErrUnknownFieldInFilterParameter("author.age"),
+		filter.Validate(schema, "articles"),
+	)
+}
+
+func TestFilterCoerce(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	// Values of the right type are kept and the filter is not modified.
+	filter := &Filter{Op: "and", Val: []*Filter{
+		{Field: "int16", Op: "=", Val: int16(3)},
+		{Field: "uint", Op: ">", Val: float64(4)},
+	}}
+
+	coerced, err := filter.Coerce(schema, "mocktypes1")
+	assert.NoError(err)
+	assert.Equal(&Filter{Op: "and", Val: []*Filter{
+		{Field: "int16", Op: "=", Val: int16(3)},
+		{Field: "uint", Op: ">", Val: uint(4)},
+	}}, coerced)
+	assert.Equal(float64(4), filter.Val.([]*Filter)[1].Val)
+
+	// Lists of values
+	filter = &Filter{Field: "int16", Op: "in", Val: []interface{}{float64(3), "4"}}
+	coerced, err = filter.Coerce(schema, "mocktypes1")
+	assert.NoError(err)
+	assert.Equal([]interface{}{int16(3), int16(4)}, coerced.Val)
+
+	filter = &Filter{Field: "uint8ptr", Op: "not-in", Val: []string{"5"}}
+	coerced, err = filter.Coerce(schema, "mocktypes2")
+	assert.NoError(err)
+	assert.Equal([]interface{}{ptr(uint8(5))}, coerced.Val)
+
+	filter = &Filter{Field: "strptr", Op: "in", Val: []interface{}{"a"}}
+	coerced, err = filter.Coerce(schema, "mocktypes2")
+	assert.NoError(err)
+	assert.Equal([]string{"a"}, coerced.Val)
+
+	// Invalid subfilters
+	filter = &Filter{Op: "or", Val: "not filters"}
+	assert.Equal(
+		NewErrInvalidValueInFilterParameter(`"not filters"`, "filters"),
+		filter.Validate(schema, "mocktypes1"),
+	)
+
+	// Bytes
+	schema = &Schema{}
+	_ = schema.AddType(Type{
+		Name:  "files",
+		Attrs: map[string]Attr{"data": {Name: "data", Type: AttrTypeBytes}},
+	})
+
+	filter = &Filter{Field: "data", Op: "=", Val: "AQI="}
+	coerced, err = filter.Coerce(schema, "files")
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2}, coerced.Val)
+
+	filter = &Filter{Field: "data", Op: "=", Val: "not base64"}
+	_, err = filter.Coerce(schema, "files")
+	assert.Error(err)
+}
@@ -0,0 +1,191 @@
+package jsonapi
+
+import "reflect"
+
+// A Graph indexes the primary and included resources of a document so that
+// relationships can be followed from one resource to another.
+//
+// A Graph is usually made from a document returned by UnmarshalDocument. The
+// related resources that are not part of the document are simply missing, and
+// since resources are looked up rather than nested, cycles in relationships
+// are not a problem.
+type Graph struct {
+	primary []Resource
+	res     map[Identifier]Resource
+}
+
+// NewGraph returns a graph of the resources of doc.
+//
+// Resources identified by a local ID can be found with it too. When a type and
+// ID pair appears more than once, the first resource is kept. Later changes to
+// doc are not reflected in the graph.
+func NewGraph(doc *Document) *Graph {
+	g := &Graph{
+		primary: []Resource{},
+		res:     map[Identifier]Resource{},
+	}
+
+	switch data := doc.Data.(type) {
+	case Resource:
+		g.primary = append(g.primary, data)
+	case Collection:
+		for i := 0; i < data.Len(); i++ {
+			g.primary = append(g.primary, data.At(i))
+		}
+	}
+
+	for _, res := range g.primary {
+		g.add(res)
+	}
+
+	for _, res := range doc.Included {
+		g.add(res)
+	}
+
+	return g
+}
+
+// Primary returns the resources of the primary data, in order.
+func (g *Graph) Primary() []Resource {
+	return append([]Resource(nil), g.primary...)
+}
+
+// Get returns the resource identified by iden, or nil if it is not part of
+// the graph. iden.LID is used only if iden.ID is empty.
+func (g *Graph) Get(iden Identifier) Resource {
+	if iden.ID != "" {
+		return g.res[Identifier{ID: iden.ID, Type: iden.Type}]
+	}
+
+	if iden.LID != "" {
+		return g.res[Identifier{LID: iden.LID, Type: iden.Type}]
+	}
+
+	return nil
+}
+
+// Resource implements the Resolver interface, which means that a graph can be
+// used to check filters or sort resources on the fields of related resources.
+//
+// It never returns an error.
+func (g *Graph) Resource(typ, id string) (Resource, error) {
+	return g.Get(Identifier{ID: id, Type: typ}), nil
+}
+
+// ToOne returns the resource pointed to by the to-one relationship rel of res,
+// or nil if the relationship is empty, unknown, or points to a resource that
+// is not part of the graph.
+func (g *Graph) ToOne(res Resource, rel string) Resource {
+	r, ok := res.GetType().Rels[rel]
+	if !ok || !r.ToOne {
+		return nil
+	}
+
+	related := g.related(res, r)
+	if len(related) == 0 {
+		return nil
+	}
+
+	return related[0]
+}
+
+// ToMany returns the resources pointed to by the to-many relationship rel of
+// res, in the order of the relationship. The resources that are not part of
+// the graph are skipped, and an empty slice is returned if the relationship is
+// unknown.
+func (g *Graph) ToMany(res Resource, rel string) []Resource {
+	r, ok := res.GetType().Rels[rel]
+	if !ok || r.ToOne {
+		return []Resource{}
+	}
+
+	return g.related(res, r)
+}
+
+// Walk calls fn once for each resource that can be reached from the primary
+// data by following relationships, including the primary resources themselves.
+// The resources are visited breadth first, and the walk stops as soon as fn
+// returns false.
+func (g *Graph) Walk(fn func(res Resource) bool) {
+	visited := map[interface{}]bool{}
+	queue := append([]Resource(nil), g.primary...)
+
+	for len(queue) > 0 {
+		res := queue[0]
+		queue = queue[1:]
+
+		key := visitKey(res)
+		if visited[key] {
+			continue
+		}
+
+		visited[key] = true
+
+		if !fn(res) {
+			return
+		}
+
+		typ := res.GetType()
+
+		for _, name := range typ.Fields() {
+			if rel, ok := typ.Rels[name]; ok {
+				queue = append(queue, g.related(res, rel)...)
+			}
+		}
+	}
+}
+
+// add indexes res by its ID and its local ID.
+func (g *Graph) add(res Resource) {
+	for _, key := range graphKeys(res) {
+		if _, ok := g.res[key]; !ok {
+			g.res[key] = res
+		}
+	}
+}
+
+// related returns the resources of the graph pointed to by the relationship
+// rel of res, in the order of the relationship.
+func (g *Graph) related(res Resource, rel Rel) []Resource {
+	related := []Resource{}
+
+	for _, iden := range relIdentifiers(res, rel) {
+		if r := g.Get(iden); r != nil {
+			related = append(related, r)
+		}
+	}
+
+	return related
+}
+
+// graphKeys returns the keys of res in the index of a graph. There is always at
+// least one key, even if res has neither an ID nor a local ID.
+func graphKeys(res Resource) []Identifier {
+	typ := res.GetType().Name
+	id := res.Get("id").(string)
+
+	keys := []Identifier{{ID: id, Type: typ}}
+
+	if lh, ok := res.(LIDHolder); ok && lh.LID() != "" {
+		keys = append(keys, Identifier{LID: lh.LID(), Type: typ})
+
+		if id == "" {
+			keys = keys[1:]
+		}
+	}
+
+	return keys
+}
+
+// visitKey returns the key of res in the resources visited by Graph.Walk.
+//
+// Resources are told apart by identity, so that those without an ID or a local
+// ID are all visited. The resources of types that are not comparable, which
+// are rarely used, are told apart by their keys in the index instead.
+func visitKey(res Resource) interface{} {
+	if reflect.TypeOf(res).Comparable() {
+		return res
+	}
+
+	return graphKeys(res)[0]
+}
@@ -0,0 +1,190 @@
+package jsonapi_test
+
+import (
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Resolver = (*Graph)(nil)
+
+func TestGraph(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	payload := `{
+		"data": [{
+			"type": "mocktypes1",
+			"id": "mt1-1",
+			"relationships": {
+				"to-one": {"data": {"type": "mocktypes2", "id": "mt2-1"}},
+				"to-many": {"data": [
+					{"type": "mocktypes2", "id": "mt2-2"},
+					{"type": "mocktypes2", "id": "mt2-9"},
+					{"type": "mocktypes2", "id": "mt2-1"}
+				]}
+			}
+		}],
+		"included": [{
+			"type": "mocktypes2",
+			"id": "mt2-1",
+			"relationships": {
+				"to-one-from-one": {"data": {"type": "mocktypes1", "id": "mt1-1"}}
+			}
+		}, {
+			"type": "mocktypes2",
+			"id": "mt2-2",
+			"relationships": {
+				"to-many-from-many": {"data": [{"type": "mocktypes1", "id": "mt1-2"}]}
+			}
+		}, {
+			"type": "mocktypes1",
+			"id": "mt1-2"
+		}]
+	}`
+
+	doc, err := UnmarshalDocument([]byte(payload), schema)
+	assert.NoError(err)
+
+	g := NewGraph(doc)
+
+	ids := func(ress []Resource) []string {
+		ids := []string{}
+
+		for _, res := range ress {
+			ids = append(ids, res.Get("id").(string))
+		}
+
+		return ids
+	}
+
+	// Primary data
+	assert.Equal([]string{"mt1-1"}, ids(g.Primary()))
+
+	// Get
+	mt11 := g.Get(Identifier{ID: "mt1-1", Type: "mocktypes1"})
+	assert.Equal(g.Primary()[0], mt11)
+	assert.Nil(g.Get(Identifier{ID: "mt1-1", Type: "mocktypes2"}))
+	assert.Nil(g.Get(Identifier{ID: "mt2-9", Type: "mocktypes2"}))
+	assert.Nil(g.Get(Identifier{Type: "mocktypes1"}))
+
+	res, err := g.Resource("mocktypes2", "mt2-2")
+	assert.NoError(err)
+	assert.Equal("mt2-2", res.Get("id"))
+
+	// To-one relationships, with a cycle
+	mt21 := g.ToOne(mt11, "to-one")
+	assert.Equal("mt2-1", mt21.Get("id"))
+	assert.Equal(mt11, g.ToOne(mt21, "to-one-from-one"))
+	assert.Nil(g.ToOne(mt11, "to-one-from-one"))
+	assert.Nil(g.ToOne(mt11, "to-many"))
+	assert.Nil(g.ToOne(mt11, "unknown"))
+
+	// To-many relationships
+	assert.Equal([]string{"mt2-2", "mt2-1"}, ids(g.ToMany(mt11, "to-many")))
+	assert.Equal([]string{}, ids(g.ToMany(mt11, "to-many-from-many")))
+	assert.Equal([]Resource{}, g.ToMany(mt11, "to-one"))
+	assert.Equal([]Resource{}, g.ToMany(mt11, "unknown"))
+
+	// Walk
+	walked := []string{}
+
+	g.Walk(func(res Resource) bool {
+		walked = append(walked, res.Get("id").(string))
+		return true
+	})
+
+	assert.Equal([]string{"mt1-1", "mt2-2", "mt2-1", "mt1-2"}, walked)
+
+	walked = []string{}
+
+	g.Walk(func(res Resource) bool {
+		walked = append(walked, res.Get("id").(string))
+		return len(walked) < 2
+	})
+
+	assert.Equal([]string{"mt1-1", "mt2-2"}, walked)
+
+	// Filters through relationships
+	filter := &Filter{Field: "to-one.to-one-from-one", Op: "=", Val: "mt1-1"}
+	assert.True(filter.IsAllowedWith(mt11, g))
+
+	// Local IDs
+	typ := schema.GetType("mocktypes1")
+	lres := &SoftResource{Type: &typ}
+	lres.SetLID("local")
+	lres.SetRelLIDs("to-one", []string{"local-2"})
+
+	typ2 := schema.GetType("mocktypes2")
+	lres2 := &SoftResource{Type: &typ2}
+	lres2.SetLID("local-2")
+
+	g = NewGraph(&Document{Data: lres, Included: []Resource{lres2}})
+	assert.Equal(lres, g.Get(Identifier{LID: "local", Type: "mocktypes1"}))
+	assert.Equal(lres2, g.ToOne(lres, "to-one"))
+
+	// Resources without IDs or local IDs
+	nores1 := &SoftResource{Type: &typ}
+	nores2 := &SoftResource{Type: &typ}
+
+	visited := []Resource{}
+
+	g = NewGraph(&Document{Data: &Resources{nores1, nores2}})
+	g.Walk(func(res Resource) bool {
+		visited = append(visited, res)
+		return true
+	})
+
+	assert.Len(visited, 2)
+	assert.True(visited[0] == nores1)
+	assert.True(visited[1] == nores2)
+
+	// Order of relationships with IDs and local IDs
+	payload = `{
+		"data": {
+			"type": "mocktypes1",
+			"id": "mt1-1",
+			"relationships": {
+				"to-many": {"data": [
+					{"type": "mocktypes2", "id": "mt2-2"},
+					{"type": "mocktypes2", "lid": "local-1"},
+					{"type": "mocktypes2", "id": "mt2-1"},
+					{"type": "mocktypes2", "lid": "local-3"}
+				]}
+			}
+		},
+		"included": [
+			{"type": "mocktypes2", "id": "mt2-1"},
+			{"type": "mocktypes2", "id": "mt2-2"},
+			{"type": "mocktypes2", "lid": "local-1"},
+			{"type": "mocktypes2", "id": "mt2-3", "lid": "local-3"}
+		]
+	}`
+
+	doc, err = UnmarshalDocument([]byte(payload), schema)
+	assert.NoError(err)
+
+	mt11 = doc.Data.(Resource)
+	assert.Equal([]string{"mt2-2", "mt2-1"}, mt11.Get("to-many"))
+
+	g = NewGraph(doc)
+	related := g.ToMany(mt11, "to-many")
+	assert.Equal([]string{"mt2-2", "", "mt2-1", "mt2-3"}, ids(related))
+	assert.Equal("local-1", related[1].(LIDHolder).LID())
+
+	// Copies keep the order.
+	assert.Equal(related, g.ToMany(mt11.(*Wrapper).Copy(), "to-many"))
+
+	// So do the local IDs that are resolved.
+	err = doc.ResolveLIDs(nil)
+	assert.Equal(`The local ID "local-1" does not identify any resource.`, err.(Error).Detail)
+	assert.Equal([]string{"mt2-2", "mt2-1", "mt2-3"}, mt11.Get("to-many"))
+	assert.Equal([]string{"local-1"}, mt11.(LIDHolder).RelLIDs("to-many"))
+	assert.Equal(related, NewGraph(doc).ToMany(mt11, "to-many"))
+
+	// Once the relationship changes, the IDs come first.
+	mt11.Set("to-many", []string{"mt2-1", "mt2-2"})
+	assert.Equal([]string{"mt2-1", "mt2-2", ""}, ids(g.ToMany(mt11, "to-many")))
+}
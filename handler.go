@@ -0,0 +1,560 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// MediaType is the media type of JSON:API payloads.
+const MediaType = "application/vnd.api+json"
+
+// A Store retrieves and persists the resources served by a Handler.
+//
+// Methods can return an Error to control the status code and the content of
+// the response. Any other error results in a 500 Internal Server Error.
+type Store interface {
+	// Resource returns the resource of type typ identified by id.
+	//
+	// nil is returned if the resource does not exist.
+	Resource(typ, id string) (Resource, error)
+
+	// Collection returns the resources described by url.
+	//
+	// The store is responsible for applying the filter, the sorting rules,
+	// and the pagination found in url.Params. When the collection is reached
+	// through a relationship, only the resources referenced by the parent
+	// described in url.BelongsToFilter must be considered.
+	//
+	// If the returned collection is a *RangeResult, its Total, HasMore, and
+	// HasPrev fields are copied into the document, which gives it the next,
+	// prev, and last pagination links.
+	Collection(url *URL) (Collection, error)
+
+	// Create saves res and returns the created resource.
+	//
+	// The store may assign an ID if res does not have one.
+	Create(res Resource) (Resource, error)
+
+	// Update applies the fields set in res to the existing resource with the
+	// same type and ID and returns the updated resource.
+	Update(res *SoftResource) (Resource, error)
+
+	// Delete deletes the resource of type typ identified by id.
+	Delete(typ, id string) error
+
+	// SetRel replaces the IDs of the relationship rel of the resource of type
+	// typ identified by id. For a to-one relationship, ids contains at most
+	// one element.
+	SetRel(typ, id, rel string, ids []string) error
+
+	// AddToRel adds ids to the to-many relationship rel of the resource of
+	// type typ identified by id.
+	AddToRel(typ, id, rel string, ids []string) error
+
+	// RemoveFromRel removes ids from the to-many relationship rel of the
+	// resource of type typ identified by id.
+	RemoveFromRel(typ, id, rel string, ids []string) error
+}
+
+// An AtomicStore is a Store that can run several changes in one transaction.
+//
+// It is required by a Handler to execute the requests of the Atomic Operations
+// extension.
+type AtomicStore interface {
+	Store
+
+	// Atomic calls fn with a Store where all the changes are applied
+	// only if fn returns nil.
+	Atomic(fn func(tx Store) error) error
+}
+
+// NewHandler returns a *Handler that serves the types of schema using store.
+func NewHandler(schema *Schema, store Store) *Handler {
+	return &Handler{
+		Schema: schema,
+		Store:  store,
+	}
+}
+
+// A Handler is an http.Handler that serves JSON:API requests.
+//
+// Each request is parsed with NewRequest, executed against the Store, and
+// answered with a document marshaled by MarshalDocument. Failures are answered
+// with an errors document.
+type Handler struct {
+	Schema *Schema
+	Store  Store
+
+	// PrePath is prepended to the links of the documents and usually
+	// represents a scheme and a domain name.
+	PrePath string
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := NewRequest(r, h.Schema)
+	if err != nil {
+		var e Error
+		if !errors.As(err, &e) {
+			err = NewErrBadRequest("Invalid request", err.Error())
+		}
+
+		h.writeError(w, err)
+
+		return
+	}
+
+	status, doc, err := h.handle(req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if doc == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	if status == http.StatusCreated {
+		if res, ok := doc.Data.(Resource); ok {
+			w.Header().Set("Location", buildSelfLink(res, h.PrePath))
+		}
+	}
+
+	h.writeDocument(w, status, doc, req.URL)
+}
+
+// handle executes req against the store and returns the status code and the
+// document of the response.
+//
+// A nil document means that the response has no body.
+func (h *Handler) handle(req *Request) (int, *Document, error) {
+	if req.Doc != nil && req.Doc.Operations != nil {
+		return h.handleOperations(req)
+	}
+
+	url := req.URL
+
+	switch {
+	case url.RelKind == "self":
+		return h.handleRelationship(req)
+	case url.RelKind == "related":
+		if req.Method != http.MethodGet {
+			return 0, nil, NewErrMethodNotAllowed()
+		}
+
+		return h.handleRelated(req)
+	case url.IsCol:
+		switch req.Method {
+		case http.MethodGet:
+			col, err := h.Store.Collection(url)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			doc, err := h.newDocument(col, url)
+
+			return http.StatusOK, doc, err
+		case http.MethodPost:
+			res, ok := req.Doc.Data.(Resource)
+			if !ok {
+				return 0, nil, NewErrBadRequest(
+					"Invalid resource",
+					"The data member must be a resource of type "+url.ResType+".",
+				)
+			}
+
+			if res.GetType().Name != url.ResType {
+				e := NewErrConflict()
+				e.Detail = "The data member must be a resource of type " + url.ResType + "."
+
+				return 0, nil, e
+			}
+
+			res, err := h.Store.Create(res)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			doc, err := h.newDocument(res, url)
+
+			return http.StatusCreated, doc, err
+		}
+	default:
+		switch req.Method {
+		case http.MethodGet:
+			res, err := h.resource(url.ResType, url.ResID)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			doc, err := h.newDocument(res, url)
+
+			return http.StatusOK, doc, err
+		case http.MethodPatch:
+			res, ok := req.Doc.Data.(*SoftResource)
+			if !ok {
+				return 0, nil, NewErrBadRequest(
+					"Invalid resource",
+					"The data member must be the resource identified by the URL.",
+				)
+			}
+
+			if res.GetType().Name != url.ResType || res.GetID() != url.ResID {
+				e := NewErrConflict()
+				e.Detail = "The data member must be the resource identified by the URL."
+
+				return 0, nil, e
+			}
+
+			updated, err := h.Store.Update(res)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			doc, err := h.newDocument(updated, url)
+
+			return http.StatusOK, doc, err
+		case http.MethodDelete:
+			if _, err := h.resource(url.ResType, url.ResID); err != nil {
+				return 0, nil, err
+			}
+
+			err := h.Store.Delete(url.ResType, url.ResID)
+
+			return http.StatusNoContent, nil, err
+		}
+	}
+
+	return 0, nil, NewErrMethodNotAllowed()
+}
+
+// handleRelationship handles requests made to relationship URLs like
+// /articles/abc123/relationships/author.
+func (h *Handler) handleRelationship(req *Request) (int, *Document, error) {
+	url := req.URL
+	btf := url.BelongsToFilter
+
+	parent, err := h.resource(btf.Type, btf.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if req.Method == http.MethodGet {
+		doc := &Document{PrePath: h.PrePath}
+
+		if url.Rel.ToOne {
+			if id := parent.Get(url.Rel.FromName).(string); id != "" {
+				doc.Data = Identifier{ID: id, Type: url.Rel.ToType}
+			}
+		} else {
+			ids := parent.Get(url.Rel.FromName).([]string)
+			doc.Data = NewIdentifiers(url.Rel.ToType, ids)
+		}
+
+		return http.StatusOK, doc, nil
+	}
+
+	var ids []string
+
+	switch data := req.Doc.Data.(type) {
+	case Identifier:
+		if !url.Rel.ToOne {
+			return 0, nil, NewErrBadRequest(
+				"Invalid relationship data",
+				"The data member must be an array of resource identifiers.",
+			)
+		}
+
+		ids = []string{data.ID}
+	case Identifiers:
+		if url.Rel.ToOne {
+			return 0, nil, NewErrBadRequest(
+				"Invalid relationship data",
+				"The data member must be a resource identifier or null.",
+			)
+		}
+
+		ids = data.IDs()
+	case nil:
+		if !url.Rel.ToOne {
+			return 0, nil, NewErrBadRequest(
+				"Invalid relationship data",
+				"The data member must be an array of resource identifiers.",
+			)
+		}
+	}
+
+	switch {
+	case req.Method == http.MethodPatch:
+		err = h.Store.SetRel(btf.Type, btf.ID, btf.Name, ids)
+	case req.Method == http.MethodPost && !url.Rel.ToOne:
+		err = h.Store.AddToRel(btf.Type, btf.ID, btf.Name, ids)
+	case req.Method == http.MethodDelete && !url.Rel.ToOne:
+		err = h.Store.RemoveFromRel(btf.Type, btf.ID, btf.Name, ids)
+	default:
+		return 0, nil, NewErrMethodNotAllowed()
+	}
+
+	return http.StatusNoContent, nil, err
+}
+
+// handleOperations handles requests of the Atomic Operations extension.
+//
+// The operations are executed in order in one transaction if the store is an
+// AtomicStore. Otherwise, a 501 Not Implemented error is returned.
+func (h *Handler) handleOperations(req *Request) (int, *Document, error) {
+	store, ok := h.Store.(AtomicStore)
+	if !ok {
+		return 0, nil, NewErrNotImplemented()
+	}
+
+	var results []OperationResult
+
+	err := store.Atomic(func(tx Store) error {
+		var err error
+		results, err = executeOperations(tx, req.Doc.Operations)
+
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	doc := &Document{
+		Results: results,
+		PrePath: h.PrePath,
+	}
+
+	return http.StatusOK, doc, nil
+}
+
+// executeOperations executes ops against store and returns their results.
+//
+// The local IDs of the resources added by the operations are replaced by the
+// IDs assigned by the store in the operations that follow, including in the
+// relationships of the resources they add or update.
+func executeOperations(store Store, ops []Operation) ([]OperationResult, error) {
+	results := make([]OperationResult, 0, len(ops))
+	lids := map[string]string{}
+
+	id := func(id, lid string) string {
+		if id == "" {
+			return lids[lid]
+		}
+
+		return id
+	}
+
+	for _, op := range ops {
+		ref := op.Ref
+		ref.ID = id(ref.ID, ref.LID)
+
+		var (
+			result OperationResult
+			err    error
+		)
+
+		switch {
+		case ref.Relationship != "":
+			var ids []string
+
+			switch data := op.Data.(type) {
+			case Identifier:
+				ids = []string{id(data.ID, data.LID)}
+			case Identifiers:
+				for _, iden := range data {
+					ids = append(ids, id(iden.ID, iden.LID))
+				}
+			}
+
+			switch op.Op {
+			case "update":
+				err = store.SetRel(ref.Type, ref.ID, ref.Relationship, ids)
+			case "add":
+				err = store.AddToRel(ref.Type, ref.ID, ref.Relationship, ids)
+			case "remove":
+				err = store.RemoveFromRel(ref.Type, ref.ID, ref.Relationship, ids)
+			}
+		case op.Op == "add":
+			res := op.Data.(Resource)
+
+			err = resolveRelLIDs(res, lids)
+			if err == nil {
+				result.Data, err = store.Create(res)
+			}
+
+			if err == nil && ref.LID != "" {
+				lids[ref.LID] = result.Data.Get("id").(string)
+			}
+		case op.Op == "update":
+			res := op.Data.(*SoftResource)
+			res.SetID(ref.ID)
+
+			err = resolveRelLIDs(res, lids)
+			if err == nil {
+				result.Data, err = store.Update(res)
+			}
+		case op.Op == "remove":
+			err = store.Delete(ref.Type, ref.ID)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// handleRelated handles requests made to related resource URLs like
+// /articles/abc123/author.
+func (h *Handler) handleRelated(req *Request) (int, *Document, error) {
+	url := req.URL
+	btf := url.BelongsToFilter
+
+	parent, err := h.resource(btf.Type, btf.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !url.Rel.ToOne {
+		col, err := h.Store.Collection(url)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		doc, err := h.newDocument(col, url)
+
+		return http.StatusOK, doc, err
+	}
+
+	var data interface{}
+
+	if id := parent.Get(url.Rel.FromName).(string); id != "" {
+		res, err := h.Store.Resource(url.Rel.ToType, id)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if res != nil {
+			data = res
+		}
+	}
+
+	doc, err := h.newDocument(data, url)
+
+	return http.StatusOK, doc, err
+}
+
+// resource returns the resource of type typ identified by id, or a not found
+// error if it does not exist.
+func (h *Handler) resource(typ, id string) (Resource, error) {
+	res, err := h.Store.Resource(typ, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, NewErrNotFound()
+	}
+
+	return res, nil
+}
+
+// newDocument returns a document whose primary data is data and where the
+// resources requested by the include parameter are included.
+func (h *Handler) newDocument(data interface{}, url *URL) (*Document, error) {
+	doc := &Document{
+		Data:    data,
+		RelData: map[string][]string{},
+		PrePath: h.PrePath,
+	}
+
+	if page, ok := data.(*RangeResult); ok {
+		total := uint(page.Total)
+
+		doc.Data = page.Page
+		doc.Total = &total
+		doc.HasMore = page.HasMore
+		doc.HasPrev = page.HasPrev
+	}
+
+	r, ok := h.Store.(BatchResolver)
+	if !ok {
+		r = storeResolver{store: h.Store}
+	}
+
+	if err := BuildIncluded(doc, url, r); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// writeDocument marshals doc and writes it with the given status code.
+func (h *Handler) writeDocument(w http.ResponseWriter, status int, doc *Document, url *URL) {
+	payload, err := MarshalDocument(doc, url)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if doc.Results != nil {
+		w.Header().Set("Content-Type", MediaType+`; ext="`+AtomicExtension+`"`)
+	} else {
+		w.Header().Set("Content-Type", MediaType)
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(payload)
+}
+
+// writeError writes an errors document built from err.
+//
+// If err is not an Error, a 500 Internal Server Error is written instead.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	var e Error
+	if !errors.As(err, &e) {
+		e = NewErrInternalServerError()
+	}
+
+	status, _ := strconv.Atoi(e.Status)
+	if http.StatusText(status) == "" {
+		status = http.StatusInternalServerError
+	}
+
+	// Errors do not depend on the URL.
+	payload, _ := MarshalDocument(&Document{Errors: []Error{e}}, nil)
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	_, _ = w.Write(payload)
+}
+
+// storeResolver is a BatchResolver that loads resources one by one from a
+// store that cannot load them in bulk.
+type storeResolver struct {
+	store Store
+}
+
+// Resources implements the BatchResolver interface.
+func (s storeResolver) Resources(typ string, ids []string) ([]Resource, error) {
+	ress := []Resource{}
+
+	for _, id := range ids {
+		res, err := s.store.Resource(typ, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if res != nil {
+			ress = append(ress, res)
+		}
+	}
+
+	return ress, nil
+}
@@ -0,0 +1,642 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ http.Handler = (*Handler)(nil)
+
+func TestHandler(t *testing.T) {
+	schema := newMockSchema()
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		accept         string
+		expectedStatus int
+		expectedBody   string
+		expectedLoc    string
+	}{
+		{
+			name:           "get collection",
+			method:         "GET",
+			url:            "/mocktypes3?fields[mocktypes3]=attr1&sort=id",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": [
+					{
+						"attributes": {"attr1": "a"},
+						"id": "mt3-1",
+						"links": {"self": "/mocktypes3/mt3-1"},
+						"type": "mocktypes3"
+					},
+					{
+						"attributes": {"attr1": "b"},
+						"id": "mt3-2",
+						"links": {"self": "/mocktypes3/mt3-2"},
+						"type": "mocktypes3"
+					}
+				],
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3?fields%5Bmocktypes3%5D=attr1&sort=id%2Cattr1%2Cattr2"
+				}
+			}`,
+		}, {
+			name:   "get resource with inclusion",
+			method: "GET",
+			url: "/mocktypes3/mt3-1" +
+				"?fields[mocktypes3]=rel1&fields[mocktypes1]=str&include=rel1",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": {
+					"id": "mt3-1",
+					"links": {"self": "/mocktypes3/mt3-1"},
+					"relationships": {
+						"rel1": {
+							"data": {"id": "mt1-1", "type": "mocktypes1"},
+							"links": {
+								"related": "/mocktypes3/mt3-1/rel1",
+								"self": "/mocktypes3/mt3-1/relationships/rel1"
+							}
+						}
+					},
+					"type": "mocktypes3"
+				},
+				"included": [
+					{
+						"attributes": {"str": "str1"},
+						"id": "mt1-1",
+						"links": {"self": "/mocktypes1/mt1-1"},
+						"type": "mocktypes1"
+					}
+				],
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3/mt3-1` +
+				`?fields%5Bmocktypes1%5D=str&fields%5Bmocktypes3%5D=rel1"
+				}
+			}`,
+		}, {
+			name:           "get unknown resource",
+			method:         "GET",
+			url:            "/mocktypes3/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedBody: `{
+				"errors": [{
+					"detail": "The URI does not exist.",
+					"status": "404",
+					"title": "Not found"
+				}],
+				"jsonapi": {"version": "1.0"}
+			}`,
+		}, {
+			name:           "get with unacceptable media type",
+			method:         "GET",
+			url:            "/mocktypes3/mt3-1",
+			accept:         MediaType + "; charset=utf-8",
+			expectedStatus: http.StatusNotAcceptable,
+			expectedBody: `{
+				"errors": [{
+					"detail": "None of the media types of the Accept header can be served.",
+					"status": "406",
+					"title": "Not acceptable"
+				}],
+				"jsonapi": {"version": "1.0"}
+			}`,
+		}, {
+			name:           "get unknown type",
+			method:         "GET",
+			url:            "/unknown",
+			expectedStatus: http.StatusBadRequest,
+		}, {
+			name:   "create resource",
+			method: "POST",
+			url:    "/mocktypes3?fields[mocktypes3]=attr1",
+			body: `{
+				"data": {
+					"id": "mt3-3",
+					"type": "mocktypes3",
+					"attributes": {"attr1": "c"}
+				}
+			}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody: `{
+				"data": {
+					"attributes": {"attr1": "c"},
+					"id": "mt3-3",
+					"links": {"self": "/mocktypes3/mt3-3"},
+					"type": "mocktypes3"
+				},
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3?fields%5Bmocktypes3%5D=attr1&sort=attr1%2Cattr2%2Cid"
+				}
+			}`,
+			expectedLoc: "/mocktypes3/mt3-3",
+		}, {
+			name:   "update resource",
+			method: "PATCH",
+			url:    "/mocktypes3/mt3-1?fields[mocktypes3]=attr1,attr2",
+			body: `{
+				"data": {
+					"id": "mt3-1",
+					"type": "mocktypes3",
+					"attributes": {"attr2": 42}
+				}
+			}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": {
+					"attributes": {"attr1": "a", "attr2": 42},
+					"id": "mt3-1",
+					"links": {"self": "/mocktypes3/mt3-1"},
+					"type": "mocktypes3"
+				},
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3/mt3-1?fields%5Bmocktypes3%5D=attr1%2Cattr2"
+				}
+			}`,
+		}, {
+			name:   "update resource with different id",
+			method: "PATCH",
+			url:    "/mocktypes3/mt3-1",
+			body: `{
+				"data": {
+					"id": "mt3-2",
+					"type": "mocktypes3"
+				}
+			}`,
+			expectedStatus: http.StatusConflict,
+		}, {
+			name:   "update resource with different type",
+			method: "PATCH",
+			url:    "/mocktypes3/mt3-1",
+			body: `{
+				"data": {
+					"id": "mt3-1",
+					"type": "mocktypes1"
+				}
+			}`,
+			expectedStatus: http.StatusConflict,
+		}, {
+			name:   "create resource with different type",
+			method: "POST",
+			url:    "/mocktypes3",
+			body: `{
+				"data": {
+					"id": "mt1-9",
+					"type": "mocktypes1"
+				}
+			}`,
+			expectedStatus: http.StatusConflict,
+		}, {
+			name:           "create resource without data",
+			method:         "POST",
+			url:            "/mocktypes3",
+			body:           `{"data": null}`,
+			expectedStatus: http.StatusBadRequest,
+		}, {
+			name:           "delete resource",
+			method:         "DELETE",
+			url:            "/mocktypes3/mt3-2",
+			expectedStatus: http.StatusNoContent,
+		}, {
+			name:           "delete unknown resource",
+			method:         "DELETE",
+			url:            "/mocktypes3/unknown",
+			expectedStatus: http.StatusNotFound,
+		}, {
+			name:           "get to-one relationship",
+			method:         "GET",
+			url:            "/mocktypes3/mt3-1/relationships/rel1?fields[mocktypes1]=str",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": {"id": "mt1-1", "type": "mocktypes1"},
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3/mt3-1/relationships/rel1?fields%5Bmocktypes1%5D=str"
+				}
+			}`,
+		}, {
+			name:           "update to-one relationship",
+			method:         "PATCH",
+			url:            "/mocktypes3/mt3-1/relationships/rel1",
+			body:           `{"data": null}`,
+			expectedStatus: http.StatusNoContent,
+		}, {
+			name:           "add to to-one relationship",
+			method:         "POST",
+			url:            "/mocktypes3/mt3-1/relationships/rel1",
+			body:           `{"data": {"id": "mt1-1", "type": "mocktypes1"}}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+		}, {
+			name:           "add to to-many relationship",
+			method:         "POST",
+			url:            "/mocktypes3/mt3-1/relationships/rel2",
+			body:           `{"data": [{"id": "mt1-2", "type": "mocktypes1"}]}`,
+			expectedStatus: http.StatusNoContent,
+		}, {
+			name:           "remove from to-many relationship",
+			method:         "DELETE",
+			url:            "/mocktypes3/mt3-1/relationships/rel2",
+			body:           `{"data": [{"id": "mt1-1", "type": "mocktypes1"}]}`,
+			expectedStatus: http.StatusNoContent,
+		}, {
+			name:           "invalid to-many relationship data",
+			method:         "PATCH",
+			url:            "/mocktypes3/mt3-1/relationships/rel2",
+			body:           `{"data": {"id": "mt1-1", "type": "mocktypes1"}}`,
+			expectedStatus: http.StatusBadRequest,
+		}, {
+			name:           "get related to-one",
+			method:         "GET",
+			url:            "/mocktypes3/mt3-1/rel1?fields[mocktypes1]=str",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": {
+					"attributes": {"str": "str1"},
+					"id": "mt1-1",
+					"links": {"self": "/mocktypes1/mt1-1"},
+					"type": "mocktypes1"
+				},
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3/mt3-1/rel1?fields%5Bmocktypes1%5D=str"
+				}
+			}`,
+		}, {
+			name:           "get related to-many",
+			method:         "GET",
+			url:            "/mocktypes3/mt3-1/rel2?fields[mocktypes1]=str&sort=-str",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"data": [
+					{
+						"attributes": {"str": "str1"},
+						"id": "mt1-1",
+						"links": {"self": "/mocktypes1/mt1-1"},
+						"type": "mocktypes1"
+					}
+				],
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": "/mocktypes3/mt3-1/rel2?fields%5Bmocktypes1%5D=str` +
+				`&sort=-str%2Cbool%2Cint%2Cint16%2Cint32%2Cint64%2Cint8%2Ctime` +
+				`%2Cuint%2Cuint16%2Cuint32%2Cuint64%2Cuint8%2Cid"
+				}
+			}`,
+		}, {
+			name:           "update related",
+			method:         "PATCH",
+			url:            "/mocktypes3/mt3-1/rel1",
+			body:           `{"data": null}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+		}, {
+			name:           "unsupported method",
+			method:         "PUT",
+			url:            "/mocktypes3",
+			expectedStatus: http.StatusMethodNotAllowed,
+		}, {
+			name:           "store error",
+			method:         "DELETE",
+			url:            "/mocktypes3/mt3-1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			store := newMockStore(schema)
+			handler := NewHandler(schema, store)
+
+			body := bytes.NewBufferString(test.body)
+			req := httptest.NewRequest(test.method, test.url, body)
+			rec := httptest.NewRecorder()
+
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(test.expectedStatus, rec.Code)
+
+			if test.expectedBody != "" {
+				assert.Equal(MediaType, rec.Header().Get("Content-Type"))
+				assert.JSONEq(test.expectedBody, rec.Body.String())
+			}
+
+			assert.Equal(test.expectedLoc, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHandlerPartialUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	typ, err := BuildType(validatedType{})
+	assert.NoError(err)
+
+	schema := &Schema{Types: []Type{typ}}
+	store := NewMemoryStore(schema)
+	handler := NewHandler(schema, store)
+
+	res := &SoftResource{}
+	res.SetType(&typ)
+	res.SetID("v1")
+	res.Set("name", "abc")
+	res.Set("code", []byte{1, 2})
+
+	_, err = store.Create(res)
+	assert.NoError(err)
+
+	tests := []struct {
+		name           string
+		attrs          string
+		expectedStatus int
+		expectedName   string
+		expectedAge    int
+	}{
+		{
+			name:           "required attribute left out",
+			attrs:          `{"age": 4}`,
+			expectedStatus: http.StatusOK,
+			expectedName:   "abc",
+			expectedAge:    4,
+		}, {
+			name:           "required attribute emptied",
+			attrs:          `{"name": ""}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedName:   "abc",
+			expectedAge:    4,
+		}, {
+			name:           "invalid attribute",
+			attrs:          `{"age": 200}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedName:   "abc",
+			expectedAge:    4,
+		},
+	}
+
+	for _, test := range tests {
+		body := bytes.NewBufferString(`{
+			"data": {
+				"id": "v1",
+				"type": "validated",
+				"attributes": ` + test.attrs + `
+			}
+		}`)
+		req := httptest.NewRequest("PATCH", "/validated/v1", body)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		assert.Equal(test.expectedStatus, rec.Code, test.name)
+
+		res, err := store.Resource("validated", "v1")
+		assert.NoError(err)
+		assert.Equal(test.expectedName, res.Get("name"), test.name)
+		assert.Equal(test.expectedAge, res.Get("age"), test.name)
+	}
+}
+
+func TestHandlerPaginationLinks(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ := schema.GetType("mocktypes3")
+	store := NewMemoryStore(schema)
+	handler := NewHandler(schema, store)
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		res := &SoftResource{Type: &typ}
+		res.SetID(id)
+
+		_, err := store.Create(res)
+		assert.NoError(err)
+	}
+
+	get := func(url string) map[string]string {
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+		assert.Equal(http.StatusOK, rec.Code, url)
+
+		doc := struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			Links map[string]string `json:"links"`
+		}{}
+
+		assert.NoError(json.Unmarshal(rec.Body.Bytes(), &doc), url)
+
+		links := map[string]string{}
+
+		for name, link := range doc.Links {
+			if name != "self" {
+				links[name] = link
+			}
+		}
+
+		ids := ""
+
+		for _, res := range doc.Data {
+			ids += res.ID
+		}
+
+		links["ids"] = ids
+
+		return links
+	}
+
+	page := func(num string) string {
+		if num != "0" {
+			num = "&page%5Bnumber%5D=" + num
+		} else {
+			num = ""
+		}
+
+		return "/mocktypes3?fields%5Bmocktypes3%5D=attr1%2Cattr2%2Crel1%2Crel2" +
+			num + "&page%5Bsize%5D=2&sort=id%2Cattr1%2Cattr2"
+	}
+
+	// Page numbers
+	links := get("/mocktypes3?sort=id&page[size]=2&page[number]=1")
+	assert.Equal(map[string]string{
+		"ids":   "cd",
+		"first": page("0"),
+		"prev":  page("0"),
+		"next":  page("2"),
+		"last":  page("2"),
+	}, links)
+
+	links = get("/mocktypes3?sort=id&page[size]=2&page[number]=2")
+	assert.Equal("e", links["ids"])
+	assert.Empty(links["next"])
+	assert.Equal(page("1"), links["prev"])
+
+	// Cursors
+	links = get("/mocktypes3?sort=id&page[size]=2&page[cursor]=")
+	assert.Equal("ab", links["ids"])
+	assert.NotEmpty(links["next"])
+	assert.Empty(links["prev"])
+
+	links = get(links["next"])
+	assert.Equal("cd", links["ids"])
+	assert.NotEmpty(links["next"])
+	assert.NotEmpty(links["prev"])
+
+	next := links["next"]
+
+	links = get(links["prev"])
+	assert.Equal("ab", links["ids"])
+	assert.Empty(links["prev"])
+
+	links = get(next)
+	assert.Equal("e", links["ids"])
+	assert.Empty(links["next"])
+	assert.NotEmpty(links["prev"])
+}
+
+// mockStore is a simple Store backed by SoftCollections.
+type mockStore struct {
+	schema *Schema
+	cols   map[string]*SoftCollection
+}
+
+func newMockStore(schema *Schema) *mockStore {
+	store := &mockStore{
+		schema: schema,
+		cols:   map[string]*SoftCollection{},
+	}
+
+	for i := range schema.Types {
+		col := &SoftCollection{}
+		col.SetType(&schema.Types[i])
+		store.cols[schema.Types[i].Name] = col
+	}
+
+	store.cols["mocktypes1"].Add(Wrap(&mockType1{ID: "mt1-1", Str: "str1"}))
+	store.cols["mocktypes1"].Add(Wrap(&mockType1{ID: "mt1-2", Str: "str2"}))
+	store.cols["mocktypes3"].Add(Wrap(&mockType3{
+		ID:    "mt3-1",
+		Attr1: "a",
+		Rel1:  "mt1-1",
+		Rel2:  []string{"mt1-1"},
+	}))
+	store.cols["mocktypes3"].Add(Wrap(&mockType3{ID: "mt3-2", Attr1: "b"}))
+
+	return store
+}
+
+func (m *mockStore) Resource(typ, id string) (Resource, error) {
+	return m.cols[typ].Resource(id, nil), nil
+}
+
+func (m *mockStore) Collection(url *URL) (Collection, error) {
+	var ids []string
+
+	if url.BelongsToFilter.ID != "" {
+		btf := url.BelongsToFilter
+		parent := m.cols[btf.Type].Resource(btf.ID, nil)
+		ids = parent.Get(btf.Name).([]string)
+	}
+
+	return Range(
+		m.cols[url.ResType],
+		ids,
+		url.Params.Filter,
+		url.Params.SortingRules,
+		10,
+		0,
+	), nil
+}
+
+func (m *mockStore) Create(res Resource) (Resource, error) {
+	m.cols[res.GetType().Name].Add(res)
+	return res, nil
+}
+
+func (m *mockStore) Update(res *SoftResource) (Resource, error) {
+	existing := m.cols[res.GetType().Name].Resource(res.GetID(), nil)
+
+	for _, attr := range res.Attrs() {
+		existing.Set(attr.Name, res.Get(attr.Name))
+	}
+
+	return existing, nil
+}
+
+func (m *mockStore) Delete(typ, id string) error {
+	if id == "mt3-1" {
+		return errors.New("cannot delete mt3-1")
+	}
+
+	m.cols[typ].Remove(id)
+
+	return nil
+}
+
+func (m *mockStore) SetRel(typ, id, rel string, ids []string) error {
+	res := m.cols[typ].Resource(id, nil)
+
+	if res.Rels()[rel].ToOne {
+		if len(ids) == 0 {
+			ids = []string{""}
+		}
+
+		res.Set(rel, ids[0])
+	} else {
+		res.Set(rel, ids)
+	}
+
+	return nil
+}
+
+func (m *mockStore) AddToRel(typ, id, rel string, ids []string) error {
+	res := m.cols[typ].Resource(id, nil)
+	res.Set(rel, append(res.Get(rel).([]string), ids...))
+
+	return nil
+}
+
+func (m *mockStore) RemoveFromRel(typ, id, rel string, ids []string) error {
+	res := m.cols[typ].Resource(id, nil)
+	kept := []string{}
+
+	for _, cur := range res.Get(rel).([]string) {
+		if !containsString(ids, cur) {
+			kept = append(kept, cur)
+		}
+	}
+
+	res.Set(rel, kept)
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
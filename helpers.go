@@ -1,6 +1,8 @@
 package jsonapi
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -11,7 +13,7 @@ import (
 // the first error it finds.
 //
 // It makes sure that the struct has an ID field of type string and that the api
-// key of the field tags are properly formatted.
+// and validate keys of the field tags are properly formatted.
 //
 // If nil is returned, then the value can be safely used with this library.
 func Check(v interface{}) error {
@@ -43,24 +45,7 @@ func Check(v interface{}) error {
 		sf := value.Type().Field(i)
 
 		if sf.Tag.Get("api") == "attr" {
-			isValid := false
-
-			switch sf.Type.String() {
-			case
-				"string",
-				"int", "int8", "int16", "int32", "int64",
-				"uint", "uint8", "uint16", "uint32", "uint64",
-				"bool",
-				"time.Time",
-				"[]uint8",
-				"*string",
-				"*int", "*int8", "*int16", "*int32", "*int64",
-				"*uint", "*uint8", "*uint16", "*uint32", "*uint64",
-				"*bool",
-				"*time.Time",
-				"*[]uint8":
-				isValid = true
-			}
+			_, isValid := goTypeToAttr(sf.Type)
 
 			if !isValid {
 				return fmt.Errorf(
@@ -69,6 +54,15 @@ func Check(v interface{}) error {
 					resType,
 				)
 			}
+
+			if _, err := parseAttrRules(sf.Tag.Get("validate")); err != nil {
+				return fmt.Errorf(
+					"jsonapi: attribute %q of type %q has an %s",
+					sf.Name,
+					resType,
+					err,
+				)
+			}
 		}
 	}
 
@@ -133,12 +127,10 @@ func BuildType(v interface{}) (Type, error) {
 		apiTag := fs.Tag.Get("api")
 
 		if apiTag == "attr" {
-			fieldType, null := GetAttrType(fs.Type.String())
-			typ.Attrs[jsonTag] = Attr{
-				Name:     jsonTag,
-				Type:     fieldType,
-				Nullable: null,
-			}
+			attr, _ := goTypeToAttr(fs.Type)
+			attr.Name = jsonTag
+			attr.Rules, _ = parseAttrRules(fs.Tag.Get("validate"))
+			typ.Attrs[jsonTag] = attr
 		}
 	}
 
@@ -222,3 +214,82 @@ func IDAndType(v interface{}) (string, string) {
 
 	return "", ""
 }
+
+// goTypeToAttr returns an Attr (without a name) that describes the values of
+// the Go type t. The returned boolean is false if t cannot be the type of an
+// attribute.
+//
+// Slices of scalars are arrays, structs are objects whose fields are named
+// after their json tags, and maps with string keys, json.RawMessage, and
+// interface{} are free-form JSON values. Pointers make the attribute nullable.
+func goTypeToAttr(t reflect.Type) (Attr, bool) {
+	attr := Attr{}
+
+	attr.Type, attr.Nullable = GetAttrType(t.String())
+	if attr.Type != AttrTypeInvalid {
+		return attr, true
+	}
+
+	if t.Kind() == reflect.Ptr {
+		attr.Nullable = true
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(json.RawMessage{}),
+		t.Kind() == reflect.Interface && t.NumMethod() == 0,
+		t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		attr.Type = AttrTypeJSON
+	case t.Kind() == reflect.Slice:
+		elem, _ := goTypeToAttr(t.Elem())
+		if !isScalarAttrType(elem.Type) || elem.Nullable {
+			return Attr{}, false
+		}
+
+		attr.Type = AttrTypeArray
+		attr.Elem = elem.Type
+	case t.Kind() == reflect.Struct:
+		attr.Type = AttrTypeObject
+		attr.Fields = map[string]Attr{}
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name := strings.Split(sf.Tag.Get("json"), ",")[0]
+
+			if sf.PkgPath != "" || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = sf.Name
+			}
+
+			field, ok := goTypeToAttr(sf.Type)
+			if !ok {
+				return Attr{}, false
+			}
+
+			field.Name = name
+			attr.Fields[name] = field
+		}
+	default:
+		return Attr{}, false
+	}
+
+	return attr, true
+}
+
+// convertValue converts src into dst, a pointer, by going through their JSON
+// representations. Numbers are decoded as json.Number values when dst points
+// to an interface{}.
+func convertValue(src, dst interface{}) error {
+	payload, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	return dec.Decode(dst)
+}
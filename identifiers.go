@@ -37,8 +37,12 @@ func (i Identifiers) IDs() []string {
 }
 
 // Identifier represents a resource's type and ID.
+//
+// LID is the local ID of a resource that has no ID yet because it is created
+// by the same request. ID is empty when LID is used.
 type Identifier struct {
-	ID   string `json:"id"`
+	ID   string `json:"id,omitempty"`
+	LID  string `json:"lid,omitempty"`
 	Type string `json:"type"`
 }
 
@@ -55,7 +59,7 @@ func UnmarshalIdentifier(payload []byte, schema *Schema) (Identifier, error) {
 	}
 
 	switch {
-	case iden.ID == "":
+	case iden.ID == "" && iden.LID == "":
 		return Identifier{}, errors.New("identifier has no ID")
 	case iden.Type == "":
 		return Identifier{}, errors.New("identifier has no type")
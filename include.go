@@ -0,0 +1,215 @@
+package jsonapi
+
+// A BatchResolver loads many resources of the same type at once.
+type BatchResolver interface {
+	// Resources returns the resources of type typ identified by ids.
+	// The resources that do not exist are omitted and the order of the
+	// returned resources does not matter.
+	Resources(typ string, ids []string) ([]Resource, error)
+}
+
+// BuildIncluded adds to doc the resources requested by the include paths of url
+// (see Params.Include) and adds the relationships found in those paths to
+// doc.RelData, so that they are marshaled with their data.
+//
+// The related resources are loaded with r. The paths are walked together, one
+// relationship at a time, so r is called at most once per type for each level
+// of the paths, and never for resources that were already loaded. A resource
+// is only included once, and never if it is part of the primary data.
+func BuildIncluded(doc *Document, url *URL, r BatchResolver) error {
+	if len(url.Params.Include) == 0 {
+		return nil
+	}
+
+	if doc.RelData == nil {
+		doc.RelData = map[string][]string{}
+	}
+
+	b := &includeBuilder{
+		doc:    doc,
+		r:      r,
+		loaded: map[string]map[string]Resource{},
+		seen:   map[string]bool{},
+	}
+
+	// The primary resources are never included or loaded again.
+	root := &includeNode{typ: url.ResType}
+
+	switch data := doc.Data.(type) {
+	case Resource:
+		root.res = []Resource{data}
+	case Collection:
+		for i := 0; i < data.Len(); i++ {
+			root.res = append(root.res, data.At(i))
+		}
+	}
+
+	for _, res := range root.res {
+		b.keep(res)
+		b.seen[resourceKey(res)] = true
+	}
+
+	for _, res := range doc.Included {
+		b.keep(res)
+		b.seen[resourceKey(res)] = true
+	}
+
+	for _, path := range url.Params.Include {
+		root.add(path)
+		typ := url.ResType
+
+		for _, rel := range path {
+			addRelData(doc.RelData, typ, rel.FromName)
+			typ = rel.ToType
+		}
+	}
+
+	for level := []*includeNode{root}; len(level) > 0; {
+		next, err := b.walk(level)
+		if err != nil {
+			return err
+		}
+
+		level = next
+	}
+
+	return nil
+}
+
+// An includeNode is a relationship reached by one or more include paths. res
+// holds the resources found at that point of the paths.
+type includeNode struct {
+	typ      string
+	rel      Rel
+	res      []Resource
+	children []*includeNode
+}
+
+// add adds the relationships of path under n.
+func (n *includeNode) add(path []Rel) {
+	if len(path) == 0 {
+		return
+	}
+
+	for _, child := range n.children {
+		if child.rel.FromName == path[0].FromName {
+			child.add(path[1:])
+			return
+		}
+	}
+
+	child := &includeNode{typ: path[0].ToType, rel: path[0]}
+	n.children = append(n.children, child)
+	child.add(path[1:])
+}
+
+// An includeBuilder holds the state of BuildIncluded.
+type includeBuilder struct {
+	doc *Document
+	r   BatchResolver
+
+	// loaded maps types and IDs to the resources already loaded
+	// and seen holds the keys of the resources already in the
+	// document.
+	loaded map[string]map[string]Resource
+	seen   map[string]bool
+}
+
+// walk loads the related resources of the nodes of a level of the paths,
+// includes them, and returns the nodes of the next level.
+func (b *includeBuilder) walk(level []*includeNode) ([]*includeNode, error) {
+	// The IDs are gathered by type so that each type is loaded
+	// in one call.
+	types := []string{}
+	missing := map[string][]string{}
+	queued := map[string]bool{}
+
+	for _, n := range level {
+		for _, child := range n.children {
+			for _, id := range relatedIDs(n.res, child.rel) {
+				key := id + " " + child.typ
+				if _, ok := b.loaded[child.typ][id]; ok || queued[key] {
+					continue
+				}
+
+				if _, ok := missing[child.typ]; !ok {
+					types = append(types, child.typ)
+				}
+
+				missing[child.typ] = append(missing[child.typ], id)
+				queued[key] = true
+			}
+		}
+	}
+
+	for _, typ := range types {
+		ress, err := b.r.Resources(typ, missing[typ])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, res := range ress {
+			b.keep(res)
+		}
+	}
+
+	next := []*includeNode{}
+
+	for _, n := range level {
+		for _, child := range n.children {
+			for _, id := range relatedIDs(n.res, child.rel) {
+				res, ok := b.loaded[child.typ][id]
+				if !ok {
+					continue
+				}
+
+				child.res = append(child.res, res)
+
+				if key := resourceKey(res); !b.seen[key] {
+					b.seen[key] = true
+					b.doc.Included = append(b.doc.Included, res)
+				}
+			}
+
+			if len(child.children) > 0 {
+				next = append(next, child)
+			}
+		}
+	}
+
+	return next, nil
+}
+
+// keep remembers res as loaded.
+func (b *includeBuilder) keep(res Resource) {
+	typ := res.GetType().Name
+
+	if b.loaded[typ] == nil {
+		b.loaded[typ] = map[string]Resource{}
+	}
+
+	b.loaded[typ][res.Get("id").(string)] = res
+}
+
+// relatedIDs returns the IDs of the relationship rel of the resources of ress,
+// without duplicates.
+func relatedIDs(ress []Resource, rel Rel) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+
+	for _, res := range ress {
+		for _, id := range relIDs(res, rel.FromName) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// resourceKey returns a key that identifies res among resources of all types.
+func resourceKey(res Resource) string {
+	return res.Get("id").(string) + " " + res.GetType().Name
+}
@@ -0,0 +1,150 @@
+package jsonapi_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ BatchResolver = (*MemoryStore)(nil)
+	_ BatchResolver = (*MemoryTx)(nil)
+)
+
+func TestBuildIncluded(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ1 := schema.GetType("mocktypes1")
+	typ2 := schema.GetType("mocktypes2")
+
+	newRes := func(typ *Type, id string, rels map[string]interface{}) *SoftResource {
+		res := &SoftResource{Type: typ}
+		res.SetID(id)
+
+		for rel, val := range rels {
+			res.Set(rel, val)
+		}
+
+		return res
+	}
+
+	primary := &SoftCollection{}
+	primary.SetType(&typ1)
+	primary.Add(newRes(&typ1, "mt1-1", map[string]interface{}{
+		"to-one":            "mt2-1",
+		"to-many-from-many": []string{"mt2-1", "mt2-2"},
+	}))
+	primary.Add(newRes(&typ1, "mt1-2", map[string]interface{}{
+		"to-many-from-many": []string{"mt2-2", "mt2-9", "mt2-3"},
+	}))
+
+	resolver := &mockBatchResolver{res: map[string]Resource{}}
+
+	for _, res := range []Resource{
+		newRes(&typ1, "mt1-3", nil),
+		newRes(&typ2, "mt2-1", map[string]interface{}{"to-one-from-many": "mt1-1"}),
+		newRes(&typ2, "mt2-2", map[string]interface{}{"to-one-from-many": "mt1-3"}),
+		newRes(&typ2, "mt2-3", nil),
+	} {
+		resolver.res[res.GetType().Name+" "+res.Get("id").(string)] = res
+	}
+
+	url, err := NewURLFromRaw(
+		schema,
+		"/mocktypes1?include=to-one,to-many-from-many.to-one-from-many",
+	)
+	assert.NoError(err)
+
+	// Collection
+	doc := &Document{Data: primary}
+	err = BuildIncluded(doc, url, resolver)
+	assert.NoError(err)
+
+	included := []string{}
+
+	for _, res := range doc.Included {
+		included = append(included, res.GetType().Name+" "+res.Get("id").(string))
+	}
+
+	assert.Equal([]string{
+		"mocktypes2 mt2-1",
+		"mocktypes2 mt2-2",
+		"mocktypes2 mt2-3",
+		"mocktypes1 mt1-3",
+	}, included)
+	assert.Equal(map[string][]string{
+		"mocktypes1": {"to-many-from-many", "to-one"},
+		"mocktypes2": {"to-one-from-many"},
+	}, doc.RelData)
+	assert.Equal([]string{
+		"mocktypes2 mt2-1,mt2-2,mt2-9,mt2-3",
+		"mocktypes1 mt1-3",
+	}, resolver.calls)
+
+	// Resource
+	resolver.calls = nil
+	doc = &Document{Data: primary.At(1)}
+	err = BuildIncluded(doc, url, resolver)
+	assert.NoError(err)
+	assert.Len(doc.Included, 3)
+	assert.Equal([]string{
+		"mocktypes2 mt2-2,mt2-9,mt2-3",
+		"mocktypes1 mt1-3",
+	}, resolver.calls)
+
+	// No include paths
+	resolver.calls = nil
+	url, _ = NewURLFromRaw(schema, "/mocktypes1")
+	doc = &Document{Data: primary}
+	err = BuildIncluded(doc, url, resolver)
+	assert.NoError(err)
+	assert.Nil(doc.Included)
+	assert.Nil(resolver.calls)
+
+	// Errors are returned as is
+	resolver.err = errors.New("unavailable")
+	url, _ = NewURLFromRaw(schema, "/mocktypes1?include=to-one")
+	err = BuildIncluded(&Document{Data: primary}, url, resolver)
+	assert.Equal(resolver.err, err)
+
+	// MemoryStore
+	store := NewMemoryStore(schema)
+	_, err = store.Create(newRes(&typ1, "mt1-1", nil))
+	assert.NoError(err)
+
+	ress, err := store.Resources("mocktypes1", []string{"mt1-1", "mt1-2"})
+	assert.NoError(err)
+	assert.Len(ress, 1)
+	assert.Equal("mt1-1", ress[0].Get("id"))
+
+	_, err = store.Resources("unknown", []string{"mt1-1"})
+	assert.Error(err)
+}
+
+type mockBatchResolver struct {
+	res   map[string]Resource
+	calls []string
+	err   error
+}
+
+func (m *mockBatchResolver) Resources(typ string, ids []string) ([]Resource, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.calls = append(m.calls, typ+" "+strings.Join(ids, ","))
+	ress := []Resource{}
+
+	for _, id := range ids {
+		if res, ok := m.res[typ+" "+id]; ok {
+			ress = append(ress, res)
+		}
+	}
+
+	return ress, nil
+}
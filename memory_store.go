@@ -0,0 +1,732 @@
+package jsonapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// NewMemoryStore returns a *MemoryStore that holds an empty collection for
+// each type of schema.
+func NewMemoryStore(schema *Schema) *MemoryStore {
+	m := &MemoryStore{
+		schema:   schema,
+		cols:     map[string]*SoftCollection{},
+		versions: map[string]uint64{},
+	}
+
+	for _, typ := range schema.Types {
+		typ := typ.Copy()
+		col := &SoftCollection{}
+		col.SetType(&typ)
+		m.cols[typ.Name] = col
+	}
+
+	return m
+}
+
+// MemoryStore is an in-memory Store where each type of a schema is stored in a
+// SoftCollection.
+//
+// Changes are made through transactions (see Begin). The Store methods of
+// MemoryStore each run in their own transaction. A transaction reads from the
+// snapshot of the store taken when it began and is not affected by other
+// transactions committed in the meantime.
+//
+// When a relationship that has an inverse (Rel.ToName) is modified, the
+// inverse relationships of the resources involved are updated accordingly.
+//
+// A transaction copies the collection of a type the first time it modifies it,
+// so MemoryStore is best suited for test suites and small services. Once
+// committed, a collection is never modified again, only replaced, which is what
+// lets transactions read it concurrently.
+type MemoryStore struct {
+	schema *Schema
+
+	mu       sync.RWMutex
+	cols     map[string]*SoftCollection
+	versions map[string]uint64
+}
+
+// Begin starts and returns a new transaction.
+func (m *MemoryStore) Begin() *MemoryTx {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tx := &MemoryTx{
+		store:    m,
+		base:     make(map[string]*SoftCollection, len(m.cols)),
+		versions: make(map[string]uint64, len(m.versions)),
+		cols:     map[string]*SoftCollection{},
+	}
+
+	for typ, col := range m.cols {
+		tx.base[typ] = col
+		tx.versions[typ] = m.versions[typ]
+	}
+
+	return tx
+}
+
+// AddIndex adds a secondary index on the attribute attr of the collection of
+// type typ (see SoftCollection.AddIndex). The index is kept by the following
+// transactions.
+func (m *MemoryStore) AddIndex(typ, attr string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	col, ok := m.cols[typ]
+	if !ok {
+		return fmt.Errorf("jsonapi: type %q does not exist", typ)
+	}
+
+	// Transactions may be reading the collection, so it is
+	// replaced by an indexed copy.
+	cp := &SoftCollection{}
+	cp.SetType(col.Type)
+
+	for i := 0; i < col.Len(); i++ {
+		cp.Add(col.At(i))
+	}
+
+	for _, name := range append(col.Indexes(), attr) {
+		err := cp.AddIndex(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.cols[typ] = cp
+
+	return nil
+}
+
+// Resource implements the Store interface.
+func (m *MemoryStore) Resource(typ, id string) (Resource, error) {
+	return m.Begin().Resource(typ, id)
+}
+
+// Resources implements the BatchResolver interface.
+func (m *MemoryStore) Resources(typ string, ids []string) ([]Resource, error) {
+	return m.Begin().Resources(typ, ids)
+}
+
+// Collection implements the Store interface.
+func (m *MemoryStore) Collection(url *URL) (Collection, error) {
+	return m.Begin().Collection(url)
+}
+
+// Create implements the Store interface.
+func (m *MemoryStore) Create(res Resource) (Resource, error) {
+	var created Resource
+
+	err := m.run(func(tx *MemoryTx) error {
+		var err error
+		created, err = tx.Create(res)
+
+		return err
+	})
+
+	return created, err
+}
+
+// Update implements the Store interface.
+func (m *MemoryStore) Update(res *SoftResource) (Resource, error) {
+	var updated Resource
+
+	err := m.run(func(tx *MemoryTx) error {
+		var err error
+		updated, err = tx.Update(res)
+
+		return err
+	})
+
+	return updated, err
+}
+
+// Delete implements the Store interface.
+func (m *MemoryStore) Delete(typ, id string) error {
+	return m.run(func(tx *MemoryTx) error {
+		return tx.Delete(typ, id)
+	})
+}
+
+// SetRel implements the Store interface.
+func (m *MemoryStore) SetRel(typ, id, rel string, ids []string) error {
+	return m.run(func(tx *MemoryTx) error {
+		return tx.SetRel(typ, id, rel, ids)
+	})
+}
+
+// AddToRel implements the Store interface.
+func (m *MemoryStore) AddToRel(typ, id, rel string, ids []string) error {
+	return m.run(func(tx *MemoryTx) error {
+		return tx.AddToRel(typ, id, rel, ids)
+	})
+}
+
+// RemoveFromRel implements the Store interface.
+func (m *MemoryStore) RemoveFromRel(typ, id, rel string, ids []string) error {
+	return m.run(func(tx *MemoryTx) error {
+		return tx.RemoveFromRel(typ, id, rel, ids)
+	})
+}
+
+// Atomic implements the AtomicStore interface.
+func (m *MemoryStore) Atomic(fn func(tx Store) error) error {
+	return m.run(func(tx *MemoryTx) error {
+		return fn(tx)
+	})
+}
+
+// run executes fn in a new transaction which is committed if fn succeeds and
+// rolled back otherwise.
+func (m *MemoryStore) run(fn func(tx *MemoryTx) error) error {
+	tx := m.Begin()
+
+	err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// A MemoryTx is a transaction of a MemoryStore.
+//
+// It implements the Store interface. Changes are only visible to the rest of
+// the store once Commit is called. A MemoryTx must not be used concurrently
+// and can no longer be used after Commit or Rollback is called.
+type MemoryTx struct {
+	store *MemoryStore
+	done  bool
+
+	// base holds the collections as they were when the
+	// transaction began and versions their versions. cols
+	// holds the copies modified by the transaction.
+	base     map[string]*SoftCollection
+	versions map[string]uint64
+	cols     map[string]*SoftCollection
+}
+
+// Commit applies the changes made by the transaction to the store.
+//
+// A conflict error is returned and nothing is applied if a type modified by
+// the transaction has also been modified by another transaction committed
+// after this one began.
+func (t *MemoryTx) Commit() error {
+	if t.done {
+		return errTxDone()
+	}
+
+	t.done = true
+
+	m := t.store
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for typ := range t.cols {
+		if m.versions[typ] != t.versions[typ] {
+			return NewErrConflict()
+		}
+	}
+
+	for typ, col := range t.cols {
+		// The committed collections are only read from then on,
+		// so they are compacted now. Indexes may also have been
+		// added since the transaction began.
+		col.compact()
+
+		for _, attr := range m.cols[typ].Indexes() {
+			_ = col.AddIndex(attr)
+		}
+
+		m.cols[typ] = col
+		m.versions[typ]++
+	}
+
+	// The transaction no longer holds the collections it
+	// published.
+	t.cols = nil
+
+	return nil
+}
+
+// Rollback discards the changes made by the transaction.
+func (t *MemoryTx) Rollback() {
+	t.done = true
+	t.cols = nil
+}
+
+// Resource implements the Store interface.
+//
+// The returned resource is a copy that can be modified freely.
+func (t *MemoryTx) Resource(typ, id string) (Resource, error) {
+	col, err := t.collection(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	if res := col.Resource(id, nil); res != nil {
+		return res.(*SoftResource).Copy(), nil
+	}
+
+	return nil, nil
+}
+
+// Resources implements the BatchResolver interface.
+//
+// The returned resources are copies that can be modified freely.
+func (t *MemoryTx) Resources(typ string, ids []string) ([]Resource, error) {
+	col, err := t.collection(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	ress := make([]Resource, 0, len(ids))
+
+	for _, id := range ids {
+		if res := col.Resource(id, nil); res != nil {
+			ress = append(ress, res.(*SoftResource).Copy())
+		}
+	}
+
+	return ress, nil
+}
+
+// Collection implements the Store interface.
+//
+// A page size of 0 means that all the resources are returned.
+func (t *MemoryTx) Collection(url *URL) (Collection, error) {
+	col, err := t.collection(url.ResType)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	if btf := url.BelongsToFilter; btf.ID != "" {
+		parent, err := t.Resource(btf.Type, btf.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if parent == nil {
+			return nil, NewErrNotFound()
+		}
+
+		ids = relIDs(parent, btf.Name)
+		if len(ids) == 0 {
+			return &Resources{}, nil
+		}
+	}
+
+	size := url.Params.PageSize
+	if size == 0 {
+		size = uint(col.Len())
+	}
+
+	res, err := RangeWith(col, RangeOptions{
+		IDs:        ids,
+		Filter:     url.Params.Filter,
+		Resolver:   t,
+		Schema:     t.store.schema,
+		Sort:       url.Params.SortingRules,
+		PageSize:   size,
+		PageNumber: url.Params.PageNumber,
+		After:      url.Params.PageAfter,
+		Before:     url.Params.PageBefore,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := res.Page
+
+	copies := make(Resources, 0, page.Len())
+	for i := 0; i < page.Len(); i++ {
+		copies = append(copies, page.At(i).(*SoftResource).Copy())
+	}
+
+	res.Page = &copies
+
+	return &res, nil
+}
+
+// Create implements the Store interface.
+//
+// A random ID is assigned to res if it does not have one.
+func (t *MemoryTx) Create(res Resource) (Resource, error) {
+	typ := res.GetType().Name
+
+	col, err := t.writable(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	id := res.Get("id").(string)
+	if id == "" {
+		id = newID()
+	} else if col.Resource(id, nil) != nil {
+		return nil, NewErrConflict()
+	}
+
+	sr := &SoftResource{}
+	sr.SetType(col.Type)
+	sr.SetID(id)
+
+	for _, attr := range col.Type.Attrs {
+		if _, ok := res.Attrs()[attr.Name]; ok {
+			sr.Set(attr.Name, res.Get(attr.Name))
+		}
+	}
+
+	col.Add(sr)
+	created := col.Resource(id, nil).(*SoftResource)
+
+	for _, rel := range col.Type.Rels {
+		if _, ok := res.Rels()[rel.FromName]; ok {
+			err = t.setRel(created, rel, relIDs(res, rel.FromName))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return created.Copy(), nil
+}
+
+// Update implements the Store interface.
+func (t *MemoryTx) Update(res *SoftResource) (Resource, error) {
+	existing, err := t.writableResource(res.GetType().Name, res.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attr := range res.Attrs() {
+		if _, ok := existing.Attrs()[attr.Name]; ok {
+			// The store does not share values with res.
+			existing.Set(attr.Name, copyValue(res.Get(attr.Name)))
+		}
+	}
+
+	for _, rel := range res.Rels() {
+		if rel, ok := existing.Rels()[rel.FromName]; ok {
+			err = t.setRel(existing, rel, relIDs(res, rel.FromName))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return existing.Copy(), nil
+}
+
+// Delete implements the Store interface.
+//
+// The resource is also removed from the inverse relationships that point to
+// it.
+func (t *MemoryTx) Delete(typ, id string) error {
+	res, err := t.writableResource(typ, id)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range res.Rels() {
+		err = t.setRel(res, rel, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.cols[typ].Remove(id)
+
+	return nil
+}
+
+// SetRel implements the Store interface.
+func (t *MemoryTx) SetRel(typ, id, rel string, ids []string) error {
+	res, err := t.writableResource(typ, id)
+	if err != nil {
+		return err
+	}
+
+	r, ok := res.Rels()[rel]
+	if !ok {
+		return NewErrUnknownRelationshipInPath(typ, rel, typ+"/"+id)
+	}
+
+	return t.setRel(res, r, ids)
+}
+
+// AddToRel implements the Store interface.
+func (t *MemoryTx) AddToRel(typ, id, rel string, ids []string) error {
+	res, err := t.writableResource(typ, id)
+	if err != nil {
+		return err
+	}
+
+	r, ok := res.Rels()[rel]
+	if !ok || r.ToOne {
+		return NewErrUnknownRelationshipInPath(typ, rel, typ+"/"+id)
+	}
+
+	newIDs := relIDs(res, rel)
+	for _, id := range ids {
+		newIDs = addID(newIDs, id)
+	}
+
+	return t.setRel(res, r, newIDs)
+}
+
+// RemoveFromRel implements the Store interface.
+func (t *MemoryTx) RemoveFromRel(typ, id, rel string, ids []string) error {
+	res, err := t.writableResource(typ, id)
+	if err != nil {
+		return err
+	}
+
+	r, ok := res.Rels()[rel]
+	if !ok || r.ToOne {
+		return NewErrUnknownRelationshipInPath(typ, rel, typ+"/"+id)
+	}
+
+	newIDs := relIDs(res, rel)
+	for _, id := range ids {
+		newIDs = removeID(newIDs, id)
+	}
+
+	return t.setRel(res, r, newIDs)
+}
+
+// setRel sets the relationship rel of res to ids and updates the inverse
+// relationships of the resources that are added to or removed from it.
+//
+// res must belong to a collection returned by writable.
+func (t *MemoryTx) setRel(res *SoftResource, rel Rel, ids []string) error {
+	oldIDs := relIDs(res, rel.FromName)
+
+	if rel.ToOne {
+		id := ""
+		if len(ids) > 0 {
+			id = ids[len(ids)-1]
+		}
+
+		ids = []string{}
+		if id != "" {
+			ids = []string{id}
+		}
+
+		res.Set(rel.FromName, id)
+	} else {
+		newIDs := []string{}
+		for _, id := range ids {
+			newIDs = addID(newIDs, id)
+		}
+
+		ids = newIDs
+		res.Set(rel.FromName, newIDs)
+	}
+
+	if rel.ToName == "" {
+		return nil
+	}
+
+	// Inverse relationships
+	typ := res.GetType().Name
+
+	col, err := t.writable(rel.ToType)
+	if err != nil {
+		return err
+	}
+
+	inv, ok := col.Type.Rels[rel.ToName]
+	if !ok {
+		return fmt.Errorf(
+			"jsonapi: inverse relationship %q of type %q does not exist",
+			rel.ToName, rel.ToType,
+		)
+	}
+
+	for _, id := range oldIDs {
+		if containsID(ids, id) {
+			continue
+		}
+
+		if target := col.Resource(id, nil); target != nil {
+			target := target.(*SoftResource)
+			target.Set(inv.FromName, removeRelID(target, inv, res.GetID()))
+		}
+	}
+
+	for _, id := range ids {
+		if containsID(oldIDs, id) {
+			continue
+		}
+
+		target := col.Resource(id, nil)
+		if target == nil {
+			continue
+		}
+
+		tres := target.(*SoftResource)
+
+		if inv.ToOne {
+			// The target can only point to one resource, so
+			// the one it currently points to loses it.
+			if prev := tres.Get(inv.FromName).(string); prev != "" && prev != res.GetID() {
+				prevCol, _ := t.writable(typ)
+
+				if prevRes := prevCol.Resource(prev, nil); prevRes != nil {
+					prevRes := prevRes.(*SoftResource)
+					prevRes.Set(rel.FromName, removeRelID(prevRes, rel, tres.GetID()))
+				}
+			}
+
+			tres.Set(inv.FromName, res.GetID())
+		} else {
+			tres.Set(inv.FromName, addID(relIDs(tres, inv.FromName), res.GetID()))
+		}
+	}
+
+	return nil
+}
+
+// collection returns the collection of type typ as seen by the transaction.
+func (t *MemoryTx) collection(typ string) (*SoftCollection, error) {
+	if t.done {
+		return nil, errTxDone()
+	}
+
+	if col, ok := t.cols[typ]; ok {
+		return col, nil
+	}
+
+	if col, ok := t.base[typ]; ok {
+		return col, nil
+	}
+
+	return nil, fmt.Errorf("jsonapi: type %q does not exist", typ)
+}
+
+// writable returns the collection of type typ that can be modified by the
+// transaction.
+//
+// The collection is copied from the base collection the first time.
+func (t *MemoryTx) writable(typ string) (*SoftCollection, error) {
+	col, err := t.collection(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := t.cols[typ]; !ok {
+		cp := &SoftCollection{}
+		cp.SetType(col.Type)
+
+		for i := 0; i < col.Len(); i++ {
+			cp.Add(col.At(i))
+		}
+
+		// The indexes are built once the resources are added,
+		// which is faster than updating them for each resource.
+		for _, attr := range col.Indexes() {
+			_ = cp.AddIndex(attr)
+		}
+
+		t.cols[typ] = cp
+		col = cp
+	}
+
+	return col, nil
+}
+
+// writableResource returns the resource of type typ identified by id from the
+// collection returned by writable.
+func (t *MemoryTx) writableResource(typ, id string) (*SoftResource, error) {
+	col, err := t.writable(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	res := col.Resource(id, nil)
+	if res == nil {
+		return nil, NewErrNotFound()
+	}
+
+	return res.(*SoftResource), nil
+}
+
+// relIDs returns a copy of the IDs of the relationship rel of res.
+func relIDs(res Resource, rel string) []string {
+	switch v := res.Get(rel).(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []string:
+		ids := make([]string, len(v))
+		copy(ids, v)
+
+		return ids
+	}
+
+	return []string{}
+}
+
+// removeRelID returns the value of the relationship rel of res without id.
+func removeRelID(res Resource, rel Rel, id string) interface{} {
+	if rel.ToOne {
+		if res.Get(rel.FromName).(string) == id {
+			return ""
+		}
+
+		return res.Get(rel.FromName)
+	}
+
+	return removeID(relIDs(res, rel.FromName), id)
+}
+
+func containsID(ids []string, id string) bool {
+	for i := range ids {
+		if ids[i] == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func addID(ids []string, id string) []string {
+	if containsID(ids, id) {
+		return ids
+	}
+
+	return append(ids, id)
+}
+
+func removeID(ids []string, id string) []string {
+	kept := make([]string, 0, len(ids))
+
+	for i := range ids {
+		if ids[i] != id {
+			kept = append(kept, ids[i])
+		}
+	}
+
+	return kept
+}
+
+// newID returns a random ID made of 32 hexadecimal characters.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func errTxDone() error {
+	return errors.New("jsonapi: transaction is already committed or rolled back")
+}
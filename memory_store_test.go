@@ -0,0 +1,494 @@
+package jsonapi_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*MemoryTx)(nil)
+)
+
+func TestMemoryStore(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+
+	// Create
+	res := &SoftResource{}
+	typ := schema.GetType("mocktypes3")
+	res.SetType(&typ)
+	res.SetID("mt3-1")
+	res.Set("attr1", "abc")
+	res.Set("attr2", 3)
+
+	created, err := store.Create(res)
+	assert.NoError(err)
+	assert.Equal("mt3-1", created.Get("id"))
+	assert.Equal("abc", created.Get("attr1"))
+
+	_, err = store.Create(res)
+	assert.Equal(NewErrConflict(), err)
+
+	res.SetID("")
+	created, err = store.Create(res)
+	assert.NoError(err)
+	assert.Len(created.Get("id").(string), 32)
+
+	_, err = store.Resource("unknown", "abc")
+	assert.Error(err)
+
+	// Resource
+	found, err := store.Resource("mocktypes3", "mt3-1")
+	assert.NoError(err)
+	assert.Equal(3, found.Get("attr2"))
+
+	// Returned resources are copies.
+	found.Set("attr2", 4)
+	found, _ = store.Resource("mocktypes3", "mt3-1")
+	assert.Equal(3, found.Get("attr2"))
+
+	found, err = store.Resource("mocktypes3", "mt3-3")
+	assert.NoError(err)
+	assert.Nil(found)
+
+	// Update
+	upd := &SoftResource{}
+	upd.SetType(&Type{Name: "mocktypes3"})
+	upd.SetID("mt3-1")
+	upd.AddAttr(Attr{Name: "attr1", Type: AttrTypeString})
+	upd.Set("attr1", "def")
+
+	updated, err := store.Update(upd)
+	assert.NoError(err)
+	assert.Equal("def", updated.Get("attr1"))
+	assert.Equal(3, updated.Get("attr2"))
+
+	upd.SetID("mt3-3")
+	_, err = store.Update(upd)
+	assert.Equal(NewErrNotFound(), err)
+
+	// Collection
+	url, err := NewURLFromRaw(schema, "/mocktypes3?sort=attr1")
+	assert.NoError(err)
+
+	col, err := store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(2, col.Len())
+	assert.Equal("def", col.At(1).Get("attr1"))
+
+	url, err = NewURLFromRaw(schema, "/mocktypes3?page[size]=1&page[number]=1&sort=attr1")
+	assert.NoError(err)
+
+	col, err = store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(1, col.Len())
+	assert.Equal("mt3-1", col.At(0).Get("id"))
+
+	// Delete
+	err = store.Delete("mocktypes3", "mt3-1")
+	assert.NoError(err)
+
+	found, _ = store.Resource("mocktypes3", "mt3-1")
+	assert.Nil(found)
+
+	err = store.Delete("mocktypes3", "mt3-1")
+	assert.Equal(NewErrNotFound(), err)
+}
+
+func TestMemoryStoreInverseRelationships(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+
+	for _, id := range []string{"mt1-1", "mt1-2"} {
+		res := &SoftResource{}
+		typ := schema.GetType("mocktypes1")
+		res.SetType(&typ)
+		res.SetID(id)
+		_, _ = store.Create(res)
+	}
+
+	for _, id := range []string{"mt2-1", "mt2-2"} {
+		res := &SoftResource{}
+		typ := schema.GetType("mocktypes2")
+		res.SetType(&typ)
+		res.SetID(id)
+		_, _ = store.Create(res)
+	}
+
+	get := func(typ, id, rel string) interface{} {
+		res, err := store.Resource(typ, id)
+		assert.NoError(err)
+
+		return res.Get(rel)
+	}
+
+	// One-to-one
+	err := store.SetRel("mocktypes1", "mt1-1", "to-one-from-one", []string{"mt2-1"})
+	assert.NoError(err)
+	assert.Equal("mt1-1", get("mocktypes2", "mt2-1", "to-one-from-one"))
+
+	err = store.SetRel("mocktypes1", "mt1-2", "to-one-from-one", []string{"mt2-1"})
+	assert.NoError(err)
+	assert.Equal("mt1-2", get("mocktypes2", "mt2-1", "to-one-from-one"))
+	assert.Equal("", get("mocktypes1", "mt1-1", "to-one-from-one"))
+
+	err = store.SetRel("mocktypes1", "mt1-2", "to-one-from-one", []string{"mt2-2"})
+	assert.NoError(err)
+	assert.Equal("", get("mocktypes2", "mt2-1", "to-one-from-one"))
+	assert.Equal("mt1-2", get("mocktypes2", "mt2-2", "to-one-from-one"))
+
+	// One-to-many
+	err = store.SetRel("mocktypes1", "mt1-1", "to-many-from-one", []string{"mt2-1", "mt2-2"})
+	assert.NoError(err)
+	assert.Equal("mt1-1", get("mocktypes2", "mt2-1", "to-one-from-many"))
+	assert.Equal("mt1-1", get("mocktypes2", "mt2-2", "to-one-from-many"))
+
+	err = store.AddToRel("mocktypes1", "mt1-2", "to-many-from-one", []string{"mt2-2"})
+	assert.NoError(err)
+	assert.Equal("mt1-2", get("mocktypes2", "mt2-2", "to-one-from-many"))
+	assert.Equal([]string{"mt2-1"}, get("mocktypes1", "mt1-1", "to-many-from-one"))
+
+	err = store.SetRel("mocktypes2", "mt2-1", "to-one-from-many", []string{"mt1-2"})
+	assert.NoError(err)
+	assert.Equal([]string{}, get("mocktypes1", "mt1-1", "to-many-from-one"))
+	assert.Equal([]string{"mt2-2", "mt2-1"}, get("mocktypes1", "mt1-2", "to-many-from-one"))
+
+	// Many-to-many
+	err = store.AddToRel("mocktypes1", "mt1-1", "to-many-from-many", []string{"mt2-1", "mt2-2"})
+	assert.NoError(err)
+	err = store.AddToRel("mocktypes1", "mt1-2", "to-many-from-many", []string{"mt2-1"})
+	assert.NoError(err)
+	assert.Equal([]string{"mt1-1", "mt1-2"}, get("mocktypes2", "mt2-1", "to-many-from-many"))
+	assert.Equal([]string{"mt1-1"}, get("mocktypes2", "mt2-2", "to-many-from-many"))
+
+	err = store.RemoveFromRel("mocktypes1", "mt1-1", "to-many-from-many", []string{"mt2-1"})
+	assert.NoError(err)
+	assert.Equal([]string{"mt1-2"}, get("mocktypes2", "mt2-1", "to-many-from-many"))
+
+	err = store.AddToRel("mocktypes1", "mt1-1", "to-one", []string{"mt2-1"})
+	assert.Error(err)
+
+	// Delete
+	err = store.Delete("mocktypes1", "mt1-2")
+	assert.NoError(err)
+	assert.Equal("", get("mocktypes2", "mt2-1", "to-one-from-many"))
+	assert.Equal("", get("mocktypes2", "mt2-2", "to-one-from-one"))
+	assert.Equal([]string{}, get("mocktypes2", "mt2-1", "to-many-from-many"))
+
+	// Collection of related resources
+	url, err := NewURLFromRaw(schema, "/mocktypes1/mt1-1/to-many-from-many")
+	assert.NoError(err)
+
+	col, err := store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(1, col.Len())
+	assert.Equal("mt2-2", col.At(0).Get("id"))
+
+	url, err = NewURLFromRaw(schema, "/mocktypes1/mt1-1/to-many-from-one")
+	assert.NoError(err)
+
+	col, err = store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(0, col.Len())
+}
+
+func TestMemoryTx(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+
+	newRes := func(id string) Resource {
+		res := &SoftResource{}
+		typ := schema.GetType("mocktypes3")
+		res.SetType(&typ)
+		res.SetID(id)
+
+		return res
+	}
+
+	// Rollback
+	tx := store.Begin()
+	_, err := tx.Create(newRes("mt3-1"))
+	assert.NoError(err)
+
+	found, _ := tx.Resource("mocktypes3", "mt3-1")
+	assert.NotNil(found)
+
+	tx.Rollback()
+
+	found, _ = store.Resource("mocktypes3", "mt3-1")
+	assert.Nil(found)
+
+	_, err = tx.Resource("mocktypes3", "mt3-1")
+	assert.Error(err)
+	assert.Error(tx.Commit())
+
+	// Snapshot isolation
+	tx1 := store.Begin()
+	tx2 := store.Begin()
+
+	_, err = tx1.Create(newRes("mt3-1"))
+	assert.NoError(err)
+	assert.NoError(tx1.Commit())
+
+	found, _ = tx2.Resource("mocktypes3", "mt3-1")
+	assert.Nil(found)
+
+	found, _ = store.Resource("mocktypes3", "mt3-1")
+	assert.NotNil(found)
+
+	// Conflicts
+	_, err = tx2.Create(newRes("mt3-2"))
+	assert.NoError(err)
+
+	err = tx2.Commit()
+	assert.Equal(NewErrConflict(), err)
+
+	found, _ = store.Resource("mocktypes3", "mt3-2")
+	assert.Nil(found)
+
+	// Transactions that touch different types do not conflict.
+	tx1 = store.Begin()
+	tx2 = store.Begin()
+
+	_, err = tx1.Create(newRes("mt3-3"))
+	assert.NoError(err)
+
+	res := &SoftResource{}
+	typ := schema.GetType("mocktypes2")
+	res.SetType(&typ)
+	res.SetID("mt2-1")
+	_, err = tx2.Create(res)
+	assert.NoError(err)
+
+	assert.NoError(tx1.Commit())
+	assert.NoError(tx2.Commit())
+}
+
+func TestMemoryStoreIndexes(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+	typ := schema.GetType("mocktypes3")
+
+	assert.Error(store.AddIndex("unknown", "attr2"))
+	assert.Error(store.AddIndex("mocktypes3", "unknown"))
+
+	create := func(id string, attr2 int) {
+		res := &SoftResource{}
+		res.SetType(&typ)
+		res.SetID(id)
+		res.Set("attr2", attr2)
+
+		_, err := store.Create(res)
+		assert.NoError(err)
+	}
+
+	create("mt3-1", 3)
+
+	// A transaction that began before the index was added.
+	tx := store.Begin()
+
+	assert.NoError(store.AddIndex("mocktypes3", "attr2"))
+
+	res := &SoftResource{}
+	res.SetType(&typ)
+	res.SetID("mt3-2")
+	res.Set("attr2", 1)
+
+	_, err := tx.Create(res)
+	assert.NoError(err)
+	assert.NoError(tx.Commit())
+
+	// Updates go through the index.
+	found, _ := store.Resource("mocktypes3", "mt3-1")
+	found.Set("attr2", 0)
+
+	_, err = store.Update(found.(*SoftResource))
+	assert.NoError(err)
+
+	create("mt3-3", 2)
+
+	url, err := NewURLFromRaw(schema, `/mocktypes3?sort=-attr2&filter={"f":"attr2","o":"<","v":2}`)
+	assert.NoError(err)
+
+	col, err := store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(2, col.Len())
+	assert.Equal("mt3-2", col.At(0).Get("id"))
+	assert.Equal("mt3-1", col.At(1).Get("id"))
+}
+
+func TestMemoryStoreConcurrentReads(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+	typ := schema.GetType("mocktypes3")
+
+	for i := 0; i < 5; i++ {
+		res := &SoftResource{Type: &typ}
+		res.SetID(fmt.Sprintf("mt3-%d", i))
+
+		_, err := store.Create(res)
+		assert.NoError(err)
+	}
+
+	assert.NoError(store.Delete("mocktypes3", "mt3-2"))
+
+	// Reading the committed collection must not modify it, which
+	// go test -race checks.
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			url, _ := NewURLFromRaw(schema, "/mocktypes3")
+			col, err := store.Collection(url)
+			assert.NoError(err)
+			assert.Equal(4, col.Len())
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestMemoryStoreConcurrentTransactions(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+	typ := schema.GetType("mocktypes3")
+
+	// retry runs fn again as long as it conflicts with another
+	// transaction.
+	retry := func(fn func() error) error {
+		for {
+			err := fn()
+			if err == nil || err.Error() != NewErrConflict().Error() {
+				return err
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	// Writers create, update and delete resources while readers
+	// read the collection, which go test -race checks.
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 20; i++ {
+				res := &SoftResource{Type: &typ}
+				res.SetID(fmt.Sprintf("mt3-%d-%d", w, i))
+				res.Set("attr2", i+1)
+
+				assert.NoError(retry(func() error {
+					_, err := store.Create(res)
+					return err
+				}))
+
+				res.Set("attr2", i+2)
+
+				assert.NoError(retry(func() error {
+					_, err := store.Update(res)
+					return err
+				}))
+
+				if i%2 == 0 {
+					assert.NoError(retry(func() error {
+						return store.Delete("mocktypes3", res.GetID())
+					}))
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			url, _ := NewURLFromRaw(schema, "/mocktypes3?sort=-attr2")
+
+			for i := 0; i < 20; i++ {
+				col, err := store.Collection(url)
+				assert.NoError(err)
+
+				for j := 0; j < col.Len(); j++ {
+					assert.NotEqual(0, col.At(j).Get("attr2"))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	url, _ := NewURLFromRaw(schema, "/mocktypes3")
+	col, err := store.Collection(url)
+	assert.NoError(err)
+	assert.Equal(40, col.Len())
+}
+
+func TestMemoryStoreCopiesValues(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := Type{Name: "things"}
+	_ = typ.AddAttr(Attr{Name: "tags", Type: AttrTypeArray, Elem: AttrTypeString})
+	_ = typ.AddAttr(Attr{
+		Name:   "geo",
+		Type:   AttrTypeObject,
+		Fields: map[string]Attr{"city": {Name: "city", Type: AttrTypeString}},
+	})
+	schema := &Schema{Types: []Type{typ}}
+	store := NewMemoryStore(schema)
+
+	tags := []string{"a", "b"}
+	geo := map[string]interface{}{"city": "Montreal"}
+
+	res := &SoftResource{Type: &typ}
+	res.SetID("thing1")
+	res.Set("tags", tags)
+	res.Set("geo", geo)
+
+	_, err := store.Create(res)
+	assert.NoError(err)
+
+	// Create
+	tags[0] = "c"
+	geo["city"] = "Quebec"
+
+	found, _ := store.Resource("things", "thing1")
+	assert.Equal([]string{"a", "b"}, found.Get("tags"))
+	assert.Equal(map[string]interface{}{"city": "Montreal"}, found.Get("geo"))
+
+	// Update
+	_, err = store.Update(res)
+	assert.NoError(err)
+
+	tags[1] = "d"
+	geo["city"] = "Laval"
+
+	found, _ = store.Resource("things", "thing1")
+	assert.Equal([]string{"c", "b"}, found.Get("tags"))
+	assert.Equal(map[string]interface{}{"city": "Quebec"}, found.Get("geo"))
+}
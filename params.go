@@ -161,8 +161,22 @@ func NewParams(schema *Schema, su SimpleURL, resType string) (*Params, error) {
 
 	// Filter
 	params.FilterLabel = su.FilterLabel
-	params.Filter = su.Filter
-	// TODO Check whether the filter is valid
+
+	if su.Filter == nil && su.FilterLabel != "" {
+		filter, err := schema.ResolveFilterLabel(resType, su.FilterLabel)
+		if err != nil {
+			return nil, err
+		}
+
+		params.Filter = filter
+	} else if su.Filter != nil {
+		filter, err := su.Filter.Coerce(schema, resType)
+		if err != nil {
+			return nil, err
+		}
+
+		params.Filter = filter
+	}
 
 	// Sorting
 	// TODO All of the following is just to figure out
@@ -185,25 +199,16 @@ func NewParams(schema *Schema, su SimpleURL, resType string) (*Params, error) {
 		idFound := false
 
 		for _, rule := range su.SortingRules {
-			urule := rule
-			if urule[0] == '-' {
-				urule = urule[1:]
+			if !checkSortRule(schema, resType, rule) {
+				return nil, NewErrUnknownFieldInSortParameter(rule)
 			}
 
-			if urule == "id" {
-				idFound = true
-
-				sortingRules = append(sortingRules, rule)
+			sortingRules = append(sortingRules, rule)
 
+			if name, _, _ := parseSortRule(rule); name == "id" {
+				idFound = true
 				break
 			}
-
-			for _, attr := range typ.Attrs {
-				if urule == attr.Name {
-					sortingRules = append(sortingRules, rule)
-					break
-				}
-			}
 		}
 
 		// Add 1 because of id
@@ -213,12 +218,7 @@ func NewParams(schema *Schema, su SimpleURL, resType string) (*Params, error) {
 			found := false
 
 			for _, rule := range sortingRules {
-				urule := rule
-				if urule[0] == '-' {
-					urule = urule[1:]
-				}
-
-				if urule == attr.Name {
+				if name, _, _ := parseSortRule(rule); name == attr.Name {
 					found = true
 					break
 				}
@@ -242,6 +242,35 @@ func NewParams(schema *Schema, su SimpleURL, resType string) (*Params, error) {
 	// Pagination
 	params.PageSize = su.PageSize
 	params.PageNumber = su.PageNumber
+	params.CursorPagination = su.CursorPagination
+	params.PageAfter = su.PageAfter
+	params.PageBefore = su.PageBefore
+
+	if params.CursorPagination {
+		for _, rule := range params.SortingRules {
+			if strings.Contains(rule, ".") {
+				return nil, NewErrSortRuleWithCursors(rule)
+			}
+		}
+	}
+
+	if typ := schema.GetType(resType); typ.Name != "" {
+		cursors := []struct{ param, cursor string }{
+			{"page[after]", params.PageAfter},
+			{"page[before]", params.PageBefore},
+		}
+
+		for _, c := range cursors {
+			if c.cursor == "" {
+				continue
+			}
+
+			_, err := DecodeCursor(c.cursor, &typ, params.SortingRules)
+			if err != nil {
+				return nil, NewErrInvalidPageCursorParameter(c.param, c.cursor)
+			}
+		}
+	}
 
 	return params, nil
 }
@@ -257,14 +286,68 @@ type Params struct {
 	// Filter
 	FilterLabel string
 	Filter      *Filter
+	FilterStyle string // FilterStyleJSON (default) or FilterStyleBrackets
 
 	// Sorting
+	//
+	// Each rule is a field name, prefixed with "-" for the descending
+	// order. The name can be a path that goes through to-one
+	// relationships, like author.name. It can be followed by
+	// ":nulls-first" or ":nulls-last" to choose where null values
+	// go. By default, they come first in ascending order and last in
+	// descending order. Paths cannot be used with cursor pagination.
 	SortingRules []string
 
 	// Pagination
 	PageSize   uint
 	PageNumber uint
 
+	// Cursor pagination
+	CursorPagination bool
+	PageAfter        string
+	PageBefore       string
+
 	// Include
 	Include [][]Rel
 }
+
+// checkSortRule reports whether rule is a valid sorting rule for the type named
+// typ of schema.
+//
+// The field must be the ID, an attribute, or a path that ends with an attribute
+// and only goes through to-one relationships. Arrays, objects and JSON values
+// cannot be compared, so they cannot be used. The null ordering can only be
+// given when the value can be null.
+func checkSortRule(schema *Schema, typ, rule string) bool {
+	name, _, nulls := parseSortRule(rule)
+	if nulls != "" && nulls != sortNullsFirst && nulls != sortNullsLast {
+		return false
+	}
+
+	if name == "id" {
+		return nulls == ""
+	}
+
+	attr, _, err := schema.ResolvePath(typ, name)
+	if err != nil || attr.Name == "" || !isScalarAttrType(attr.Type) {
+		return false
+	}
+
+	t := schema.GetType(typ)
+	for _, field := range strings.Split(name, ".") {
+		rel, ok := t.Rels[field]
+		if !ok {
+			break
+		}
+
+		if !rel.ToOne {
+			return false
+		}
+
+		t = schema.GetType(rel.ToType)
+	}
+
+	// A path has no value when a relationship is empty or when
+	// an object does not have the field.
+	return nulls == "" || attr.Nullable || strings.Contains(name, ".")
+}
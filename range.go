@@ -1,6 +1,13 @@
 package jsonapi
 
 import (
+	"bytes"
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
@@ -16,432 +23,736 @@ import (
 //
 // A non-nil Collection is always returned, but it can be empty.
 func Range(c Collection, ids []string, filter *Filter, sort []string, size uint, num uint) Collection {
-	col := sortedResources{}
+	// No cursors are given, so no error can occur.
+	res, _ := RangeWith(c, RangeOptions{
+		IDs:        ids,
+		Filter:     filter,
+		Sort:       sort,
+		PageSize:   size,
+		PageNumber: num,
+	})
+
+	return res.Page
+}
 
-	// Filter IDs
-	if len(ids) > 0 {
-		for i := 0; i < c.Len(); i++ {
-			for _, id := range ids {
-				res := c.At(i)
-				if res.Get("id").(string) == id {
-					col.col = append(col.col, res)
-				}
-			}
+// RangeOptions holds the parameters of RangeWith.
+type RangeOptions struct {
+	// IDs, if not empty, restricts the resources to the ones with
+	// one of those IDs.
+	IDs []string
+
+	// Filter, if not nil, is applied to the resources.
+	Filter *Filter
+
+	// Resolver, if not nil, is used to load the related resources
+	// when the filter or the sorting rules go through relationships.
+	Resolver Resolver
+
+	// Schema, if not nil, is used to compile the filter (see
+	// Filter.Compile). Otherwise, the type of the collection is
+	// used, which is enough unless the filter goes through
+	// relationships. The filter is not compiled if the collection
+	// has no type or if it is invalid for that type.
+	Schema *Schema
+
+	// Collation is the collation used to sort strings (see the
+	// Collation constants).
+	Collation string
+
+	// Sort holds the sorting rules (see Params.SortingRules). The ID
+	// is always used to break ties.
+	Sort []string
+
+	// PageSize is the maximum number of resources of the page and
+	// PageNumber is the index of the page.
+	PageSize   uint
+	PageNumber uint
+
+	// After and Before are cursors (see EncodeCursor). When After is
+	// set, only the resources after the cursor are considered. When
+	// Before is set, only the resources before the cursor are
+	// considered and, if After is not set, the page is made of the
+	// last resources before it.
+	After  string
+	Before string
+}
+
+// RangeResult holds the result of RangeWith.
+type RangeResult struct {
+	// Page is the requested page. It is never nil.
+	Page Collection
+
+	// HasMore reports whether there are resources after the page and
+	// HasPrev whether there are resources before it.
+	HasMore bool
+	HasPrev bool
+
+	// Total is the number of resources that match the IDs and the
+	// filter, regardless of the cursors and the pagination. It can be
+	// reported in Document.Total or in the meta object of a document.
+	Total int
+}
+
+// GetType implements the Collection interface.
+//
+// A *RangeResult is a Collection that holds the resources of the page. A Store
+// can return it from Collection so that the Handler knows where the page is in
+// the whole collection and adds the pagination links accordingly.
+func (r *RangeResult) GetType() Type {
+	return r.Page.GetType()
+}
+
+// Len implements the Collection interface.
+func (r *RangeResult) Len() int {
+	return r.Page.Len()
+}
+
+// At implements the Collection interface.
+func (r *RangeResult) At(i int) Resource {
+	return r.Page.At(i)
+}
+
+// Add implements the Collection interface.
+func (r *RangeResult) Add(res Resource) {
+	r.Page.Add(res)
+}
+
+// RangeWith is like Range, but it accepts more options and returns more
+// information about the result.
+//
+// The collection is not copied. Only the resources of the page are kept while
+// the collection is scanned, so asking for the first pages of a large
+// collection does not require sorting all of it.
+//
+// An error is returned if one of the cursors cannot be decoded.
+func RangeWith(c Collection, opts RangeOptions) (RangeResult, error) {
+	rules := sortingRulesWithID(opts.Sort)
+	resolver := opts.Resolver
+
+	for _, rule := range rules {
+		if strings.Contains(rule, ".") && resolver != nil {
+			// The related resources are needed for each
+			// comparison, so they are only loaded once.
+			resolver = &cachedResolver{r: resolver, res: map[[2]string]Resource{}}
+			break
 		}
-	} else {
-		for i := 0; i < c.Len(); i++ {
-			col.col = append(col.col, c.At(i))
+	}
+
+	less := func(r1, r2 Resource) bool {
+		return lessResources(r1, r2, rules, opts.Collation, resolver)
+	}
+
+	// Cursors
+	var after, before Resource
+
+	if opts.After != "" {
+		typ := c.GetType()
+
+		pivot, err := DecodeCursor(opts.After, &typ, rules)
+		if err != nil {
+			return RangeResult{Page: &Resources{}}, err
 		}
+
+		after = pivot
 	}
 
-	// Filter
-	if filter != nil {
-		i := 0
-		for i < col.Len() {
-			if !filter.IsAllowed(col.col[i]) {
-				col.col = append(col.col[:i], col.col[i+1:]...)
-			} else {
-				i++
-			}
+	if opts.Before != "" {
+		typ := c.GetType()
+
+		pivot, err := DecodeCursor(opts.Before, &typ, rules)
+		if err != nil {
+			return RangeResult{Page: &Resources{}}, err
 		}
+
+		before = pivot
+	}
+
+	// Filter
+	var allowed func(Resource) bool
+	if opts.Filter != nil {
+		allowed = rangeFilter(c.GetType(), opts)
 	}
 
-	// Sort
-	col.Sort(sort)
+	// The page is made of the resources found after the first skip
+	// ones of the window defined by the cursors, or of the last ones
+	// of the window if only Before is set.
+	size := int(opts.PageSize)
+	last := before != nil && after == nil
 
-	// Pagination
-	var page Resources
+	skip := 0
+	if !last {
+		skip = int(opts.PageNumber) * size
+	}
+
+	k := 0
+	if size > 0 {
+		k = skip + size
+	}
+
+	each, sorted := rangeCandidates(c, opts, rules)
+	sel := &selection{k: k, less: less, sorted: sorted, last: last}
+
+	result := RangeResult{}
+	lo, window := 0, 0
+
+	each(func(res Resource) {
+		if allowed != nil && !allowed(res) {
+			return
+		}
+
+		result.Total++
 
-	skip := int(num * size)
+		if after != nil && !less(after, res) {
+			lo++
+			return
+		}
+
+		if before != nil && !less(res, before) {
+			return
+		}
+
+		window++
 
-	if skip >= len(col.col) {
-		col = sortedResources{}
-	} else {
-		for i := skip; i < len(col.col) && i < skip+int(size); i++ {
-			page = append(page, col.col[i])
+		sel.push(res)
+	})
+
+	// Pagination
+	page := sel.result()
+	start := lo + window - len(page)
+
+	if !last {
+		if skip > len(page) {
+			skip = len(page)
 		}
+
+		page = page[skip:]
+		start = lo + skip
 	}
 
-	return &page
-}
+	result.Page = &page
+	result.HasPrev = start > 0
+	result.HasMore = start+len(page) < result.Total
 
-// sortedResources is an internal struct for sorting Collections with the Range
-// function.
-type sortedResources struct {
-	rules []string
-	col   Resources
+	return result, nil
 }
 
-// Sort rearranges the order of the collection according the rules.
-func (s sortedResources) Sort(rules []string) {
-	s.rules = rules
-	if len(s.rules) == 0 {
-		s.rules = []string{"id"}
+// rangeCandidates returns a function that calls its argument with each
+// resource of c that has one of the IDs of opts, if any, and whether the
+// resources are given in the order defined by rules.
+func rangeCandidates(c Collection, opts RangeOptions, rules []string) (func(func(Resource)), bool) {
+	if sc, ok := c.(*SoftCollection); ok {
+		// The indexes of the collection may give the resources
+		// and their order without scanning the whole collection.
+		if res, sorted, ok := sc.scan(opts, rules); ok {
+			return func(fn func(Resource)) {
+				for _, r := range res {
+					fn(r)
+				}
+			}, sorted
+		}
 	}
 
-	sort.Sort(s)
+	var ids map[string]struct{}
+	if len(opts.IDs) > 0 {
+		ids = stringSet(opts.IDs)
+	}
+
+	return func(fn func(Resource)) {
+		for i := 0; i < c.Len(); i++ {
+			res := c.At(i)
+
+			if ids != nil {
+				if _, ok := ids[res.Get("id").(string)]; !ok {
+					continue
+				}
+			}
+
+			fn(res)
+		}
+	}, false
 }
 
-// Len implements sort.Interface's Len method.
-func (s sortedResources) Len() int {
-	return len(s.col)
+// rangeFilter returns a predicate that applies the filter of opts to the
+// resources of type typ. The filter is compiled when possible.
+func rangeFilter(typ Type, opts RangeOptions) func(Resource) bool {
+	schema := opts.Schema
+	if schema == nil {
+		schema = &Schema{Types: []Type{typ}}
+	}
+
+	if typ.Name != "" {
+		allowed, err := opts.Filter.CompileWith(schema, typ.Name, opts.Resolver)
+		if err == nil {
+			return allowed
+		}
+	}
+
+	return func(res Resource) bool {
+		return opts.Filter.IsAllowedWith(res, opts.Resolver)
+	}
 }
 
-// Swap implements sort.Interface's Swap method.
-func (s sortedResources) Swap(i, j int) {
-	s.col[i], s.col[j] = s.col[j], s.col[i]
+// cachedResolver is a Resolver that keeps the resources it loads.
+type cachedResolver struct {
+	r   Resolver
+	res map[[2]string]Resource
 }
 
-// Less implements sort.Interface's Less method.
-func (s sortedResources) Less(i, j int) bool {
-	for _, r := range s.rules {
-		inverse := false
+// Resource implements the Resolver interface.
+func (c *cachedResolver) Resource(typ, id string) (Resource, error) {
+	key := [2]string{typ, id}
 
-		if strings.HasPrefix(r, "-") {
-			r = r[1:]
-			inverse = true
-		}
+	if res, ok := c.res[key]; ok {
+		return res, nil
+	}
 
-		if r == "id" {
-			return s.col[i].Get("id").(string) < s.col[j].Get("id").(string) != inverse
-		}
+	res, err := c.r.Resource(typ, id)
+	if err != nil {
+		return nil, err
+	}
 
-		v := s.col[i].Get(r)
-		v2 := s.col[j].Get(r)
+	c.res[key] = res
 
-		// Here we return true if v < v2.
-		// The "!= inverse" part acts as a XOR operation so that
-		// the opposite boolean is returned when inverse sorting
-		// is required.
-		switch v := v.(type) {
-		case string:
-			v2 := v2.(string)
-			if v == v2 {
-				continue
-			}
+	return res, nil
+}
 
-			return v < v2 != inverse
-		case int:
-			v2 := v2.(int)
-			if v == v2 {
-				continue
-			}
+// EncodeCursor returns an opaque cursor that represents the position of res in
+// a collection sorted according to rules.
+//
+// The cursor holds the values of the fields of res used in rules, and its ID.
+// An error is returned if a rule is not the ID or an attribute of the type of
+// res, like a rule that goes through relationships, or if a value cannot be
+// marshaled, like a float that is NaN.
+func EncodeCursor(res Resource, rules []string) (string, error) {
+	rules = sortingRulesWithID(rules)
+	vals := make([]interface{}, 0, len(rules))
+	typ := res.GetType()
+
+	for _, rule := range rules {
+		name, _, _ := parseSortRule(rule)
+
+		if _, ok := typ.Attrs[name]; !ok && name != "id" {
+			return "", fmt.Errorf("jsonapi: sorting rule %q cannot be used in a cursor", rule)
+		}
 
-			return v < v2 != inverse
-		case int8:
-			v2 := v2.(int8)
-			if v == v2 {
-				continue
-			}
+		vals = append(vals, res.Get(name))
+	}
 
-			return v < v2 != inverse
-		case int16:
-			v2 := v2.(int16)
-			if v == v2 {
-				continue
-			}
+	payload, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
 
-			return v < v2 != inverse
-		case int32:
-			v2 := v2.(int32)
-			if v == v2 {
-				continue
-			}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
 
-			return v < v2 != inverse
-		case int64:
-			v2 := v2.(int64)
-			if v == v2 {
-				continue
-			}
+// DecodeCursor decodes a cursor returned by EncodeCursor. The values are
+// converted into the types of the attributes of typ and a resource that holds
+// them is returned.
+//
+// rules must be the same as the ones used to encode the cursor. Cursors cannot
+// be used with rules that go through relationships, so an error is returned
+// for them.
+func DecodeCursor(cursor string, typ *Type, rules []string) (Resource, error) {
+	errInvalid := errors.New("jsonapi: invalid cursor")
+
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalid
+	}
 
-			return v < v2 != inverse
-		case uint:
-			v2 := v2.(uint)
-			if v == v2 {
-				continue
-			}
+	vals := []json.RawMessage{}
 
-			return v < v2 != inverse
-		case uint8:
-			v2 := v2.(uint8)
-			if v == v2 {
-				continue
-			}
+	err = json.Unmarshal(payload, &vals)
+	if err != nil {
+		return nil, errInvalid
+	}
 
-			return v < v2 != inverse
-		case uint16:
-			v2 := v2.(uint16)
-			if v == v2 {
-				continue
-			}
+	rules = sortingRulesWithID(rules)
+	if len(vals) != len(rules) {
+		return nil, errInvalid
+	}
 
-			return v < v2 != inverse
-		case uint32:
-			v2 := v2.(uint32)
-			if v == v2 {
-				continue
-			}
+	sr := &SoftResource{}
+	sr.SetType(&Type{Name: typ.Name})
 
-			return v < v2 != inverse
-		case bool:
-			v2 := v2.(bool)
-			if v == v2 {
-				continue
-			}
+	for i, rule := range rules {
+		name, _, _ := parseSortRule(rule)
 
-			return !v != inverse
-		case time.Time:
-			if v.Equal(v2.(time.Time)) {
-				continue
+		if name == "id" {
+			var id string
+
+			err = json.Unmarshal(vals[i], &id)
+			if err != nil {
+				return nil, errInvalid
 			}
 
-			return v.Before(v2.(time.Time)) != inverse
-		case []byte:
-			s2 := v2.([]byte)
-			for i := 0; i < len(v) && i < len(s2); i++ {
-				if v[i] == s2[i] {
-					continue
-				}
+			sr.SetID(id)
 
-				return v[i] < s2[i] != inverse
-			}
+			continue
+		}
 
-			if len(v) == len(s2) {
-				continue
-			}
+		attr, ok := typ.Attrs[name]
+		if !ok {
+			return nil, errInvalid
+		}
 
-			return len(v) < len(s2) != inverse
-		case *string:
-			v2 := v2.(*string)
-			if v == v2 {
-				continue
-			}
+		zero := reflect.TypeOf(attr.zeroValue())
+		if zero == nil {
+			return nil, errInvalid
+		}
 
-			if v == nil {
-				return !inverse
-			}
+		v := reflect.New(zero)
 
-			if v2 == nil {
-				return inverse
-			}
+		err = json.Unmarshal(vals[i], v.Interface())
+		if err != nil {
+			return nil, errInvalid
+		}
 
-			if *v == *v2 {
-				continue
-			}
+		sr.AddAttr(attr)
+		sr.Set(name, v.Elem().Interface())
+	}
 
-			return *v < *v2 != inverse
-		case *int:
-			v2 := v2.(*int)
-			if v == v2 {
-				continue
-			}
+	return sr, nil
+}
 
-			if v == nil {
-				return !inverse
-			}
+// sortingRulesWithID returns rules with "id" appended if it is not already
+// there, so that the order of resources is always deterministic.
+func sortingRulesWithID(rules []string) []string {
+	for _, rule := range rules {
+		if rule == "id" || rule == "-id" {
+			return rules
+		}
+	}
 
-			if v2 == nil {
-				return inverse
-			}
+	withID := make([]string, len(rules), len(rules)+1)
+	copy(withID, rules)
 
-			if *v == *v2 {
-				continue
-			}
+	return append(withID, "id")
+}
 
-			return *v < *v2 != inverse
-		case *int8:
-			v2 := v2.(*int8)
-			if v == v2 {
-				continue
-			}
+// A selection keeps the first k resources pushed to it according to less, or
+// the last k ones if last is true. It is used by RangeWith to find a page
+// without sorting the whole collection.
+//
+// If sorted is true, the resources are pushed in order.
+type selection struct {
+	k      int
+	less   func(r1, r2 Resource) bool
+	sorted bool
+	last   bool
+
+	// res is a heap where the first resource is the one that would
+	// be dropped first, unless the resources are pushed in order.
+	res Resources
+}
 
-			if v == nil {
-				return !inverse
-			}
+// push adds res to the selection if it is one of the k resources to keep.
+func (s *selection) push(res Resource) {
+	switch {
+	case s.k == 0:
+	case s.sorted && !s.last:
+		// The following resources cannot come before.
+		if len(s.res) < s.k {
+			s.res = append(s.res, res)
+		}
+	case s.sorted:
+		if len(s.res) == s.k {
+			s.res = s.res[1:]
+		}
 
-			if v2 == nil {
-				return inverse
-			}
+		s.res = append(s.res, res)
+	case len(s.res) < s.k:
+		heap.Push(s, res)
+	case s.keeps(res, s.res[0]):
+		s.res[0] = res
+		heap.Fix(s, 0)
+	}
+}
 
-			if *v == *v2 {
-				continue
-			}
+// result returns the resources of the selection in order.
+func (s *selection) result() Resources {
+	if !s.sorted {
+		sort.Slice(s.res, func(i, j int) bool {
+			return s.less(s.res[i], s.res[j])
+		})
+	}
 
-			return *v < *v2 != inverse
-		case *int16:
-			v2 := v2.(*int16)
-			if v == v2 {
-				continue
-			}
+	return s.res
+}
 
-			if v == nil {
-				return !inverse
-			}
+// keeps reports whether r1 is kept before r2.
+func (s *selection) keeps(r1, r2 Resource) bool {
+	if s.last {
+		return s.less(r2, r1)
+	}
 
-			if v2 == nil {
-				return inverse
-			}
+	return s.less(r1, r2)
+}
 
-			if *v == *v2 {
-				continue
-			}
+// Len implements heap.Interface's Len method.
+func (s *selection) Len() int {
+	return len(s.res)
+}
 
-			return *v < *v2 != inverse
-		case *int32:
-			v2 := v2.(*int32)
-			if v == v2 {
-				continue
-			}
+// Less implements heap.Interface's Less method.
+func (s *selection) Less(i, j int) bool {
+	return s.keeps(s.res[j], s.res[i])
+}
 
-			if v == nil {
-				return !inverse
-			}
+// Swap implements heap.Interface's Swap method.
+func (s *selection) Swap(i, j int) {
+	s.res[i], s.res[j] = s.res[j], s.res[i]
+}
 
-			if v2 == nil {
-				return inverse
-			}
+// Push implements heap.Interface's Push method.
+func (s *selection) Push(x interface{}) {
+	s.res = append(s.res, x.(Resource))
+}
 
-			if *v == *v2 {
-				continue
-			}
+// Pop implements heap.Interface's Pop method.
+func (s *selection) Pop() interface{} {
+	res := s.res[len(s.res)-1]
+	s.res = s.res[:len(s.res)-1]
 
-			return *v < *v2 != inverse
-		case *int64:
-			v2 := v2.(*int64)
-			if v == v2 {
-				continue
-			}
+	return res
+}
 
-			if v == nil {
-				return !inverse
-			}
+// lessResources reports whether r1 comes before r2 according to rules. Strings
+// are compared under the collation col.
+//
+// r is used to load the related resources when a rule goes through
+// relationships. A resource without a related resource
+// has a null value for that rule.
+func lessResources(r1, r2 Resource, rules []string, col string, r Resolver) bool {
+	for _, rule := range rules {
+		name, desc, nulls := parseSortRule(rule)
 
-			if v2 == nil {
-				return inverse
+		if name == "id" {
+			id1, id2 := r1.Get("id").(string), r2.Get("id").(string)
+			if id1 == id2 {
+				return false
 			}
 
-			if *v == *v2 {
-				continue
-			}
+			return id1 < id2 != desc
+		}
 
-			return *v < *v2 != inverse
-		case *uint:
-			v2 := v2.(*uint)
-			if v == v2 {
-				continue
-			}
+		var (
+			v, v2        interface{}
+			null1, null2 bool
+		)
 
-			if v == nil {
-				return !inverse
-			}
+		if strings.Contains(name, ".") {
+			v, v2 = pathSortValue(r1, name, r), pathSortValue(r2, name, r)
+			null1, null2 = isNull(v), isNull(v2)
+		} else {
+			v, v2 = r1.Get(name), r2.Get(name)
 
-			if v2 == nil {
-				return inverse
+			// Values of different types are not compared.
+			if reflect.TypeOf(v) == reflect.TypeOf(v2) {
+				null1, null2 = isNull(v), isNull(v2)
 			}
+		}
 
-			if *v == *v2 {
+		if null1 || null2 {
+			if null1 == null2 {
 				continue
 			}
 
-			return *v < *v2 != inverse
-		case *uint8:
-			v2 := v2.(*uint8)
-			if v == v2 {
-				continue
-			}
+			// Nulls come first in ascending order and last in
+			// descending order, unless the rule says otherwise.
+			first := nulls == sortNullsFirst || (nulls == "" && !desc)
 
-			if v == nil {
-				return !inverse
-			}
+			return null1 == first
+		}
 
-			if v2 == nil {
-				return inverse
-			}
+		c := compareSortValues(v, v2, col)
+		if c == 0 {
+			continue
+		}
 
-			if *v == *v2 {
-				continue
-			}
+		// The "!= desc" part acts as a XOR operation so that
+		// the opposite boolean is returned when inverse sorting
+		// is required.
+		return c < 0 != desc
+	}
 
-			return *v < *v2 != inverse
-		case *uint16:
-			v2 := v2.(*uint16)
-			if v == v2 {
-				continue
-			}
+	return false
+}
 
-			if v == nil {
-				return !inverse
-			}
+// compareSortValues returns -1, 0, or 1 whether v is smaller than, equal to,
+// or greater than v2. Both must be values of the same attribute and must not be
+// null. Strings are compared under the collation col.
+//
+// Values that cannot be ordered are equal.
+func compareSortValues(v, v2 interface{}, col string) int {
+	switch v := v.(type) {
+	case string:
+		return compareStrings(col, v, v2.(string))
+	case int:
+		return compareInts(int64(v), int64(v2.(int)))
+	case int8:
+		return compareInts(int64(v), int64(v2.(int8)))
+	case int16:
+		return compareInts(int64(v), int64(v2.(int16)))
+	case int32:
+		return compareInts(int64(v), int64(v2.(int32)))
+	case int64:
+		return compareInts(v, v2.(int64))
+	case uint:
+		return compareUints(uint64(v), uint64(v2.(uint)))
+	case uint8:
+		return compareUints(uint64(v), uint64(v2.(uint8)))
+	case uint16:
+		return compareUints(uint64(v), uint64(v2.(uint16)))
+	case uint32:
+		return compareUints(uint64(v), uint64(v2.(uint32)))
+	case uint64:
+		return compareUints(v, v2.(uint64))
+	case float32:
+		return compareFloats(float64(v), float64(v2.(float32)))
+	case float64:
+		return compareFloats(v, v2.(float64))
+	case bool:
+		return compareBools(v, v2.(bool))
+	case time.Time:
+		return compareTimes(v, v2.(time.Time))
+	case []byte:
+		return bytes.Compare(v, v2.([]byte))
+	case Decimal:
+		return v.Cmp(v2.(Decimal))
+	case UUID:
+		v2 := v2.(UUID)
+		return bytes.Compare(v[:], v2[:])
+	case *string:
+		return compareStrings(col, *v, *v2.(*string))
+	case *int:
+		return compareInts(int64(*v), int64(*v2.(*int)))
+	case *int8:
+		return compareInts(int64(*v), int64(*v2.(*int8)))
+	case *int16:
+		return compareInts(int64(*v), int64(*v2.(*int16)))
+	case *int32:
+		return compareInts(int64(*v), int64(*v2.(*int32)))
+	case *int64:
+		return compareInts(*v, *v2.(*int64))
+	case *uint:
+		return compareUints(uint64(*v), uint64(*v2.(*uint)))
+	case *uint8:
+		return compareUints(uint64(*v), uint64(*v2.(*uint8)))
+	case *uint16:
+		return compareUints(uint64(*v), uint64(*v2.(*uint16)))
+	case *uint32:
+		return compareUints(uint64(*v), uint64(*v2.(*uint32)))
+	case *uint64:
+		return compareUints(*v, *v2.(*uint64))
+	case *float32:
+		return compareFloats(float64(*v), float64(*v2.(*float32)))
+	case *float64:
+		return compareFloats(*v, *v2.(*float64))
+	case *bool:
+		return compareBools(*v, *v2.(*bool))
+	case *time.Time:
+		return compareTimes(*v, *v2.(*time.Time))
+	case *[]byte:
+		return bytes.Compare(*v, *v2.(*[]byte))
+	case *Decimal:
+		return v.Cmp(*v2.(*Decimal))
+	case *UUID:
+		v2 := v2.(*UUID)
+		return bytes.Compare(v[:], v2[:])
+	}
 
-			if v2 == nil {
-				return inverse
-			}
+	return 0
+}
 
-			if *v == *v2 {
-				continue
-			}
+// compareInts returns -1, 0, or 1 whether a is smaller than, equal to, or
+// greater than b. The other compare functions do the same for their types.
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
 
-			return *v < *v2 != inverse
-		case *uint32:
-			v2 := v2.(*uint32)
-			if v == v2 {
-				continue
-			}
+	return 0
+}
 
-			if v == nil {
-				return !inverse
-			}
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
 
-			if v2 == nil {
-				return inverse
-			}
+	return 0
+}
 
-			if *v == *v2 {
-				continue
-			}
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
 
-			return *v < *v2 != inverse
-		case *bool:
-			v2 := v2.(*bool)
-			if v == v2 {
-				continue
-			}
+	return 0
+}
 
-			if v == nil {
-				return !inverse
-			}
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
 
-			if v2 == nil {
-				return inverse
-			}
+	return 1
+}
 
-			if *v == *v2 {
-				continue
-			}
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
 
-			return !*v != inverse
-		case *time.Time:
-			v2 := v2.(*time.Time)
-			if v == v2 {
-				continue
-			}
+	return 0
+}
 
-			if v == nil {
-				return !inverse
-			}
+// Null orderings of the sorting rules.
+const (
+	sortNullsFirst = "nulls-first"
+	sortNullsLast  = "nulls-last"
+)
 
-			if v2 == nil {
-				return inverse
-			}
+// parseSortRule returns the name of the field of a sorting rule, whether the
+// order is descending, and where the null values go (see Params.SortingRules).
+// The null ordering is empty if the rule does not have one.
+func parseSortRule(rule string) (string, bool, string) {
+	desc := strings.HasPrefix(rule, "-")
+	if desc {
+		rule = rule[1:]
+	}
 
-			if v.Equal(*v2) {
-				continue
-			}
+	nulls := ""
+	if i := strings.LastIndexByte(rule, ':'); i != -1 {
+		rule, nulls = rule[:i], rule[i+1:]
+	}
 
-			return v.Before(*v2) != inverse
-		}
+	return rule, desc, nulls
+}
+
+// pathSortValue returns the value found at the end of path from res, or nil if
+// there is none.
+func pathSortValue(res Resource, path string, r Resolver) interface{} {
+	vals := pathValues(res, strings.Split(path, "."), r)
+	if len(vals) == 0 {
+		return nil
 	}
 
-	return false
+	return vals[0]
 }
@@ -2,6 +2,7 @@ package jsonapi_test
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"testing"
@@ -322,13 +323,13 @@ func TestSortResources(t *testing.T) {
 	expectedIDs := []string{
 		"id0", "id3", "id6", "id9", "id12", "id17", "id21", "id22", "id24",
 		"id32", "id36", "id37", "id39", "id47", "id51", "id52", "id54", "id62",
-		"id77", "id10", "id15", "id18", "id20", "id23", "id25", "id28", "id30",
-		"id33", "id35", "id38", "id40", "id43", "id45", "id48", "id50", "id53",
-		"id55", "id58", "id60", "id63", "id65", "id66", "id67", "id68", "id69",
-		"id70", "id73", "id75", "id78", "id80", "id81", "id82", "id83", "id84",
-		"id79", "id76", "id74", "id72", "id71", "id64", "id61", "id59", "id57",
-		"id56", "id49", "id46", "id44", "id42", "id41", "id34", "id31", "id29",
-		"id27", "id26", "id19", "id16", "id14", "id13", "id11", "id8", "id7",
+		"id66", "id67", "id69", "id77", "id81", "id82", "id84", "id15", "id18",
+		"id20", "id23", "id25", "id28", "id30", "id33", "id35", "id38", "id40",
+		"id43", "id45", "id48", "id50", "id53", "id55", "id58", "id60", "id63",
+		"id65", "id68", "id70", "id73", "id75", "id78", "id80", "id83", "id79",
+		"id76", "id74", "id72", "id71", "id64", "id61", "id59", "id57", "id56",
+		"id49", "id46", "id44", "id42", "id41", "id34", "id31", "id29", "id27",
+		"id26", "id19", "id16", "id14", "id13", "id11", "id10", "id8", "id7",
 		"id5", "id4", "id2", "id1",
 	}
 	assert.Equal(expectedIDs, ids, fmt.Sprintf("sort with rules: %v", rules))
@@ -403,3 +404,457 @@ func TestSortResources(t *testing.T) {
 		_ = Range(col1, nil, nil, []string{"samename", "id"}, 100, 0)
 	})
 }
+
+func TestRangeWith(t *testing.T) {
+	assert := assert.New(t)
+
+	// Collection
+	typ := &Type{Name: "type"}
+	_ = typ.AddAttr(Attr{
+		Name: "attr1",
+		Type: AttrTypeInt,
+	})
+	_ = typ.AddAttr(Attr{
+		Name:     "attr2",
+		Type:     AttrTypeTime,
+		Nullable: true,
+	})
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	col := &SoftCollection{}
+	col.SetType(typ)
+
+	for i, attr1 := range []int{3, 1, 2, 1, 3, 2, 1} {
+		sr := &SoftResource{}
+		sr.SetType(typ)
+		sr.SetID("res" + strconv.Itoa(i))
+		sr.Set("attr1", attr1)
+
+		if i%2 == 0 {
+			sr.Set("attr2", ptr(now.Add(time.Duration(i)*time.Hour)))
+		}
+
+		col.Add(sr)
+	}
+
+	getIDs := func(c Collection) []string {
+		ids := []string{}
+		for i := 0; i < c.Len(); i++ {
+			ids = append(ids, c.At(i).Get("id").(string))
+		}
+
+		return ids
+	}
+
+	rules := []string{"-attr1", "attr2"}
+	ids := []string{"res0", "res1", "res2", "res3", "res4", "res5"}
+
+	// First page
+	res, err := RangeWith(col, RangeOptions{
+		IDs:      ids,
+		Sort:     rules,
+		PageSize: 2,
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"res0", "res4"}, getIDs(res.Page))
+	assert.Equal(6, res.Total)
+	assert.True(res.HasMore)
+	assert.False(res.HasPrev)
+
+	// Next pages
+	after := mustEncodeCursor(res.Page.At(1), rules)
+
+	res, err = RangeWith(col, RangeOptions{
+		IDs:      ids,
+		Sort:     rules,
+		PageSize: 3,
+		After:    after,
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"res5", "res2", "res1"}, getIDs(res.Page))
+	assert.True(res.HasMore)
+	assert.True(res.HasPrev)
+
+	after = mustEncodeCursor(res.Page.At(2), rules)
+
+	res, err = RangeWith(col, RangeOptions{
+		IDs:      ids,
+		Sort:     rules,
+		PageSize: 3,
+		After:    after,
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"res3"}, getIDs(res.Page))
+	assert.False(res.HasMore)
+	assert.True(res.HasPrev)
+
+	// Previous page
+	before := mustEncodeCursor(res.Page.At(0), rules)
+
+	res, err = RangeWith(col, RangeOptions{
+		IDs:      ids,
+		Sort:     rules,
+		PageSize: 2,
+		Before:   before,
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"res2", "res1"}, getIDs(res.Page))
+	assert.True(res.HasMore)
+	assert.True(res.HasPrev)
+
+	// Between two cursors
+	res, err = RangeWith(col, RangeOptions{
+		Sort:     rules,
+		PageSize: 10,
+		After:    after,
+		Before:   mustEncodeCursor(res.Page.At(0), rules),
+	})
+	assert.NoError(err)
+	assert.Equal([]string{}, getIDs(res.Page))
+
+	res, err = RangeWith(col, RangeOptions{
+		Sort:     rules,
+		PageSize: 10,
+		After:    "invalid",
+	})
+	assert.Error(err)
+	assert.Equal(0, res.Page.Len())
+}
+
+func TestRangeWithPages(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := &Type{Name: "type"}
+	_ = typ.AddAttr(Attr{
+		Name: "attr1",
+		Type: AttrTypeInt,
+	})
+	_ = typ.AddAttr(Attr{
+		Name:     "attr2",
+		Type:     AttrTypeString,
+		Nullable: true,
+	})
+
+	soft := &SoftCollection{}
+	soft.SetType(typ)
+
+	indexed := &SoftCollection{}
+	indexed.SetType(typ)
+	_ = indexed.AddIndex("attr1")
+
+	plain := &Resources{}
+
+	for i := 0; i < 40; i++ {
+		sr := &SoftResource{}
+		sr.SetType(typ)
+		sr.SetID(fmt.Sprintf("res%02d", (i*7)%40))
+		sr.Set("attr1", (i*13)%9)
+
+		if i%3 != 0 {
+			sr.Set("attr2", ptr(strconv.Itoa((i*5)%11)))
+		}
+
+		soft.Add(sr)
+		indexed.Add(sr)
+		*plain = append(*plain, sr)
+	}
+
+	filter := &Filter{Field: "attr1", Op: ">=", Val: 2}
+
+	for _, rules := range [][]string{
+		{},
+		{"attr1"},
+		{"-attr1"},
+		{"attr2", "-attr1"},
+		{"-attr2", "-id"},
+	} {
+		// All the resources in order, like a full sort gives them.
+		all := Resources{}
+		for _, res := range *plain {
+			all = append(all, res)
+		}
+
+		withID := append(append([]string{}, rules...), "id")
+		sort.SliceStable(all, func(i, j int) bool {
+			for _, rule := range withID {
+				desc := rule[0] == '-'
+				name := rule
+				if desc {
+					name = rule[1:]
+				}
+
+				v1, v2 := fmt.Sprint(all[i].Get(name)), fmt.Sprint(all[j].Get(name))
+				if p, ok := all[i].Get(name).(*string); ok {
+					v1 = "\x00"
+					if p != nil {
+						v1 = "\x01" + *p
+					}
+				}
+
+				if p, ok := all[j].Get(name).(*string); ok {
+					v2 = "\x00"
+					if p != nil {
+						v2 = "\x01" + *p
+					}
+				}
+
+				if v1 != v2 {
+					return v1 < v2 != desc
+				}
+
+				if name == "id" {
+					break
+				}
+			}
+
+			return false
+		})
+
+		cursor := func(i int) string {
+			return mustEncodeCursor(all[i], rules)
+		}
+
+		for _, opts := range []RangeOptions{
+			{PageSize: 0},
+			{PageSize: 1},
+			{PageSize: 7, PageNumber: 2},
+			{PageSize: 7, PageNumber: 6},
+			{PageSize: 40},
+			{PageSize: 50, PageNumber: 1},
+			{PageSize: 5, After: cursor(3)},
+			{PageSize: 5, PageNumber: 1, After: cursor(30)},
+			{PageSize: 5, Before: cursor(3)},
+			{PageSize: 5, Before: cursor(20)},
+			{PageSize: 5, After: cursor(10), Before: cursor(13)},
+			{PageSize: 5, After: cursor(13), Before: cursor(10)},
+		} {
+			opts.Sort = rules
+
+			// The expected page comes from the sorted resources.
+			lo, hi := 0, len(all)
+
+			for i := range all {
+				if opts.After == cursor(i) {
+					lo = i + 1
+				}
+
+				if opts.Before == cursor(i) {
+					hi = i
+				}
+			}
+
+			if hi < lo {
+				hi = lo
+			}
+
+			size := int(opts.PageSize)
+			start := lo + int(opts.PageNumber)*size
+
+			if opts.Before != "" && opts.After == "" {
+				start = hi - size
+				if start < lo {
+					start = lo
+				}
+			}
+
+			if start > hi {
+				start = hi
+			}
+
+			end := start + size
+			if end > hi {
+				end = hi
+			}
+
+			expected := []string{}
+			for _, res := range all[start:end] {
+				expected = append(expected, res.Get("id").(string))
+			}
+
+			cols := []Collection{soft, indexed}
+			if opts.After == "" && opts.Before == "" {
+				// Resources has no type to decode cursors.
+				cols = append(cols, plain)
+			}
+
+			for _, c := range cols {
+				res, err := RangeWith(c, opts)
+				assert.NoError(err)
+				assert.Equal(expected, ids(res.Page), "%T %+v", c, opts)
+				assert.Equal(len(all), res.Total)
+				assert.Equal(start > 0, res.HasPrev)
+				assert.Equal(end < len(all), res.HasMore)
+			}
+		}
+
+		// Total counts the resources allowed by the filter.
+		for _, c := range []Collection{plain, soft, indexed} {
+			res, err := RangeWith(c, RangeOptions{
+				Filter:   filter,
+				Sort:     rules,
+				PageSize: 3,
+			})
+			assert.NoError(err)
+			assert.Equal(3, res.Page.Len())
+			assert.Equal(31, res.Total)
+			assert.True(res.HasMore)
+		}
+	}
+}
+
+func TestRangeSortingRules(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	store := NewMemoryStore(schema)
+	typ1 := schema.GetType("mocktypes1")
+	typ2 := schema.GetType("mocktypes2")
+
+	for i, str := range []string{"c", "a", "b"} {
+		res := &SoftResource{Type: &typ1}
+		res.SetID("mt1-" + strconv.Itoa(i))
+		res.Set("str", str)
+
+		_, err := store.Create(res)
+		assert.NoError(err)
+	}
+
+	col := &SoftCollection{}
+	col.SetType(&typ2)
+
+	for i, rel := range []string{"mt1-0", "", "mt1-1", "mt1-2", "mt1-0", "unknown"} {
+		res := &SoftResource{Type: &typ2}
+		res.SetID("mt2-" + strconv.Itoa(i))
+		res.Set("to-one-from-many", rel)
+
+		if i%2 == 0 {
+			res.Set("intptr", ptr(i))
+		}
+
+		col.Add(res)
+	}
+
+	tests := []struct {
+		rules    []string
+		expected []string
+	}{
+		{
+			rules:    []string{"to-one-from-many.str"},
+			expected: []string{"mt2-1", "mt2-5", "mt2-2", "mt2-3", "mt2-0", "mt2-4"},
+		}, {
+			rules:    []string{"-to-one-from-many.str"},
+			expected: []string{"mt2-0", "mt2-4", "mt2-3", "mt2-2", "mt2-1", "mt2-5"},
+		}, {
+			rules:    []string{"to-one-from-many.str:nulls-last"},
+			expected: []string{"mt2-2", "mt2-3", "mt2-0", "mt2-4", "mt2-1", "mt2-5"},
+		}, {
+			rules:    []string{"-to-one-from-many.str:nulls-first", "-id"},
+			expected: []string{"mt2-5", "mt2-1", "mt2-4", "mt2-0", "mt2-3", "mt2-2"},
+		}, {
+			rules:    []string{"intptr"},
+			expected: []string{"mt2-1", "mt2-3", "mt2-5", "mt2-0", "mt2-2", "mt2-4"},
+		}, {
+			rules:    []string{"-intptr"},
+			expected: []string{"mt2-4", "mt2-2", "mt2-0", "mt2-1", "mt2-3", "mt2-5"},
+		}, {
+			rules:    []string{"intptr:nulls-last"},
+			expected: []string{"mt2-0", "mt2-2", "mt2-4", "mt2-1", "mt2-3", "mt2-5"},
+		}, {
+			rules:    []string{"-intptr:nulls-first"},
+			expected: []string{"mt2-1", "mt2-3", "mt2-5", "mt2-4", "mt2-2", "mt2-0"},
+		},
+	}
+
+	for _, test := range tests {
+		res, err := RangeWith(col, RangeOptions{
+			Sort:     test.rules,
+			Resolver: store,
+			PageSize: 10,
+		})
+		assert.NoError(err)
+		assert.Equal(test.expected, ids(res.Page), "%v", test.rules)
+	}
+
+	// Without a resolver, the related resources are missing.
+	page := Range(col, nil, nil, []string{"-to-one-from-many.str"}, 10, 0)
+	assert.Equal([]string{"mt2-0", "mt2-1", "mt2-2", "mt2-3", "mt2-4", "mt2-5"}, ids(page))
+}
+
+func TestCursors(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := &Type{Name: "type"}
+	_ = typ.AddAttr(Attr{
+		Name: "attr1",
+		Type: AttrTypeString,
+	})
+	_ = typ.AddAttr(Attr{
+		Name:     "attr2",
+		Type:     AttrTypeUint8,
+		Nullable: true,
+	})
+
+	sr := &SoftResource{}
+	sr.SetType(typ)
+	sr.SetID("abc")
+	sr.Set("attr1", "str")
+	sr.Set("attr2", ptr(uint8(8)))
+
+	rules := []string{"attr1", "-attr2"}
+	cursor, err := EncodeCursor(sr, rules)
+	assert.NoError(err)
+
+	res, err := DecodeCursor(cursor, typ, rules)
+	assert.NoError(err)
+	assert.Equal("abc", res.Get("id"))
+	assert.Equal("str", res.Get("attr1"))
+	assert.Equal(ptr(uint8(8)), res.Get("attr2"))
+
+	// Null values
+	sr.Set("attr2", nil)
+	cursor = mustEncodeCursor(sr, rules)
+
+	res, err = DecodeCursor(cursor, typ, rules)
+	assert.NoError(err)
+	assert.Equal(nilptr("uint8"), res.Get("attr2"))
+
+	// Invalid cursors
+	_, err = DecodeCursor("%", typ, rules)
+	assert.Error(err)
+
+	_, err = DecodeCursor(cursor, typ, []string{"attr1"})
+	assert.Error(err)
+
+	_, err = DecodeCursor(cursor, typ, []string{"attr1", "attr3"})
+	assert.Error(err)
+
+	_, err = DecodeCursor(mustEncodeCursor(sr, []string{"attr2", "attr1"}), typ, rules)
+	assert.Error(err)
+
+	// Only the ID and attributes can be encoded.
+	for _, rule := range []string{"rel.attr1", "-unknown", "unknown:nulls-last"} {
+		_, err = EncodeCursor(sr, []string{"attr1", rule})
+		assert.EqualError(
+			err,
+			fmt.Sprintf("jsonapi: sorting rule %q cannot be used in a cursor", rule),
+		)
+	}
+
+	// Values that cannot be marshaled
+	_ = typ.AddAttr(Attr{Name: "attr3", Type: AttrTypeFloat64})
+	sr.Set("attr3", math.Inf(1))
+
+	_, err = EncodeCursor(sr, []string{"attr3"})
+	assert.EqualError(err, "json: unsupported value: +Inf")
+}
+
+// mustEncodeCursor returns the cursor of res and panics if it cannot be
+// encoded.
+func mustEncodeCursor(res Resource, rules []string) string {
+	cursor, err := EncodeCursor(res, rules)
+	if err != nil {
+		panic(err)
+	}
+
+	return cursor
+}
@@ -1,20 +1,55 @@
 package jsonapi
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // NewRequest builds and returns a *Request based on r and schema.
 //
 // schema can be nil, in which case no checks will be done to insure that the
 // request respects a specific schema.
+//
+// The payload of a PATCH request made to a resource URL is unmarshaled with
+// UnmarshalPartialResource, so the primary data only holds the fields found in
+// the payload. The payload of a request made to a relationship URL is made of
+// resource identifiers, so the primary data is an Identifier, an Identifiers,
+// or nil.
+//
+// The Content-Type and Accept headers are checked according to the content
+// negotiation rules of the specification. A 415 Unsupported Media Type error is
+// returned if the payload is not of the JSON:API media type, or if the media
+// type has parameters other than ext and profile or an unsupported extension. A
+// 406 Not Acceptable error is returned if the Accept header holds the JSON:API
+// media type, but only refused or with parameters that cannot be served. A
+// request without a Content-Type header is accepted.
 func NewRequest(r *http.Request, schema *Schema) (*Request, error) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	exts, profiles, err := negotiate(r, len(body) > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasExtension(r.Header.Get("Content-Type"), AtomicExtension) {
+		req, err := newAtomicRequest(r, body, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Extensions = exts
+		req.Profiles = profiles
+
+		return req, nil
+	}
+
 	su, err := NewSimpleURL(r.URL)
 	if err != nil {
 		return nil, err
@@ -27,7 +62,24 @@ func NewRequest(r *http.Request, schema *Schema) (*Request, error) {
 
 	var doc *Document
 
-	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+	switch {
+	case url.RelKind == "self":
+		if r.Method == http.MethodPost ||
+			r.Method == http.MethodPatch ||
+			r.Method == http.MethodDelete {
+			doc, err = unmarshalIdentifiersDocument(body, schema)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case r.Method == http.MethodPatch && !url.IsCol && url.RelKind == "":
+		// The missing attributes are not zero-filled, so
+		// only the ones in the payload are validated.
+		doc, err = unmarshalDocument(body, schema, true)
+		if err != nil {
+			return nil, err
+		}
+	case r.Method == http.MethodPost || r.Method == http.MethodPatch:
 		doc, err = UnmarshalDocument(body, schema)
 		if err != nil {
 			return nil, err
@@ -35,17 +87,218 @@ func NewRequest(r *http.Request, schema *Schema) (*Request, error) {
 	}
 
 	req := &Request{
-		Method: r.Method,
-		URL:    url,
-		Doc:    doc,
+		Method:     r.Method,
+		URL:        url,
+		Doc:        doc,
+		Extensions: exts,
+		Profiles:   profiles,
 	}
 
 	return req, nil
 }
 
 // A Request represents a JSON:API request.
+//
+// URL is nil for requests of the Atomic Operations extension, where the
+// operations are found in Doc.Operations.
+//
+// Extensions and Profiles hold the URIs found in the ext and profile parameters
+// of the media type of the request and of the media type chosen from the Accept
+// header.
 type Request struct {
 	Method string
 	URL    *URL
 	Doc    *Document
+
+	Extensions []string
+	Profiles   []string
+}
+
+// unmarshalIdentifiersDocument reads a payload where the primary data is made
+// of resource identifiers, like the ones sent to relationship URLs.
+func unmarshalIdentifiersDocument(payload []byte, schema *Schema) (*Document, error) {
+	ske := &payloadSkeleton{}
+
+	err := json.Unmarshal(payload, ske)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Meta: ske.Meta,
+	}
+
+	switch {
+	case len(ske.Data) == 0:
+		return nil, NewErrMissingDataMember()
+	case ske.Data[0] == '{':
+		doc.Data, err = UnmarshalIdentifier(ske.Data, schema)
+	case ske.Data[0] == '[':
+		doc.Data, err = UnmarshalIdentifiers(ske.Data, schema)
+	case string(ske.Data) != "null":
+		return nil, NewErrMissingDataMember()
+	}
+
+	if err != nil {
+		return nil, NewErrBadRequest("Invalid identifier", err.Error())
+	}
+
+	return doc, nil
+}
+
+// newAtomicRequest builds a *Request from a request of the Atomic Operations
+// extension.
+func newAtomicRequest(r *http.Request, body []byte, schema *Schema) (*Request, error) {
+	if r.Method != http.MethodPost {
+		return nil, NewErrMethodNotAllowed()
+	}
+
+	doc, err := UnmarshalDocument(body, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Operations == nil {
+		return nil, NewErrBadRequest(
+			"Missing operations",
+			"The atomic:operations top-level member is missing.",
+		)
+	}
+
+	req := &Request{
+		Method: r.Method,
+		Doc:    doc,
+	}
+
+	return req, nil
+}
+
+// hasExtension reports whether the ext parameter of the JSON:API media type
+// found in header contains the URI ext.
+func hasExtension(header, ext string) bool {
+	mt, params, err := mime.ParseMediaType(header)
+	if err != nil || mt != MediaType {
+		return false
+	}
+
+	for _, uri := range strings.Fields(params["ext"]) {
+		if uri == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+// supportedExtensions holds the URIs of the extensions supported by NewRequest.
+var supportedExtensions = []string{AtomicExtension}
+
+// negotiate checks the Content-Type and Accept headers of r and returns the
+// extensions and profiles of the request.
+//
+// hasBody reports whether the request has a payload, in which case the
+// Content-Type header, if present, must be the JSON:API media type.
+func negotiate(r *http.Request, hasBody bool) ([]string, []string, error) {
+	exts, profiles := []string{}, []string{}
+
+	// Content-Type
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, params, err := mime.ParseMediaType(ct)
+
+		switch {
+		case err != nil || mt != MediaType:
+			if hasBody {
+				e := NewErrUnsupportedMediaType()
+				e.Detail = "The media type of the payload must be " + MediaType + "."
+
+				return nil, nil, e
+			}
+		default:
+			if detail := checkMediaTypeParams(params); detail != "" {
+				e := NewErrUnsupportedMediaType()
+				e.Detail = detail
+
+				return nil, nil, e
+			}
+
+			for _, ext := range strings.Fields(params["ext"]) {
+				exts = addID(exts, ext)
+			}
+
+			for _, profile := range strings.Fields(params["profile"]) {
+				profiles = addID(profiles, profile)
+			}
+		}
+	}
+
+	// Accept
+	accept := strings.Join(r.Header["Accept"], ",")
+	if strings.TrimSpace(accept) == "" {
+		return exts, profiles, nil
+	}
+
+	// Media types other than the JSON:API one are served
+	// with it anyway, unless all its instances are refused
+	// or have parameters that cannot be served.
+	rejected := false
+
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			// A quality value of 0 means "not acceptable".
+			if v, err := strconv.ParseFloat(q, 64); err != nil || v == 0 {
+				rejected = rejected || mt == MediaType
+				continue
+			}
+
+			delete(params, "q")
+		}
+
+		if mt == MediaType {
+			if checkMediaTypeParams(params) != "" {
+				rejected = true
+				continue
+			}
+
+			// The first acceptable instance of the JSON:API
+			// media type is the one used for the response.
+			for _, ext := range strings.Fields(params["ext"]) {
+				exts = addID(exts, ext)
+			}
+
+			for _, profile := range strings.Fields(params["profile"]) {
+				profiles = addID(profiles, profile)
+			}
+
+			return exts, profiles, nil
+		}
+	}
+
+	if rejected {
+		return nil, nil, NewErrNotAcceptable()
+	}
+
+	return exts, profiles, nil
+}
+
+// checkMediaTypeParams checks the parameters of an instance of the JSON:API
+// media type and returns the detail of the error if they are not allowed.
+func checkMediaTypeParams(params map[string]string) string {
+	for name := range params {
+		if name != "ext" && name != "profile" {
+			return "The media type parameter " + strconv.Quote(name) + " is not allowed."
+		}
+	}
+
+	for _, ext := range strings.Fields(params["ext"]) {
+		if !containsID(supportedExtensions, ext) {
+			return "The extension " + strconv.Quote(ext) + " is not supported."
+		}
+	}
+
+	return ""
 }
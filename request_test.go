@@ -93,3 +93,216 @@ type badReader struct {
 func (badReader) Read([]byte) (int, error) {
 	return 0, errors.New("bad reader")
 }
+
+func TestNewRequestBody(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+
+	// Partial resource
+	body := bytes.NewBufferString(`{
+		"data": {
+			"id": "mt3-1",
+			"type": "mocktypes3",
+			"attributes": {"attr2": 2}
+		}
+	}`)
+	req := httptest.NewRequest("PATCH", "/mocktypes3/mt3-1", body)
+
+	doc, err := NewRequest(req, schema)
+	assert.NoError(err)
+
+	res, ok := doc.Doc.Data.(*SoftResource)
+	assert.True(ok)
+	assert.Equal([]string{"attr2"}, res.Type.Fields())
+	assert.Equal(2, res.Get("attr2"))
+
+	// Identifier
+	body = bytes.NewBufferString(`{"data": {"id": "mt1-1", "type": "mocktypes1"}}`)
+	req = httptest.NewRequest("PATCH", "/mocktypes3/mt3-1/relationships/rel1", body)
+
+	doc, err = NewRequest(req, schema)
+	assert.NoError(err)
+	assert.Equal(Identifier{ID: "mt1-1", Type: "mocktypes1"}, doc.Doc.Data)
+
+	// Identifiers
+	body = bytes.NewBufferString(`{"data": [{"id": "mt1-1", "type": "mocktypes1"}]}`)
+	req = httptest.NewRequest("DELETE", "/mocktypes3/mt3-1/relationships/rel2", body)
+
+	doc, err = NewRequest(req, schema)
+	assert.NoError(err)
+	assert.Equal(NewIdentifiers("mocktypes1", []string{"mt1-1"}), doc.Doc.Data)
+
+	// Null
+	body = bytes.NewBufferString(`{"data": null}`)
+	req = httptest.NewRequest("PATCH", "/mocktypes3/mt3-1/relationships/rel1", body)
+
+	doc, err = NewRequest(req, schema)
+	assert.NoError(err)
+	assert.Nil(doc.Doc.Data)
+
+	// Unknown type
+	body = bytes.NewBufferString(`{"data": {"id": "abc", "type": "unknown"}}`)
+	req = httptest.NewRequest("PATCH", "/mocktypes3/mt3-1/relationships/rel1", body)
+
+	doc, err = NewRequest(req, schema)
+	assert.EqualError(err, `400 Bad Request: type "unknown" is unknown`)
+	assert.Nil(doc)
+
+	// Missing data
+	body = bytes.NewBufferString(`{}`)
+	req = httptest.NewRequest("PATCH", "/mocktypes3/mt3-1/relationships/rel1", body)
+
+	_, err = NewRequest(req, schema)
+	assert.EqualError(err, "400 Bad Request: Missing data top-level member in payload.")
+}
+
+func TestNewRequestContentNegotiation(t *testing.T) {
+	schema := newMockSchema()
+
+	profile := "https://example.com/profiles/timestamps"
+	atomic := MediaType + `; ext="` + AtomicExtension + `"`
+
+	tests := []struct {
+		name               string
+		method             string
+		body               string
+		contentType        string
+		accept             string
+		expectedExtensions []string
+		expectedProfiles   []string
+		expectedError      string
+	}{
+		{
+			name:   "no headers",
+			method: "GET",
+		}, {
+			name:   "accept media type",
+			method: "GET",
+			accept: MediaType,
+		}, {
+			name:   "accept wildcard",
+			method: "GET",
+			accept: "text/html, */*;q=0.8",
+		}, {
+			name:             "accept with profile",
+			method:           "GET",
+			accept:           MediaType + `; profile="` + profile + `"`,
+			expectedProfiles: []string{profile},
+		}, {
+			name:   "accept with other instances",
+			method: "GET",
+			accept: MediaType + "; charset=utf-8, " +
+				MediaType + `; ext="https://example.com/ext"; q=0.9, ` +
+				atomic + "; q=0.5",
+			expectedExtensions: []string{AtomicExtension},
+		}, {
+			name:   "accept without valid instance",
+			method: "GET",
+			accept: MediaType + "; charset=utf-8",
+			expectedError: "406 Not Acceptable: " +
+				"None of the media types of the Accept header can be served.",
+		}, {
+			name:   "accept other media type",
+			method: "GET",
+			accept: "application/json",
+		}, {
+			name:   "accept other media type and invalid instance",
+			method: "GET",
+			accept: "application/json, " + MediaType + "; charset=utf-8",
+			expectedError: "406 Not Acceptable: " +
+				"None of the media types of the Accept header can be served.",
+		}, {
+			name:   "accept wildcard and invalid instance",
+			method: "GET",
+			accept: "*/*, " + MediaType + "; charset=utf-8",
+			expectedError: "406 Not Acceptable: " +
+				"None of the media types of the Accept header can be served.",
+		}, {
+			name:   "accept media type with q=0",
+			method: "GET",
+			accept: MediaType + "; q=0",
+			expectedError: "406 Not Acceptable: " +
+				"None of the media types of the Accept header can be served.",
+		}, {
+			name:               "content type with profile",
+			method:             "POST",
+			body:               `{"data": {"type": "mocktypes1"}}`,
+			contentType:        MediaType + `; profile="` + profile + `"`,
+			accept:             atomic,
+			expectedExtensions: []string{AtomicExtension},
+			expectedProfiles:   []string{profile},
+		}, {
+			name:        "content type ignored without body",
+			method:      "GET",
+			contentType: "text/plain",
+		}, {
+			name:        "content type of other media type",
+			method:      "POST",
+			body:        `{"data": {"type": "mocktypes1"}}`,
+			contentType: "application/json",
+			expectedError: "415 Unsupported Media Type: " +
+				"The media type of the payload must be application/vnd.api+json.",
+		}, {
+			name:        "content type with charset",
+			method:      "POST",
+			body:        `{"data": {"type": "mocktypes1"}}`,
+			contentType: MediaType + "; charset=utf-8",
+			expectedError: "415 Unsupported Media Type: " +
+				`The media type parameter "charset" is not allowed.`,
+		}, {
+			name:        "content type with unsupported extension",
+			method:      "POST",
+			body:        `{"data": {"type": "mocktypes1"}}`,
+			contentType: MediaType + `; ext="https://example.com/ext"`,
+			expectedError: "415 Unsupported Media Type: " +
+				`The extension "https://example.com/ext" is not supported.`,
+		}, {
+			name:   "atomic operations",
+			method: "POST",
+			body: `{"atomic:operations": [
+				{"op": "remove", "ref": {"type": "mocktypes1", "id": "mt1-1"}}
+			]}`,
+			contentType:        atomic,
+			expectedExtensions: []string{AtomicExtension},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			body := bytes.NewBufferString(test.body)
+			r := httptest.NewRequest(test.method, "/mocktypes1", body)
+
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+
+			req, err := NewRequest(r, schema)
+
+			if test.expectedError != "" {
+				assert.EqualError(err, test.expectedError)
+				assert.Nil(req)
+
+				return
+			}
+
+			if test.expectedExtensions == nil {
+				test.expectedExtensions = []string{}
+			}
+
+			if test.expectedProfiles == nil {
+				test.expectedProfiles = []string{}
+			}
+
+			assert.NoError(err)
+			assert.Equal(test.expectedExtensions, req.Extensions)
+			assert.Equal(test.expectedProfiles, req.Profiles)
+		})
+	}
+}
@@ -21,13 +21,47 @@ type Resource interface {
 	Set(key string, val interface{})
 }
 
+// A LIDHolder is a resource that can hold local IDs.
+//
+// A local ID identifies a resource created by the same request before the
+// server assigns it an ID. LID returns the local ID of the resource itself and
+// RelLIDs returns the local IDs referenced by a relationship, in addition to
+// the IDs returned by Get.
+type LIDHolder interface {
+	LID() string
+	SetLID(lid string)
+	RelLIDs(key string) []string
+	SetRelLIDs(key string, lids []string)
+}
+
 // MarshalResource marshals a Resource into a JSON-encoded payload.
+//
+// nil is returned if the resource cannot be marshaled, like when a float
+// attribute is NaN or infinite. MarshalDocument returns an error instead.
 func MarshalResource(r Resource, prepath string, fields []string, relData map[string][]string) []byte {
+	pl, _ := marshalResource(r, prepath, fields, relData)
+
+	return pl
+}
+
+// marshalResource marshals r like MarshalResource does, but returns the error.
+func marshalResource(
+	r Resource, prepath string, fields []string, relData map[string][]string,
+) ([]byte, error) {
 	mapPl := map[string]interface{}{}
 
 	mapPl["id"] = r.Get("id").(string)
 	mapPl["type"] = r.GetType().Name
 
+	lh, _ := r.(LIDHolder)
+	if lh != nil && lh.LID() != "" {
+		mapPl["lid"] = lh.LID()
+
+		if mapPl["id"] == "" {
+			delete(mapPl, "id")
+		}
+	}
+
 	// Attributes
 	attrs := map[string]interface{}{}
 
@@ -73,6 +107,11 @@ func MarshalResource(r Resource, prepath string, fields []string, relData map[st
 								"id":   r.Get(rel.FromName).(string),
 								"type": rel.ToType,
 							}
+						} else if lids := relLIDs(lh, rel.FromName); len(lids) > 0 {
+							s["data"] = map[string]string{
+								"lid":  lids[0],
+								"type": rel.ToType,
+							}
 						} else {
 							s["data"] = nil
 						}
@@ -99,6 +138,12 @@ func MarshalResource(r Resource, prepath string, fields []string, relData map[st
 								"type": rel.ToType,
 							})
 						}
+						for _, lid := range relLIDs(lh, rel.FromName) {
+							data = append(data, map[string]string{
+								"lid":  lid,
+								"type": rel.ToType,
+							})
+						}
 						s["data"] = data
 						break
 					}
@@ -126,13 +171,13 @@ func MarshalResource(r Resource, prepath string, fields []string, relData map[st
 		}
 	}
 
-	// NOTE An error should not happen.
-	pl, _ := json.Marshal(mapPl)
-
-	return pl
+	return json.Marshal(mapPl)
 }
 
 // UnmarshalResource unmarshals a JSON-encoded payload into a Resource.
+//
+// The attributes are checked against their validation rules (see AttrRules) and
+// the first error found is returned.
 func UnmarshalResource(data []byte, schema *Schema) (Resource, error) {
 	var rske resourceSkeleton
 	err := json.Unmarshal(data, &rske)
@@ -149,6 +194,10 @@ func UnmarshalResource(data []byte, schema *Schema) (Resource, error) {
 
 	res.Set("id", rske.ID)
 
+	if lh, ok := res.(LIDHolder); ok {
+		lh.SetLID(rske.LID)
+	}
+
 	for a, v := range rske.Attributes {
 		if attr, ok := typ.Attrs[a]; ok {
 			val, err := attr.UnmarshalToType(v)
@@ -165,19 +214,7 @@ func UnmarshalResource(data []byte, schema *Schema) (Resource, error) {
 	for r, v := range rske.Relationships {
 		if rel, ok := typ.Rels[r]; ok {
 			if len(v.Data) > 0 {
-				if rel.ToOne {
-					var iden Identifier
-					err = json.Unmarshal(v.Data, &iden)
-					res.Set(rel.FromName, iden.ID)
-				} else {
-					var idens Identifiers
-					err = json.Unmarshal(v.Data, &idens)
-					ids := make([]string, len(idens))
-					for i := range idens {
-						ids[i] = idens[i].ID
-					}
-					res.Set(rel.FromName, ids)
-				}
+				err = unmarshalRelData(res, rel, v.Data)
 			}
 
 			if err != nil {
@@ -192,6 +229,11 @@ func UnmarshalResource(data []byte, schema *Schema) (Resource, error) {
 		}
 	}
 
+	// Validation
+	if errs := typ.Validate(res); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
 	// Meta
 	if m, ok := res.(MetaHolder); ok {
 		m.SetMeta(rske.Meta)
@@ -211,6 +253,9 @@ func UnmarshalResource(data []byte, schema *Schema) (Resource, error) {
 // set to a value. UnmarshalResource returns a Resource where the missing fields
 // are added and set to their zero value, but UnmarshalPartialResource does not
 // do that. Therefore, the user is able to tell which fields have been set.
+//
+// Only the attributes found in the payload are checked against their validation
+// rules.
 func UnmarshalPartialResource(data []byte, schema *Schema) (*SoftResource, error) {
 	var rske resourceSkeleton
 	err := json.Unmarshal(data, &rske)
@@ -229,6 +274,7 @@ func UnmarshalPartialResource(data []byte, schema *Schema) (*SoftResource, error
 	res := &SoftResource{
 		Type: &newType,
 		id:   rske.ID,
+		lid:  rske.LID,
 	}
 
 	for a, v := range rske.Attributes {
@@ -248,21 +294,8 @@ func UnmarshalPartialResource(data []byte, schema *Schema) (*SoftResource, error
 	for r, v := range rske.Relationships {
 		if rel, ok := typ.Rels[r]; ok {
 			if len(v.Data) > 0 {
-				if rel.ToOne {
-					var iden Identifier
-					err = json.Unmarshal(v.Data, &iden)
-					_ = newType.AddRel(rel)
-					res.Set(rel.FromName, iden.ID)
-				} else {
-					var idens Identifiers
-					err = json.Unmarshal(v.Data, &idens)
-					ids := make([]string, len(idens))
-					for i := range idens {
-						ids[i] = idens[i].ID
-					}
-					_ = newType.AddRel(rel)
-					res.Set(rel.FromName, ids)
-				}
+				_ = newType.AddRel(rel)
+				err = unmarshalRelData(res, rel, v.Data)
 			}
 
 			if err != nil {
@@ -277,6 +310,11 @@ func UnmarshalPartialResource(data []byte, schema *Schema) (*SoftResource, error
 		}
 	}
 
+	// Validation
+	if errs := typ.Validate(res); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
 	return res, nil
 }
 
@@ -321,7 +359,15 @@ func Equal(r1, r2 Resource) bool {
 
 	for i, attr1 := range r1Attrs {
 		attr2 := r2Attrs[i]
-		if !reflect.DeepEqual(r1.Get(attr1.Name), r2.Get(attr2.Name)) {
+		v1, v2 := r1.Get(attr1.Name), r2.Get(attr2.Name)
+		if !reflect.DeepEqual(v1, v2) {
+			// Arrays, objects, and JSON values can be held in different
+			// forms (like a struct and a map), so they are compared
+			// through their JSON representations.
+			if !isScalarAttrType(attr1.Type) && equalJSON(v1, v2) {
+				continue
+			}
+
 			// TODO Fix the following condition one day. Basically, all
 			// nils (nil pointer, nil slice, etc) should be considered
 			// equal to a nil empty interface.
@@ -385,6 +431,17 @@ func Equal(r1, r2 Resource) bool {
 	return true
 }
 
+// equalJSON reports whether v1 and v2 have the same JSON representation.
+func equalJSON(v1, v2 interface{}) bool {
+	var n1, n2 interface{}
+
+	if convertValue(v1, &n1) != nil || convertValue(v2, &n2) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(n1, n2)
+}
+
 // EqualStrict is like Equal, but it also considers IDs.
 func EqualStrict(r1, r2 Resource) bool {
 	if r1.Get("id").(string) != r2.Get("id").(string) {
@@ -393,3 +450,214 @@ func EqualStrict(r1, r2 Resource) bool {
 
 	return Equal(r1, r2)
 }
+
+// unmarshalRelData sets the relationship rel of res from data, the content of
+// the data member of a relationship object.
+//
+// Identifiers that have a local ID instead of an ID are kept in res if it is a
+// LIDHolder.
+func unmarshalRelData(res Resource, rel Rel, data json.RawMessage) error {
+	var idens Identifiers
+
+	if rel.ToOne {
+		var iden Identifier
+
+		err := json.Unmarshal(data, &iden)
+		if err != nil {
+			return err
+		}
+
+		res.Set(rel.FromName, iden.ID)
+		idens = Identifiers{iden}
+	} else {
+		err := json.Unmarshal(data, &idens)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(idens))
+
+		for i := range idens {
+			if idens[i].ID != "" || idens[i].LID == "" {
+				ids = append(ids, idens[i].ID)
+			}
+		}
+
+		res.Set(rel.FromName, ids)
+	}
+
+	if lh, ok := res.(LIDHolder); ok {
+		lids := []string{}
+
+		for _, iden := range idens {
+			if iden.ID == "" && iden.LID != "" {
+				lids = append(lids, iden.LID)
+			}
+		}
+
+		lh.SetRelLIDs(rel.FromName, lids)
+
+		if kh, ok := res.(linkageHolder); ok && !rel.ToOne {
+			if len(lids) == 0 || len(lids) == len(idens) {
+				// The order cannot be lost.
+				idens = nil
+			}
+
+			kh.setRelLinkage(rel.FromName, idens)
+		}
+	}
+
+	return nil
+}
+
+// resolveRelLIDs replaces the local IDs referenced by the relationships of res
+// by the IDs found in ids, which maps local IDs to IDs.
+//
+// An error is returned if a local ID cannot be resolved. The local IDs that
+// can be resolved are still replaced.
+func resolveRelLIDs(res Resource, ids map[string]string) error {
+	lh, ok := res.(LIDHolder)
+	if !ok {
+		return nil
+	}
+
+	var err error
+
+	for _, rel := range res.Rels() {
+		if len(lh.RelLIDs(rel.FromName)) == 0 {
+			continue
+		}
+
+		rids := []string{}
+		unresolved := []string{}
+		linkage := Identifiers{}
+
+		for _, iden := range relIdentifiers(res, rel) {
+			if iden.ID == "" && iden.LID != "" {
+				id, ok := ids[iden.LID]
+				if !ok {
+					unresolved = append(unresolved, iden.LID)
+					linkage = append(linkage, iden)
+
+					if err == nil {
+						err = newErrUnknownLID(iden.LID)
+					}
+
+					continue
+				}
+
+				iden = Identifier{ID: id, Type: iden.Type}
+			}
+
+			rids = append(rids, iden.ID)
+			linkage = append(linkage, iden)
+		}
+
+		if rel.ToOne {
+			if len(rids) > 0 {
+				res.Set(rel.FromName, rids[len(rids)-1])
+			}
+		} else {
+			res.Set(rel.FromName, rids)
+		}
+
+		lh.SetRelLIDs(rel.FromName, unresolved)
+
+		if kh, ok := res.(linkageHolder); ok && !rel.ToOne {
+			if len(unresolved) == 0 || len(unresolved) == len(linkage) {
+				linkage = nil
+			}
+
+			kh.setRelLinkage(rel.FromName, linkage)
+		}
+	}
+
+	return err
+}
+
+// relLIDs returns the local IDs referenced by the relationship named after key,
+// or nil if lh is nil.
+func relLIDs(lh LIDHolder, key string) []string {
+	if lh == nil {
+		return nil
+	}
+
+	return lh.RelLIDs(key)
+}
+
+// A linkageHolder is a LIDHolder that remembers the order of the identifiers of
+// its to-many relationships, which is lost when they mix IDs and local IDs.
+type linkageHolder interface {
+	relLinkage(key string) Identifiers
+	setRelLinkage(key string, idens Identifiers)
+}
+
+// relIdentifiers returns the identifiers referenced by the relationship rel of
+// res, through IDs and local IDs.
+//
+// The IDs come first, unless res is a linkageHolder that remembers the order of
+// a relationship that has not changed since.
+func relIdentifiers(res Resource, rel Rel) Identifiers {
+	var ids []string
+
+	if rel.ToOne {
+		if id := res.Get(rel.FromName).(string); id != "" {
+			ids = []string{id}
+		}
+	} else {
+		ids = res.Get(rel.FromName).([]string)
+	}
+
+	lh, _ := res.(LIDHolder)
+	lids := relLIDs(lh, rel.FromName)
+
+	idens := make(Identifiers, 0, len(ids)+len(lids))
+
+	if kh, ok := res.(linkageHolder); ok && !rel.ToOne {
+		linkage := kh.relLinkage(rel.FromName)
+
+		var lids2, ids2 []string
+
+		for _, iden := range linkage {
+			if iden.ID == "" && iden.LID != "" {
+				lids2 = append(lids2, iden.LID)
+			} else {
+				ids2 = append(ids2, iden.ID)
+			}
+		}
+
+		if len(linkage) > 0 && equalStrings(ids, ids2) && equalStrings(lids, lids2) {
+			for _, iden := range linkage {
+				idens = append(idens, Identifier{ID: iden.ID, LID: iden.LID, Type: rel.ToType})
+			}
+
+			return idens
+		}
+	}
+
+	for _, id := range ids {
+		idens = append(idens, Identifier{ID: id, Type: rel.ToType})
+	}
+
+	for _, lid := range lids {
+		idens = append(idens, Identifier{LID: lid, Type: rel.ToType})
+	}
+
+	return idens
+}
+
+// equalStrings reports whether s1 and s2 hold the same strings in the same
+// order.
+func equalStrings(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+
+	return true
+}
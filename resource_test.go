@@ -1,6 +1,7 @@
 package jsonapi_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -155,6 +156,67 @@ func TestUnmarshalPartialResource(t *testing.T) {
 	})
 }
 
+func TestUnmarshalResourceLIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	typ, _ := BuildType(mocktype{})
+	typ.NewFunc = func() Resource {
+		return Wrap(&mocktype{})
+	}
+	schema := &Schema{Types: []Type{typ}}
+
+	payload := `{
+		"lid": "a",
+		"type": "mocktype",
+		"relationships": {
+			"to-1": {
+				"data": {"type": "mocktype", "lid": "b"}
+			},
+			"to-x": {
+				"data": [
+					{"type": "mocktype", "id": "c"},
+					{"type": "mocktype", "lid": "d"}
+				]
+			}
+		}
+	}`
+
+	// Full resource
+	res, err := UnmarshalResource([]byte(payload), schema)
+	assert.NoError(err)
+
+	lh := res.(LIDHolder)
+	assert.Equal("", res.Get("id"))
+	assert.Equal("a", lh.LID())
+	assert.Equal("", res.Get("to-1"))
+	assert.Equal([]string{"b"}, lh.RelLIDs("to-1"))
+	assert.Equal([]string{"c"}, res.Get("to-x"))
+	assert.Equal([]string{"d"}, lh.RelLIDs("to-x"))
+	assert.Nil(lh.RelLIDs("to-x-from-x"))
+
+	// Partial resource
+	pres, err := UnmarshalPartialResource([]byte(payload), schema)
+	assert.NoError(err)
+	assert.Equal("a", pres.LID())
+	assert.Equal([]string{"b"}, pres.RelLIDs("to-1"))
+	assert.Equal([]string{"d"}, pres.RelLIDs("to-x"))
+
+	// Marshaling
+	raw := MarshalResource(res, "", []string{"to-1", "to-x"}, map[string][]string{
+		"mocktype": {"to-1", "to-x"},
+	})
+
+	ske := map[string]json.RawMessage{}
+	_ = json.Unmarshal(raw, &ske)
+	assert.NotContains(ske, "id")
+	assert.Equal(`"a"`, string(ske["lid"]))
+	assert.Contains(string(ske["relationships"]), `"data":{"lid":"b","type":"mocktype"}`)
+	assert.Contains(
+		string(ske["relationships"]),
+		`"data":[{"id":"c","type":"mocktype"},{"lid":"d","type":"mocktype"}]`,
+	)
+}
+
 func TestEqual(t *testing.T) {
 	assert := assert.New(t)
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // A Schema contains a list of types. It makes sure that all types are valid and
@@ -27,6 +28,9 @@ type Schema struct {
 	// duplication (the information is already accessible through the
 	// inverse relationship).
 	rels map[string]Rel
+
+	// filterLabels maps type names to their filter labels.
+	filterLabels map[string]map[string]FilterTemplate
 }
 
 // AddType adds a type to the schema.
@@ -55,6 +59,8 @@ func (s *Schema) RemoveType(typ string) {
 			s.Types = append(s.Types[0:i], s.Types[i+1:]...)
 		}
 	}
+
+	delete(s.filterLabels, typ)
 }
 
 // AddAttr adds an attribute to the specified type.
@@ -181,6 +187,51 @@ func (s *Schema) GetType(name string) Type {
 	return Type{}
 }
 
+// ResolvePath returns the attribute or the relationship found at the end of
+// path, a field path that starts from the type named typ.
+//
+// The names of a path are separated by dots, like author.address.city. Each name
+// except the last one is a relationship, which leads to the type pointed by its
+// ToType field, or an object attribute, which leads to its fields. Exactly one
+// of the returned Attr and Rel is set when the error is nil.
+func (s *Schema) ResolvePath(typ, path string) (Attr, Rel, error) {
+	t := s.GetType(typ)
+	if t.Name == "" {
+		return Attr{}, Rel{}, fmt.Errorf("jsonapi: type %q does not exist", typ)
+	}
+
+	names := strings.Split(path, ".")
+	attrs, rels := t.Attrs, t.Rels
+
+	for i, name := range names {
+		last := i == len(names)-1
+
+		if attr, ok := attrs[name]; ok {
+			if last {
+				return attr, Rel{}, nil
+			}
+
+			if attr.Type != AttrTypeObject {
+				break
+			}
+
+			// Objects do not have relationships.
+			attrs, rels = attr.Fields, nil
+		} else if rel, ok := rels[name]; ok {
+			if last {
+				return Attr{}, rel, nil
+			}
+
+			next := s.GetType(rel.ToType)
+			attrs, rels = next.Attrs, next.Rels
+		} else {
+			break
+		}
+	}
+
+	return Attr{}, Rel{}, fmt.Errorf("jsonapi: path %q of type %q is invalid", path, typ)
+}
+
 // Check checks the integrity of all the relationships between the types and
 // returns all the errors that were found.
 func (s *Schema) Check() []error {
@@ -274,3 +274,54 @@ func TestSchemaRels(t *testing.T) {
 	assert.Equal(messages.Rels["author"], rels[0])
 	assert.Equal(users.Rels["favorites"], rels[1])
 }
+
+func TestSchemaResolvePath(t *testing.T) {
+	assert := assert.New(t)
+
+	city := Attr{Name: "city", Type: AttrTypeString}
+	address := Attr{
+		Name:   "address",
+		Type:   AttrTypeObject,
+		Fields: map[string]Attr{"city": city},
+	}
+	author := Rel{FromName: "author", FromType: "messages", ToOne: true, ToType: "users"}
+	posts := Rel{FromName: "posts", FromType: "users", ToType: "messages"}
+
+	schema := &Schema{}
+	_ = schema.AddType(Type{
+		Name:  "users",
+		Attrs: map[string]Attr{"address": address},
+		Rels:  map[string]Rel{"posts": posts},
+	})
+	_ = schema.AddType(Type{
+		Name: "messages",
+		Rels: map[string]Rel{"author": author},
+	})
+
+	tests := []struct {
+		typ, path    string
+		expectedAttr Attr
+		expectedRel  Rel
+	}{
+		{typ: "users", path: "address", expectedAttr: address},
+		{typ: "users", path: "address.city", expectedAttr: city},
+		{typ: "users", path: "posts", expectedRel: posts},
+		{typ: "messages", path: "author.address.city", expectedAttr: city},
+		{typ: "messages", path: "author.posts.author", expectedRel: author},
+	}
+
+	for _, test := range tests {
+		attr, rel, err := schema.ResolvePath(test.typ, test.path)
+		assert.NoError(err, test.path)
+		assert.Equal(test.expectedAttr, attr, test.path)
+		assert.Equal(test.expectedRel, rel, test.path)
+	}
+
+	for _, path := range []string{"", "author.", "author.address.city.x", "author.unknown"} {
+		_, _, err := schema.ResolvePath("messages", path)
+		assert.Error(err, path)
+	}
+
+	_, _, err := schema.ResolvePath("unknown", "author")
+	assert.EqualError(err, `jsonapi: type "unknown" does not exist`)
+}
@@ -26,6 +26,15 @@ type SimpleURL struct {
 	PageSize     uint
 	PageNumber   uint
 	Include      []string
+
+	// Cursor pagination
+	//
+	// CursorPagination is true if page[cursor], page[after], or
+	// page[before] is present, even if empty. page[cursor] is an
+	// alias of page[after].
+	CursorPagination bool
+	PageAfter        string
+	PageBefore       string
 }
 
 // NewSimpleURL takes and parses a *url.URL and returns a SimpleURL.
@@ -56,6 +65,9 @@ func NewSimpleURL(u *url.URL) (SimpleURL, error) {
 			if len(values.Get(name)) > 0 {
 				sURL.Fields[resType] = parseCommaList(values.Get(name))
 			}
+		} else if strings.HasPrefix(name, "filter[") {
+			// Filters in the bracket syntax are parsed below
+			continue
 		} else {
 			switch name {
 			case "filter":
@@ -96,6 +108,13 @@ func NewSimpleURL(u *url.URL) (SimpleURL, error) {
 				}
 
 				sURL.PageNumber = uint(num)
+			case "page[cursor]", "page[after]":
+				// Page cursors
+				sURL.CursorPagination = true
+				sURL.PageAfter = values.Get(name)
+			case "page[before]":
+				sURL.CursorPagination = true
+				sURL.PageBefore = values.Get(name)
 			case "include":
 				// Include
 				for _, include := range values[name] {
@@ -108,6 +127,26 @@ func NewSimpleURL(u *url.URL) (SimpleURL, error) {
 		}
 	}
 
+	filter, err := parseFilterParams(values)
+	if err != nil {
+		return sURL, err
+	}
+
+	if filter != nil {
+		if sURL.Filter != nil || sURL.FilterLabel != "" {
+			return sURL, NewErrMalformedFilterParameter(values.Get("filter"))
+		}
+
+		sURL.Filter = filter
+	}
+
+	if sURL.CursorPagination && sURL.PageNumber != 0 {
+		return sURL, NewErrBadRequest(
+			"Invalid pagination",
+			"Page numbers and cursors cannot be used at the same time.",
+		)
+	}
+
 	return sURL, nil
 }
 
@@ -161,6 +161,78 @@ func TestSimpleURL(t *testing.T) {
 				Include:      []string{},
 			},
 			expectedError: nil,
+		}, {
+			name: "filter in bracket syntax",
+			url: `
+				http://api.example.com/type
+				?filter[name]=abc
+				&filter[age][gt]=30
+				&filter[tags][in]=a,b
+				&filter[name][like][ci]=a%25
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields: map[string][]string{},
+				Filter: &Filter{Op: "and", Val: []*Filter{
+					{Field: "age", Op: ">", Val: "30"},
+					{Field: "name", Op: "=", Val: "abc"},
+					{Field: "name", Op: "like", Val: "a%", Col: "ci"},
+					{Field: "tags", Op: "in", Val: []string{"a", "b"}},
+				}},
+				SortingRules: []string{},
+				Include:      []string{},
+			},
+			expectedError: nil,
+		}, {
+			name: "single filter in bracket syntax",
+			url: `
+				http://api.example.com/type
+				?filter[author.name][starts-with]=abc
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:       map[string][]string{},
+				Filter:       &Filter{Field: "author.name", Op: "starts-with", Val: "abc"},
+				SortingRules: []string{},
+				Include:      []string{},
+			},
+			expectedError: nil,
+		}, {
+			name: "malformed filter in bracket syntax",
+			url: `
+				http://api.example.com/type
+				?filter[name][]=abc
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:       map[string][]string{},
+				SortingRules: []string{},
+				Include:      []string{},
+			},
+			expectedError: NewErrMalformedFilterParameter("filter[name][]"),
+		}, {
+			name: "filter in bracket syntax and label",
+			url: `
+				http://api.example.com/type
+				?filter=label
+				&filter[name]=abc
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:       map[string][]string{},
+				FilterLabel:  "label",
+				SortingRules: []string{},
+				Include:      []string{},
+			},
+			expectedError: NewErrMalformedFilterParameter("label"),
 		}, {
 			name: "negative page size",
 			url: `
@@ -197,6 +269,72 @@ func TestSimpleURL(t *testing.T) {
 				Include:      []string{},
 			},
 			expectedError: NewErrInvalidPageNumberParameter("-1"),
+		}, {
+			name: "page cursors",
+			url: `
+				http://api.example.com/type
+				?page[after]=abc
+				&page[before]=def
+				&page[size]=10
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:           map[string][]string{},
+				Filter:           nil,
+				SortingRules:     []string{},
+				PageSize:         10,
+				PageNumber:       0,
+				Include:          []string{},
+				CursorPagination: true,
+				PageAfter:        "abc",
+				PageBefore:       "def",
+			},
+			expectedError: nil,
+		}, {
+			name: "empty page cursor",
+			url: `
+				http://api.example.com/type
+				?page[cursor]=
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:           map[string][]string{},
+				Filter:           nil,
+				SortingRules:     []string{},
+				PageSize:         0,
+				PageNumber:       0,
+				Include:          []string{},
+				CursorPagination: true,
+			},
+			expectedError: nil,
+		}, {
+			name: "page cursor and page number",
+			url: `
+				http://api.example.com/type
+				?page[cursor]=abc
+				&page[number]=2
+			`,
+			expectedURL: SimpleURL{
+				Fragments: []string{"type"},
+				Route:     "/type",
+
+				Fields:           map[string][]string{},
+				Filter:           nil,
+				SortingRules:     []string{},
+				PageSize:         0,
+				PageNumber:       2,
+				Include:          []string{},
+				CursorPagination: true,
+				PageAfter:        "abc",
+			},
+			expectedError: NewErrBadRequest(
+				"Invalid pagination",
+				"Page numbers and cursors cannot be used at the same time.",
+			),
 		}, {
 			name: "unknown parameter",
 			url: `
@@ -3,14 +3,17 @@ package jsonapi
 import "encoding/json"
 
 type payloadSkeleton struct {
-	Data     json.RawMessage   `json:"data"`
-	Errors   []Error           `json:"errors"`
-	Included []json.RawMessage `json:"included"`
-	Meta     Meta              `json:"meta"`
+	Data       json.RawMessage     `json:"data"`
+	Errors     []Error             `json:"errors"`
+	Included   []json.RawMessage   `json:"included"`
+	Meta       Meta                `json:"meta"`
+	Operations []operationSkeleton `json:"atomic:operations"`
+	Results    []resultSkeleton    `json:"atomic:results"`
 }
 
 type resourceSkeleton struct {
 	ID            string                          `json:"id"`
+	LID           string                          `json:"lid"`
 	Type          string                          `json:"type"`
 	Attributes    map[string]json.RawMessage      `json:"attributes"`
 	Relationships map[string]relationshipSkeleton `json:"relationships"`
@@ -22,3 +25,16 @@ type relationshipSkeleton struct {
 	Links map[string]json.RawMessage `json:"links"`
 	Meta  map[string]json.RawMessage `json:"meta"`
 }
+
+type operationSkeleton struct {
+	Op   string          `json:"op"`
+	Ref  *OperationRef   `json:"ref"`
+	Href string          `json:"href"`
+	Data json.RawMessage `json:"data"`
+	Meta Meta            `json:"meta"`
+}
+
+type resultSkeleton struct {
+	Data json.RawMessage `json:"data"`
+	Meta Meta            `json:"meta"`
+}
@@ -1,11 +1,38 @@
 package jsonapi
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // SoftCollection is a collection of SoftResources where the type can be changed
 // for all elements at once by modifying the Type field.
+//
+// The resources are indexed by ID, so Resource and Remove do not scan the
+// collection. Secondary indexes can also be added on attributes (see
+// AddIndex). They are used by Range to answer filters and sorting rules on
+// those attributes without scanning and sorting the whole collection.
+//
+// The indexes are kept up to date when the resources of the collection are
+// modified through their methods.
 type SoftCollection struct {
 	Type *Type
 
-	col []*SoftResource
+	// col holds the resources. The removed ones are left as nil
+	// until the collection is compacted, so that the positions of
+	// the others do not change.
+	col     []*SoftResource
+	removed int
+
+	// ids maps the IDs to the positions of the resources. When many
+	// resources have the same ID, the first one is indexed and dups
+	// is not 0.
+	ids  map[string]int
+	dups int
+
+	// indexes maps attribute names to their index.
+	indexes map[string]*softIndex
 }
 
 // SetType sets the collection's type.
@@ -28,17 +55,96 @@ func (s *SoftCollection) AddRel(rel Rel) error {
 	return s.Type.AddRel(rel)
 }
 
+// AddIndex adds a secondary index on the attribute named attr. Nothing happens
+// if the index already exists.
+//
+// Only attributes of scalar types can be indexed.
+func (s *SoftCollection) AddIndex(attr string) error {
+	if _, ok := s.indexes[attr]; ok {
+		return nil
+	}
+
+	if s.Type == nil {
+		return fmt.Errorf("jsonapi: collection has no type")
+	}
+
+	a, ok := s.Type.Attrs[attr]
+	if !ok {
+		return fmt.Errorf("jsonapi: attribute %q of type %q does not exist", attr, s.Type.Name)
+	}
+
+	if !isScalarAttrType(a.Type) {
+		return fmt.Errorf("jsonapi: attribute %q of type %q cannot be indexed", attr, s.Type.Name)
+	}
+
+	s.compact()
+
+	idx := &softIndex{
+		attr:  attr,
+		rules: []string{attr, "id"},
+		res:   make([]*SoftResource, len(s.col)),
+	}
+
+	copy(idx.res, s.col)
+	sort.Slice(idx.res, func(i, j int) bool {
+		return lessResources(idx.res[i], idx.res[j], idx.rules, CollationBinary, nil)
+	})
+
+	if s.indexes == nil {
+		s.indexes = map[string]*softIndex{}
+	}
+
+	s.indexes[attr] = idx
+
+	return nil
+}
+
+// RemoveIndex removes the secondary index on the attribute named attr.
+func (s *SoftCollection) RemoveIndex(attr string) {
+	delete(s.indexes, attr)
+}
+
+// Indexes returns the sorted names of the attributes that have a secondary
+// index.
+func (s *SoftCollection) Indexes() []string {
+	attrs := make([]string, 0, len(s.indexes))
+	for attr := range s.indexes {
+		attrs = append(attrs, attr)
+	}
+
+	sort.Strings(attrs)
+
+	return attrs
+}
+
 // Len returns the length of the collection.
 func (s *SoftCollection) Len() int {
-	return len(s.col)
+	return len(s.col) - s.removed
 }
 
 // At returns the element at index i.
 func (s *SoftCollection) At(i int) Resource {
-	if i >= 0 && i < len(s.col) {
+	if i < 0 || i >= s.Len() {
+		return nil
+	}
+
+	if s.removed == 0 {
 		return s.col[i]
 	}
 
+	// The removed resources are skipped.
+	for _, sr := range s.col {
+		if sr == nil {
+			continue
+		}
+
+		if i == 0 {
+			return sr
+		}
+
+		i--
+	}
+
 	return nil
 }
 
@@ -46,16 +152,17 @@ func (s *SoftCollection) At(i int) Resource {
 //
 // It builds and returns a SoftResource with only the specified fields.
 func (s *SoftCollection) Resource(id string, fields []string) Resource {
-	for i := range s.col {
-		if s.col[i].GetID() == id {
-			return s.col[i]
-		}
+	if i, ok := s.ids[id]; ok {
+		return s.col[i]
 	}
 
 	return nil
 }
 
 // Add creates a SoftResource and adds it to the collection.
+//
+// The values of arrays, objects and to-many relationships are copied, so the
+// collection does not share them with r.
 func (s *SoftCollection) Add(r Resource) {
 	// A SoftResource is built from the Resource and
 	// then it is added to the collection.
@@ -65,7 +172,7 @@ func (s *SoftCollection) Add(r Resource) {
 
 	for _, attr := range r.Attrs() {
 		sr.AddAttr(attr)
-		sr.Set(attr.Name, r.Get(attr.Name))
+		sr.Set(attr.Name, copyValue(r.Get(attr.Name)))
 	}
 
 	for _, rel := range r.Rels() {
@@ -74,21 +181,409 @@ func (s *SoftCollection) Add(r Resource) {
 		if rel.ToOne {
 			sr.Set(rel.FromName, r.Get(rel.FromName).(string))
 		} else {
-			sr.Set(rel.FromName, r.Get(rel.FromName).([]string))
+			sr.Set(rel.FromName, relIDs(r, rel.FromName))
 		}
 	}
 
 	s.col = append(s.col, sr)
+	sr.col = s
+
+	if s.ids == nil {
+		s.ids = map[string]int{}
+	}
+
+	s.indexID(sr, len(s.col)-1)
+
+	for _, idx := range s.indexes {
+		idx.insert(sr)
+	}
 }
 
 // Remove removes the resource with an ID equal to id.
 //
 // Nothing happens if no resource has such an ID.
+//
+// The resource is only marked as removed, and the collection is compacted once
+// half of it has been removed. Until then, At has to skip the removed
+// resources. Removing a resource from a secondary index moves
+// the entries that follow it in that index.
 func (s *SoftCollection) Remove(id string) {
+	i, ok := s.ids[id]
+	if !ok {
+		return
+	}
+
+	sr := s.col[i]
+	sr.col = nil
+
+	for _, idx := range s.indexes {
+		idx.remove(sr)
+	}
+
+	s.col[i] = nil
+	s.removed++
+	delete(s.ids, id)
+
+	if s.dups > 0 {
+		s.indexNextID(id, i)
+	}
+
+	if s.removed > len(s.col)/2 {
+		s.compact()
+	}
+}
+
+// compact drops the resources marked as removed by Remove, so that At no longer
+// has to skip them.
+func (s *SoftCollection) compact() {
+	if s.removed == 0 {
+		return
+	}
+
+	col := make([]*SoftResource, 0, len(s.col)-s.removed)
+
+	for _, sr := range s.col {
+		if sr != nil {
+			col = append(col, sr)
+		}
+	}
+
+	s.col = col
+	s.removed = 0
+	s.ids = make(map[string]int, len(col))
+	s.dups = 0
+
+	for i, sr := range col {
+		s.indexID(sr, i)
+	}
+}
+
+// indexID adds the resource sr found at position i to the ID index.
+func (s *SoftCollection) indexID(sr *SoftResource, i int) {
+	if p, ok := s.ids[sr.id]; ok && p < i {
+		s.dups++
+	} else {
+		s.ids[sr.id] = i
+	}
+}
+
+// indexNextID indexes the first resource after position i whose ID is id, if
+// there is one.
+func (s *SoftCollection) indexNextID(id string, i int) {
+	for j := i + 1; j < len(s.col); j++ {
+		if s.col[j] != nil && s.col[j].id == id {
+			s.ids[id] = j
+			return
+		}
+	}
+}
+
+// track removes sr from the indexes affected by a change of its field named
+// key and returns a function that adds it back once the change is made.
+func (s *SoftCollection) track(sr *SoftResource, key string) func() {
+	var idxs []*softIndex
+
+	if key == "id" {
+		// The ID is part of the order of every index.
+		for _, idx := range s.indexes {
+			idxs = append(idxs, idx)
+		}
+	} else if idx, ok := s.indexes[key]; ok {
+		idxs = append(idxs, idx)
+	}
+
+	for _, idx := range idxs {
+		idx.remove(sr)
+	}
+
+	pos := -1
+
+	if key == "id" {
+		pos = s.position(sr)
+
+		if p, ok := s.ids[sr.id]; ok && p == pos {
+			delete(s.ids, sr.id)
+
+			if s.dups > 0 {
+				// Another resource may have the same ID.
+				s.indexNextID(sr.id, pos)
+			}
+		}
+	}
+
+	return func() {
+		if pos != -1 {
+			s.indexID(sr, pos)
+		}
+
+		for _, idx := range idxs {
+			idx.insert(sr)
+		}
+	}
+}
+
+// position returns the position of sr in the collection.
+func (s *SoftCollection) position(sr *SoftResource) int {
+	if i, ok := s.ids[sr.id]; ok && s.col[i] == sr {
+		return i
+	}
+
 	for i := range s.col {
-		if s.col[i].GetID() == id {
-			s.col = append(s.col[:i], s.col[i+1:]...)
+		if s.col[i] == sr {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// A softIndex is a secondary index of a SoftCollection. It holds the resources
+// sorted by the value of an attribute and then by ID.
+type softIndex struct {
+	attr  string
+	rules []string
+	res   []*SoftResource
+}
+
+// insert adds sr to the index.
+func (idx *softIndex) insert(sr *SoftResource) {
+	i := sort.Search(len(idx.res), func(i int) bool {
+		return lessResources(sr, idx.res[i], idx.rules, CollationBinary, nil)
+	})
+
+	idx.res = append(idx.res, nil)
+	copy(idx.res[i+1:], idx.res[i:])
+	idx.res[i] = sr
+}
+
+// remove removes sr from the index.
+func (idx *softIndex) remove(sr *SoftResource) {
+	i := sort.Search(len(idx.res), func(i int) bool {
+		return !lessResources(idx.res[i], sr, idx.rules, CollationBinary, nil)
+	})
+
+	// Resources with the same value and ID are next to each other.
+	for ; i < len(idx.res); i++ {
+		if idx.res[i] == sr {
+			idx.res = append(idx.res[:i], idx.res[i+1:]...)
+			return
+		}
+
+		if lessResources(sr, idx.res[i], idx.rules, CollationBinary, nil) {
 			return
 		}
 	}
 }
+
+// bounds returns the part of the index where the value of the attribute
+// satisfies op with val, a non-null value of the attribute's type.
+//
+// Null values are never part of the result.
+func (idx *softIndex) bounds(typ *Type, op string, val interface{}) []*SoftResource {
+	pivot := &SoftResource{Type: typ}
+	pivot.Set(idx.attr, val)
+
+	rules := idx.rules[:1]
+
+	// before returns the position of the first resource that does not
+	// come before the pivot, and after the position of the first one
+	// that comes after it.
+	before := sort.Search(len(idx.res), func(i int) bool {
+		return !lessResources(idx.res[i], pivot, rules, CollationBinary, nil)
+	})
+	after := sort.Search(len(idx.res), func(i int) bool {
+		return lessResources(pivot, idx.res[i], rules, CollationBinary, nil)
+	})
+	nulls := sort.Search(len(idx.res), func(i int) bool {
+		return !isNull(idx.res[i].Get(idx.attr))
+	})
+
+	lo, hi := nulls, len(idx.res)
+
+	switch op {
+	case "=":
+		lo, hi = before, after
+	case "<":
+		hi = before
+	case "<=":
+		hi = after
+	case ">":
+		lo = after
+	case ">=":
+		lo = before
+	}
+
+	if lo < nulls {
+		lo = nulls
+	}
+
+	if hi < lo {
+		hi = lo
+	}
+
+	return idx.res[lo:hi]
+}
+
+// sorted returns the resources of res, a part of the index, sorted by the
+// attribute in descending order if desc is true, and then by ID.
+func (idx *softIndex) sorted(res []*SoftResource, desc bool) Resources {
+	sorted := make(Resources, 0, len(res))
+
+	if !desc {
+		for _, sr := range res {
+			sorted = append(sorted, sr)
+		}
+
+		return sorted
+	}
+
+	// The runs of resources with the same value keep their order.
+	rules := idx.rules[:1]
+
+	for end := len(res); end > 0; {
+		start := end - 1
+		for start > 0 && !lessResources(res[start-1], res[start], rules, CollationBinary, nil) {
+			start--
+		}
+
+		for _, sr := range res[start:end] {
+			sorted = append(sorted, sr)
+		}
+
+		end = start
+	}
+
+	return sorted
+}
+
+// scan returns the resources that Range has to consider for opts, and whether
+// they are already sorted according to rules.
+//
+// The resources are taken from the ID index if opts.IDs is set, or from a
+// secondary index if the filter or the sorting rules allow it. The filter
+// must still be applied to the returned resources. false is returned if no
+// index can be used, in which case all the resources must be considered.
+func (s *SoftCollection) scan(opts RangeOptions, rules []string) (Resources, bool, bool) {
+	if len(opts.IDs) > 0 {
+		res := make(Resources, 0, len(opts.IDs))
+		ids := stringSet(opts.IDs)
+
+		if s.dups > 0 {
+			// All the resources with one of the IDs are kept.
+			for _, sr := range s.col {
+				if sr == nil {
+					continue
+				}
+
+				if _, ok := ids[sr.id]; ok {
+					res = append(res, sr)
+				}
+			}
+
+			return res, false, true
+		}
+
+		for id := range ids {
+			if i, ok := s.ids[id]; ok {
+				res = append(res, s.col[i])
+			}
+		}
+
+		return res, false, true
+	}
+
+	// An index can give the order if the resources are sorted by
+	// its attribute and then by ID.
+	var (
+		sortIdx  *softIndex
+		sortDesc bool
+	)
+
+	if len(rules) == 2 && rules[1] == "id" {
+		attr := rules[0]
+		if strings.HasPrefix(attr, "-") {
+			attr, sortDesc = attr[1:], true
+		}
+
+		sortIdx = s.indexes[attr]
+
+		if sortIdx != nil && opts.Collation != CollationBinary &&
+			s.Type.Attrs[attr].Type == AttrTypeString {
+			sortIdx = nil
+		}
+	}
+
+	if leaf, idx := s.indexedLeaf(opts, sortIdx); idx != nil {
+		res := idx.bounds(s.Type, leaf.Op, leaf.Val)
+		if idx == sortIdx {
+			return idx.sorted(res, sortDesc), true, true
+		}
+
+		return idx.sorted(res, false), false, true
+	}
+
+	if sortIdx != nil {
+		return sortIdx.sorted(sortIdx.res, sortDesc), true, true
+	}
+
+	return nil, false, false
+}
+
+// indexedLeaf returns a condition of the filter of opts that can be answered by
+// a secondary index, and that index. The condition must be the filter itself
+// or one of the conditions of a top level and. The index given by prefer is
+// chosen first if possible.
+func (s *SoftCollection) indexedLeaf(
+	opts RangeOptions, prefer *softIndex,
+) (*Filter, *softIndex) {
+	if opts.Filter == nil || len(s.indexes) == 0 {
+		return nil, nil
+	}
+
+	schema := opts.Schema
+	if schema == nil {
+		schema = &Schema{Types: []Type{*s.Type}}
+	}
+
+	cf, err := opts.Filter.Coerce(schema, s.Type.Name)
+	if err != nil {
+		return nil, nil
+	}
+
+	leaves := []*Filter{cf}
+	if cf.Op == "and" {
+		leaves = cf.Val.([]*Filter)
+	}
+
+	var (
+		leaf *Filter
+		idx  *softIndex
+	)
+
+	for _, l := range leaves {
+		switch l.Op {
+		case "=", "<", "<=", ">", ">=":
+		default:
+			continue
+		}
+
+		i, ok := s.indexes[l.Field]
+		if !ok || isNull(l.Val) {
+			continue
+		}
+
+		if l.Col != CollationBinary && s.Type.Attrs[l.Field].Type == AttrTypeString {
+			continue
+		}
+
+		if i == prefer {
+			return l, i
+		}
+
+		if idx == nil {
+			leaf, idx = l, i
+		}
+	}
+
+	return leaf, idx
+}
@@ -1,6 +1,7 @@
 package jsonapi_test
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/mfcochauxlaberge/jsonapi"
@@ -152,12 +153,14 @@ func TestSoftCollectionResource(t *testing.T) {
 	sc.Add(sr)
 
 	// Resource with all fields
-	assert.Equal(t, sr, sc.Resource("res1", nil))
+	// The resource of the collection knows its collection, which
+	// is not part of its copy.
+	assert.Equal(t, sr, sc.Resource("res1", nil).(*SoftResource).Copy())
 
 	// Resource with some fields
 	// TODO Fix this test. It seems like defining any set of
 	// fields will make the assert pass.
-	assert.Equal(t, sr, sc.Resource("res1", []string{"attr2", "rel1"}))
+	assert.Equal(t, sr, sc.Resource("res1", []string{"attr2", "rel1"}).(*SoftResource).Copy())
 
 	// Resource not found
 	assert.Equal(t, nil, sc.Resource("notfound", nil))
@@ -169,3 +172,159 @@ func TestSoftCollectionMiscellaneous(t *testing.T) {
 	sc := &SoftCollection{}
 	assert.Nil(sc.At(99), "nonexistent element")
 }
+
+func TestSoftCollectionRemove(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ := schema.GetType("mocktypes2")
+
+	sc := &SoftCollection{}
+	sc.SetType(&typ)
+
+	for _, id := range []string{"id1", "id2", "id3", "id2", "id4", "id5", "id6", "id7"} {
+		res := &SoftResource{Type: &typ}
+		res.SetID(id)
+		res.Set("intptr", ptr(len(id)))
+		sc.Add(res)
+	}
+
+	// Removals without accessing the resources by position.
+	sc.Remove("id2")
+	sc.Remove("id3")
+	sc.Remove("id9")
+
+	assert.Equal(6, sc.Len())
+	assert.Nil(sc.Resource("id3", nil))
+	assert.Equal("id2", sc.Resource("id2", nil).Get("id"))
+	assert.Equal("id4", sc.Resource("id4", nil).Get("id"))
+
+	sc.Resource("id4", nil).(*SoftResource).SetID("id40")
+	sc.Add(sc.Resource("id1", nil))
+
+	assert.Nil(sc.Resource("id4", nil))
+	assert.Equal("id40", sc.Resource("id40", nil).Get("id"))
+	assert.Equal([]string{"id1", "id2", "id40", "id5", "id6", "id7", "id1"}, ids(sc))
+
+	// Enough removals to compact the collection.
+	sc.Remove("id1")
+	sc.Remove("id5")
+	sc.Remove("id6")
+	sc.Remove("id1")
+	sc.Remove("id7")
+
+	assert.Equal(2, sc.Len())
+	assert.Equal("id40", sc.Resource("id40", nil).Get("id"))
+	assert.Equal([]string{"id2", "id40"}, ids(sc))
+
+	assert.NoError(sc.AddIndex("intptr"))
+	sc.Resource("id40", nil).Set("intptr", ptr(9))
+
+	result, err := RangeWith(sc, RangeOptions{Sort: []string{"-intptr"}, PageSize: 10})
+	assert.NoError(err)
+	assert.Equal([]string{"id40", "id2"}, ids(result.Page))
+}
+
+func TestSoftCollectionIndexes(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	typ := schema.GetType("mocktypes2")
+
+	sc := &SoftCollection{}
+	sc.SetType(&typ)
+
+	// Invalid indexes
+	assert.Error(sc.AddIndex("unknown"))
+	assert.Error(sc.AddIndex("to-one-from-one"))
+
+	assert.NoError(sc.AddIndex("intptr"))
+	assert.NoError(sc.AddIndex("intptr"))
+
+	for i := 0; i < 20; i++ {
+		res := &SoftResource{Type: &typ}
+		res.SetID(fmt.Sprintf("id%02d", 19-i))
+
+		if i%4 != 0 {
+			res.Set("intptr", ptr(i%7))
+			res.Set("strptr", ptr(fmt.Sprintf("str%d", i%5)))
+		}
+
+		sc.Add(res)
+	}
+
+	// The index can also be added to a collection that is not
+	// empty.
+	assert.NoError(sc.AddIndex("strptr"))
+	assert.Equal([]string{"intptr", "strptr"}, sc.Indexes())
+
+	// The resources are modified after being added.
+	sc.Resource("id03", nil).Set("intptr", ptr(3))
+	sc.Resource("id04", nil).Set("intptr", nilptr("int"))
+	sc.Resource("id05", nil).(*SoftResource).SetID("id50")
+	sc.Remove("id06")
+	sc.Remove("id06")
+
+	assert.Equal(19, sc.Len())
+	assert.Nil(sc.Resource("id05", nil))
+	assert.Equal("id50", sc.Resource("id50", nil).Get("id"))
+	assert.Equal("id07", sc.Resource("id07", nil).Get("id"))
+
+	// The same resources without the indexes.
+	plain := &Resources{}
+	for i := 0; i < sc.Len(); i++ {
+		*plain = append(*plain, sc.At(i))
+	}
+
+	tests := []RangeOptions{
+		{Sort: []string{"intptr"}},
+		{Sort: []string{"-intptr"}},
+		{Sort: []string{"-strptr", "id"}},
+		{Sort: []string{"strptr"}, Collation: CollationCaseInsensitive},
+		{IDs: []string{"id01", "id50", "id50", "id99"}, Sort: []string{"intptr"}},
+		{Filter: &Filter{Field: "intptr", Op: "=", Val: ptr(3)}},
+		{Filter: &Filter{Field: "intptr", Op: "<", Val: ptr(3)}, Sort: []string{"-intptr"}},
+		{Filter: &Filter{Field: "intptr", Op: "<=", Val: ptr(3)}, Sort: []string{"strptr"}},
+		{Filter: &Filter{Field: "intptr", Op: ">", Val: ptr(3)}, Sort: []string{"-id"}},
+		{Filter: &Filter{Field: "intptr", Op: ">=", Val: ptr(7)}},
+		{Filter: &Filter{Field: "intptr", Op: "=", Val: nilptr("int")}},
+		{Filter: &Filter{Field: "intptr", Op: "!=", Val: ptr(2)}},
+		{Filter: &Filter{Field: "strptr", Op: "=", Val: ptr("STR1"), Col: "ci"}},
+		{Filter: &Filter{Op: "and", Val: []*Filter{
+			{Field: "strptr", Op: ">=", Val: ptr("str2")},
+			{Field: "intptr", Op: "<", Val: ptr(5)},
+		}}, Sort: []string{"-strptr"}},
+		{Filter: &Filter{Op: "or", Val: []*Filter{
+			{Field: "strptr", Op: "=", Val: ptr("str2")},
+			{Field: "intptr", Op: "=", Val: ptr(5)},
+		}}},
+		{
+			Filter:   &Filter{Field: "intptr", Op: ">", Val: ptr(1)},
+			Sort:     []string{"intptr"},
+			PageSize: 3, PageNumber: 1,
+		},
+	}
+
+	for _, opts := range tests {
+		expected, err := RangeWith(plain, opts)
+		assert.NoError(err)
+
+		result, err := RangeWith(sc, opts)
+		assert.NoError(err)
+
+		assert.Equal(ids(expected.Page), ids(result.Page), "%+v", opts)
+		assert.Equal(expected.Total, result.Total)
+	}
+
+	sc.RemoveIndex("intptr")
+	assert.Equal([]string{"strptr"}, sc.Indexes())
+}
+
+func ids(c Collection) []string {
+	ids := make([]string, 0, c.Len())
+	for i := 0; i < c.Len(); i++ {
+		ids = append(ids, c.At(i).Get("id").(string))
+	}
+
+	return ids
+}
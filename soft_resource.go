@@ -1,7 +1,8 @@
 package jsonapi
 
 import (
-	"fmt"
+	"encoding/json"
+	"reflect"
 	"time"
 )
 
@@ -14,9 +15,16 @@ import (
 type SoftResource struct {
 	Type *Type
 
-	id   string
-	data map[string]interface{}
-	meta Meta
+	id      string
+	lid     string
+	data    map[string]interface{}
+	relLIDs map[string][]string
+	linkage map[string]Identifiers
+	meta    Meta
+
+	// col is the collection that holds the resource, if any. Its
+	// indexes are updated when the resource changes.
+	col *SoftCollection
 }
 
 // Attrs returns the resource's attributes.
@@ -130,6 +138,11 @@ func (sr *SoftResource) Get(key string) interface{} {
 // SetID sets the resource's ID.
 func (sr *SoftResource) SetID(id string) {
 	sr.check()
+
+	if sr.col != nil {
+		defer sr.col.track(sr, "id")()
+	}
+
 	sr.id = id
 }
 
@@ -143,6 +156,10 @@ func (sr *SoftResource) SetType(typ *Type) {
 func (sr *SoftResource) Set(key string, v interface{}) {
 	sr.check()
 
+	if sr.col != nil {
+		defer sr.col.track(sr, key)()
+	}
+
 	if key == "id" {
 		id, _ := v.(string)
 		sr.id = id
@@ -151,11 +168,18 @@ func (sr *SoftResource) Set(key string, v interface{}) {
 	}
 
 	if attr, ok := sr.Type.Attrs[key]; ok {
-		typ, nullable := GetAttrType(fmt.Sprintf("%T", v))
-		if attr.Type == typ && attr.Nullable == nullable {
+		if attr.accepts(v) {
 			sr.data[key] = v
 		} else if v == nil && attr.Nullable {
-			sr.data[key] = GetZeroValue(attr.Type, attr.Nullable)
+			sr.data[key] = attr.zeroValue()
+		} else if !isScalarAttrType(attr.Type) {
+			// Arrays and objects can come in another form, like a
+			// struct for an object, so they are converted.
+			if payload, err := json.Marshal(v); err == nil {
+				if val, err := attr.UnmarshalToType(payload); err == nil {
+					sr.data[key] = val
+				}
+			}
 		}
 	} else if rel, ok := sr.Type.Rels[key]; ok {
 		if _, ok := v.(string); ok && rel.ToOne {
@@ -173,9 +197,12 @@ func (sr *SoftResource) Copy() Resource {
 	typ := sr.Type.Copy()
 
 	return &SoftResource{
-		Type: &typ,
-		id:   sr.id,
-		data: copyData(sr.data),
+		Type:    &typ,
+		id:      sr.id,
+		lid:     sr.lid,
+		data:    copyData(sr.data),
+		relLIDs: copyRelLIDs(sr.relLIDs),
+		linkage: copyRelLinkage(sr.linkage),
 	}
 }
 
@@ -189,6 +216,55 @@ func (sr *SoftResource) SetMeta(m Meta) {
 	sr.meta = m
 }
 
+// LID returns the local ID of the resource.
+func (sr *SoftResource) LID() string {
+	return sr.lid
+}
+
+// SetLID sets the local ID of the resource.
+func (sr *SoftResource) SetLID(lid string) {
+	sr.lid = lid
+}
+
+// RelLIDs returns the local IDs referenced by the relationship named after key.
+func (sr *SoftResource) RelLIDs(key string) []string {
+	return sr.relLIDs[key]
+}
+
+// SetRelLIDs sets the local IDs referenced by the relationship named after key.
+func (sr *SoftResource) SetRelLIDs(key string, lids []string) {
+	if len(lids) == 0 {
+		delete(sr.relLIDs, key)
+		return
+	}
+
+	if sr.relLIDs == nil {
+		sr.relLIDs = map[string][]string{}
+	}
+
+	sr.relLIDs[key] = lids
+}
+
+// relLinkage returns the identifiers of the to-many relationship named after
+// key in the order they were unmarshaled in, if they mix IDs and local IDs.
+func (sr *SoftResource) relLinkage(key string) Identifiers {
+	return sr.linkage[key]
+}
+
+// setRelLinkage sets the identifiers returned by relLinkage.
+func (sr *SoftResource) setRelLinkage(key string, idens Identifiers) {
+	if len(idens) == 0 {
+		delete(sr.linkage, key)
+		return
+	}
+
+	if sr.linkage == nil {
+		sr.linkage = map[string]Identifiers{}
+	}
+
+	sr.linkage[key] = idens
+}
+
 func (sr *SoftResource) fields() []string {
 	fields := make([]string, 0, len(sr.Type.Attrs)+len(sr.Type.Rels))
 	for i := range sr.Type.Attrs {
@@ -222,7 +298,7 @@ func (sr *SoftResource) check() {
 	for i := range sr.Type.Attrs {
 		n := sr.Type.Attrs[i].Name
 		if _, ok := sr.data[n]; !ok {
-			sr.data[n] = GetZeroValue(sr.Type.Attrs[i].Type, sr.Type.Attrs[i].Nullable)
+			sr.data[n] = sr.Type.Attrs[i].zeroValue()
 		}
 	}
 
@@ -287,14 +363,22 @@ func copyData(d map[string]interface{}) map[string]interface{} {
 			d2[k] = v2
 		case time.Time:
 			d2[k] = v2
+		case float32:
+			d2[k] = v2
+		case float64:
+			d2[k] = v2
+		case Decimal:
+			d2[k] = v2
+		case UUID:
+			d2[k] = v2
 		case []uint8:
 			nv := make([]byte, len(v2))
 			_ = copy(nv, v2)
-			d2[k] = v2
+			d2[k] = nv
 		case []string:
 			nv := make([]string, len(v2))
 			_ = copy(nv, v2)
-			d2[k] = v2
+			d2[k] = nv
 		case *string:
 			d2[k] = v2
 		case *int:
@@ -321,16 +405,108 @@ func copyData(d map[string]interface{}) map[string]interface{} {
 			d2[k] = v2
 		case *time.Time:
 			d2[k] = v2
+		case *float32:
+			d2[k] = v2
+		case *float64:
+			d2[k] = v2
+		case *Decimal:
+			d2[k] = v2
+		case *UUID:
+			d2[k] = v2
 		case *[]uint8:
 			if v2 == nil {
 				d2[k] = (*[]uint8)(nil)
 			} else {
 				nv := make([]byte, len(*v2))
 				_ = copy(nv, *v2)
-				d2[k] = v2
+				d2[k] = &nv
 			}
+		default:
+			d2[k] = copyValue(v2)
 		}
 	}
 
 	return d2
 }
+
+// copyValue returns a deep copy of v, the value of an array, an object, or a
+// JSON attribute.
+func copyValue(v interface{}) interface{} {
+	switch v2 := v.(type) {
+	case map[string]interface{}:
+		if v2 == nil {
+			return v2
+		}
+
+		m := make(map[string]interface{}, len(v2))
+		for k, e := range v2 {
+			m[k] = copyValue(e)
+		}
+
+		return m
+	case []interface{}:
+		if v2 == nil {
+			return v2
+		}
+
+		s := make([]interface{}, len(v2))
+		for i, e := range v2 {
+			s[i] = copyValue(e)
+		}
+
+		return s
+	}
+
+	val := reflect.ValueOf(v)
+
+	switch val.Kind() {
+	case reflect.Slice:
+		if val.IsNil() {
+			return v
+		}
+
+		s := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		_ = reflect.Copy(s, val)
+
+		return s.Interface()
+	case reflect.Ptr:
+		if val.IsNil() || val.Elem().Kind() != reflect.Slice {
+			return v
+		}
+
+		p := reflect.New(val.Elem().Type())
+		p.Elem().Set(reflect.ValueOf(copyValue(val.Elem().Interface())))
+
+		return p.Interface()
+	}
+
+	return v
+}
+
+func copyRelLIDs(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+
+	m2 := make(map[string][]string, len(m))
+
+	for k, lids := range m {
+		m2[k] = append([]string(nil), lids...)
+	}
+
+	return m2
+}
+
+func copyRelLinkage(m map[string]Identifiers) map[string]Identifiers {
+	if m == nil {
+		return nil
+	}
+
+	m2 := make(map[string]Identifiers, len(m))
+
+	for k, idens := range m {
+		m2[k] = append(Identifiers(nil), idens...)
+	}
+
+	return m2
+}
@@ -256,6 +256,22 @@ func TestSoftResourceCopy(t *testing.T) {
 
 	sr.Set("nil-*[]byte", (*[]byte)(nil))
 
+	sr.AddAttr(Attr{Name: "array", Type: AttrTypeArray, Elem: AttrTypeInt})
+	sr.Set("array", []int{1, 2})
+
+	sr.AddAttr(Attr{Name: "strings", Type: AttrTypeArray, Elem: AttrTypeString})
+	sr.Set("strings", []string{"a", "b"})
+
+	sr.AddAttr(Attr{
+		Name:   "object",
+		Type:   AttrTypeObject,
+		Fields: map[string]Attr{"str": {Name: "str", Type: AttrTypeString}},
+	})
+	sr.Set("object", map[string]interface{}{"str": "abc"})
+
+	sr.AddAttr(Attr{Name: "json", Type: AttrTypeJSON})
+	sr.Set("json", []interface{}{"a", map[string]interface{}{"b": true}})
+
 	// Relationships
 	sr.AddRel(Rel{
 		FromName: "to-one",
@@ -272,6 +288,24 @@ func TestSoftResourceCopy(t *testing.T) {
 	// Copy
 	sr2 := sr.Copy()
 	assert.Equal(true, Equal(sr, sr2))
+
+	// Arrays, objects, and JSON values are deeply copied.
+	sr2.Get("array").([]int)[0] = 3
+	sr2.Get("object").(map[string]interface{})["str"] = "def"
+	sr2.Get("json").([]interface{})[1].(map[string]interface{})["b"] = false
+	assert.Equal([]int{1, 2}, sr.Get("array"))
+	assert.Equal(map[string]interface{}{"str": "abc"}, sr.Get("object"))
+	assert.Equal([]interface{}{"a", map[string]interface{}{"b": true}}, sr.Get("json"))
+
+	// So are bytes, arrays of strings and to-many relationships.
+	sr2.Get("[]uint8").([]byte)[0] = 'z'
+	(*sr2.Get("*[]uint8").(*[]byte))[0] = 'z'
+	sr2.Get("strings").([]string)[0] = "c"
+	sr2.Get("to-many").([]string)[0] = "id4"
+	assert.Equal([]byte{'a', 'b', 'c'}, sr.Get("[]uint8"))
+	assert.Equal(ptr([]byte{'a', 'b', 'c'}), sr.Get("*[]uint8"))
+	assert.Equal([]string{"a", "b"}, sr.Get("strings"))
+	assert.Equal([]string{"id2", "id3"}, sr.Get("to-many"))
 }
 
 func TestSoftResourceMeta(t *testing.T) {
@@ -296,6 +330,29 @@ func TestSoftResourceMeta(t *testing.T) {
 	assert.Equal(meta, sr.Meta())
 }
 
+func TestSoftResourceLID(t *testing.T) {
+	assert := assert.New(t)
+
+	typ, _ := BuildType(mocktype{})
+	sr := &SoftResource{}
+	sr.Type = &typ
+
+	assert.Equal("", sr.LID())
+	assert.Nil(sr.RelLIDs("to-x"))
+
+	sr.SetLID("a")
+	sr.SetRelLIDs("to-x", []string{"b", "c"})
+	assert.Equal("a", sr.LID())
+	assert.Equal([]string{"b", "c"}, sr.RelLIDs("to-x"))
+
+	// Copy
+	sr2 := sr.Copy().(*SoftResource)
+	sr.SetRelLIDs("to-x", nil)
+	assert.Equal("a", sr2.LID())
+	assert.Equal([]string{"b", "c"}, sr2.RelLIDs("to-x"))
+	assert.Nil(sr.RelLIDs("to-x"))
+}
+
 func TestSoftResourceGetSetID(t *testing.T) {
 	assert := assert.New(t)
 
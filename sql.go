@@ -0,0 +1,479 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A SQLTable describes how the resources of a type are stored in a SQL
+// database.
+type SQLTable struct {
+	// Name is the name of the table.
+	Name string
+
+	// ID is the name of the column holding the resource's ID. It
+	// defaults to "id".
+	ID string
+
+	// Columns maps the names of attributes and to-one relationships
+	// to the names of their columns. A field that is not found in
+	// Columns is stored in a column with the same name.
+	Columns map[string]string
+
+	// JoinTables maps the names of to-many relationships to the join
+	// tables holding them.
+	JoinTables map[string]SQLJoinTable
+}
+
+// A SQLJoinTable describes a table that stores a to-many relationship as pairs
+// of IDs.
+type SQLJoinTable struct {
+	// Name is the name of the table.
+	Name string
+
+	// From is the name of the column holding the ID of the resource
+	// the relationship belongs to.
+	From string
+
+	// To is the name of the column holding the ID of the related
+	// resource.
+	To string
+}
+
+// A SQLBuilder builds SQL queries from URLs.
+//
+// Identifiers are always quoted with double quotes and values are always passed
+// as arguments, so the queries are safe to run even when the URL comes from an
+// untrusted source.
+type SQLBuilder struct {
+	// Tables maps the names of types to the tables storing them.
+	Tables map[string]SQLTable
+
+	// Placeholder returns the placeholder of the nth argument of a
+	// query, starting at 1. If nil, "?" is used for all arguments.
+	Placeholder func(n int) string
+}
+
+// DollarPlaceholder returns "$n". It can be used as SQLBuilder.Placeholder for
+// databases like PostgreSQL.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Select returns a SELECT query and its arguments for fetching the resources
+// described by url.
+//
+// The ID and the columns of the fields found in url.Params.Fields are selected,
+// except for to-many relationships which are stored in join tables. The
+// filter, the sorting rules, the pagination and the parent resource (see
+// BelongsToFilter) of url are all translated into the query.
+//
+// An error is returned for the parts of url that cannot be translated: paths
+// in the filter or the sorting rules, collations other than the binary one,
+// text operators, and page cursors.
+func (b *SQLBuilder) Select(url *URL) (string, []interface{}, error) {
+	q := &sqlQuery{builder: b}
+
+	tbl, err := b.table(url.ResType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Columns
+	cols := []string{q.col(tbl, "id")}
+
+	for _, field := range url.Params.Fields[url.ResType] {
+		if _, ok := tbl.JoinTables[field]; ok || field == "id" {
+			continue
+		}
+
+		cols = append(cols, q.col(tbl, field))
+	}
+
+	q.buf.WriteString("SELECT " + strings.Join(cols, ", "))
+	q.buf.WriteString(" FROM " + quoteIdent(tbl.Name))
+
+	// Where
+	conds := []string{}
+
+	if !url.IsCol && url.RelKind == "" {
+		conds = append(conds, q.col(tbl, "id")+" = "+q.arg(url.ResID))
+	}
+
+	if btf := url.BelongsToFilter; btf.ID != "" {
+		cond, err := q.belongsTo(tbl, btf)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if url.Params.Filter != nil {
+		cond, err := q.filter(tbl, url.Params.Filter)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if len(conds) > 0 {
+		q.buf.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+
+	// Order by
+	rules := []string{}
+	sortedByID := false
+
+	for _, rule := range url.Params.SortingRules {
+		name, desc, nulls := parseSortRule(rule)
+
+		if strings.Contains(name, ".") {
+			return "", nil, fmt.Errorf("jsonapi: sorting rule %q is not supported", rule)
+		}
+
+		if _, ok := tbl.JoinTables[name]; ok {
+			continue
+		}
+
+		if name == "id" {
+			sortedByID = true
+		}
+
+		dir := " ASC"
+		if desc {
+			dir = " DESC"
+		}
+
+		switch nulls {
+		case sortNullsFirst:
+			dir += " NULLS FIRST"
+		case sortNullsLast:
+			dir += " NULLS LAST"
+		}
+
+		rules = append(rules, q.col(tbl, name)+dir)
+	}
+
+	// The ID is always used last so that the order is
+	// deterministic, which is required for pagination.
+	if !sortedByID {
+		rules = append(rules, q.col(tbl, "id")+" ASC")
+	}
+
+	q.buf.WriteString(" ORDER BY " + strings.Join(rules, ", "))
+
+	// Pagination
+	if url.Params.PageAfter != "" || url.Params.PageBefore != "" {
+		return "", nil, errors.New("jsonapi: page cursors are not supported")
+	}
+
+	if size := url.Params.PageSize; size > 0 {
+		q.buf.WriteString(" LIMIT " + q.arg(size))
+		q.buf.WriteString(" OFFSET " + q.arg(size*url.Params.PageNumber))
+	}
+
+	return q.buf.String(), q.args, nil
+}
+
+// table returns the table of type typ.
+func (b *SQLBuilder) table(typ string) (SQLTable, error) {
+	tbl, ok := b.Tables[typ]
+	if !ok {
+		return SQLTable{}, fmt.Errorf("jsonapi: no table for type %q", typ)
+	}
+
+	if tbl.ID == "" {
+		tbl.ID = "id"
+	}
+
+	return tbl, nil
+}
+
+// sqlQuery holds the state of a query being built.
+type sqlQuery struct {
+	builder *SQLBuilder
+	buf     strings.Builder
+	args    []interface{}
+}
+
+// arg adds v to the arguments and returns its placeholder.
+func (q *sqlQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+
+	if q.builder.Placeholder == nil {
+		return "?"
+	}
+
+	return q.builder.Placeholder(len(q.args))
+}
+
+// argList adds vals to the arguments and returns their placeholders separated
+// by commas.
+func (q *sqlQuery) argList(vals []interface{}) string {
+	phs := make([]string, 0, len(vals))
+	for _, v := range vals {
+		phs = append(phs, q.arg(v))
+	}
+
+	return strings.Join(phs, ", ")
+}
+
+// col returns the qualified and quoted column of field in tbl.
+func (q *sqlQuery) col(tbl SQLTable, field string) string {
+	name := field
+
+	if field == "id" {
+		name = tbl.ID
+	} else if c, ok := tbl.Columns[field]; ok {
+		name = c
+	}
+
+	return quoteIdent(tbl.Name) + "." + quoteIdent(name)
+}
+
+// belongsTo returns a condition that only keeps the resources related to the
+// parent resource described by btf.
+func (q *sqlQuery) belongsTo(tbl SQLTable, btf BelongsToFilter) (string, error) {
+	parent, err := q.builder.table(btf.Type)
+	if err != nil {
+		return "", err
+	}
+
+	if jt, ok := parent.JoinTables[btf.Name]; ok {
+		jtName := quoteIdent(jt.Name)
+
+		return fmt.Sprintf(
+			"%s IN (SELECT %s.%s FROM %s WHERE %s.%s = %s)",
+			q.col(tbl, "id"),
+			jtName, quoteIdent(jt.To),
+			jtName,
+			jtName, quoteIdent(jt.From), q.arg(btf.ID),
+		), nil
+	}
+
+	return fmt.Sprintf(
+		"%s IN (SELECT %s FROM %s WHERE %s = %s)",
+		q.col(tbl, "id"),
+		q.col(parent, btf.Name),
+		quoteIdent(parent.Name),
+		q.col(parent, "id"), q.arg(btf.ID),
+	), nil
+}
+
+// filter returns the condition equivalent to f.
+//
+// Fields of related resources, collations other than the binary one, and text
+// operators cannot be translated, so an error is returned for them.
+func (q *sqlQuery) filter(tbl SQLTable, f *Filter) (string, error) {
+	if strings.Contains(f.Field, ".") {
+		return "", fmt.Errorf("jsonapi: filter on field %q is not supported", f.Field)
+	}
+
+	if f.Col != CollationBinary {
+		return "", fmt.Errorf("jsonapi: collation %q is not supported", f.Col)
+	}
+
+	switch f.Op {
+	case "and", "or":
+		filters, _ := f.Val.([]*Filter)
+		if len(filters) == 0 {
+			// An empty "and" is always true and an empty
+			// "or" is always false, like in IsAllowed.
+			if f.Op == "and" {
+				return "1 = 1", nil
+			}
+
+			return "1 = 0", nil
+		}
+
+		conds := make([]string, 0, len(filters))
+
+		for _, sub := range filters {
+			cond, err := q.filter(tbl, sub)
+			if err != nil {
+				return "", err
+			}
+
+			conds = append(conds, cond)
+		}
+
+		return "(" + strings.Join(conds, " "+strings.ToUpper(f.Op)+" ") + ")", nil
+	case "not":
+		sub, _ := f.Val.(*Filter)
+		if sub == nil {
+			return "", NewErrInvalidValueInFilterParameter(filterValString(f.Val), "filter")
+		}
+
+		cond, err := q.filter(tbl, sub)
+		if err != nil {
+			return "", err
+		}
+
+		return "NOT (" + cond + ")", nil
+	case "is-null":
+		return q.col(tbl, f.Field) + " IS NULL", nil
+	case "is-not-null":
+		return q.col(tbl, f.Field) + " IS NOT NULL", nil
+	case "in", "not-in":
+		vals := sqlValues(f.Val)
+		if len(vals) == 0 {
+			// Like in IsAllowed, nothing is in an empty list.
+			if f.Op == "in" {
+				return "1 = 0", nil
+			}
+
+			return "1 = 1", nil
+		}
+
+		op := " IN ("
+		if f.Op == "not-in" {
+			op = " NOT IN ("
+		}
+
+		return q.col(tbl, f.Field) + op + q.argList(vals) + ")", nil
+	case "has", "has-any", "has-all":
+		return q.joinFilter(tbl, f)
+	case "=", "!=", "<", "<=", ">", ">=":
+		if _, ok := f.Val.([]string); ok {
+			// The IDs of a to-many relationship.
+			return q.joinFilter(tbl, f)
+		}
+
+		val := derefValue(f.Val)
+
+		if val == nil {
+			switch f.Op {
+			case "=":
+				return q.col(tbl, f.Field) + " IS NULL", nil
+			case "!=":
+				return q.col(tbl, f.Field) + " IS NOT NULL", nil
+			default:
+				// Like in IsAllowed, nothing can be compared
+				// to null.
+				return "1 = 0", nil
+			}
+		}
+
+		op := f.Op
+		if op == "!=" {
+			op = "<>"
+		}
+
+		return q.col(tbl, f.Field) + " " + op + " " + q.arg(val), nil
+	case "contains", "starts-with", "ends-with", "like", "regex":
+		// The escaping rules of LIKE and the regular expression
+		// operators differ from one database to another.
+		return "", fmt.Errorf("jsonapi: operator %q is not supported", f.Op)
+	default:
+		return "", NewErrUnknownOperatorInFilterParameter(f.Op)
+	}
+}
+
+// joinFilter returns the condition equivalent to f, a filter on a to-many
+// relationship stored in a join table.
+func (q *sqlQuery) joinFilter(tbl SQLTable, f *Filter) (string, error) {
+	jt, ok := tbl.JoinTables[f.Field]
+	if !ok {
+		return "", fmt.Errorf(
+			"jsonapi: no join table for relationship %q of table %q",
+			f.Field, tbl.Name,
+		)
+	}
+
+	jtName := quoteIdent(jt.Name)
+	from := jtName + "." + quoteIdent(jt.From) + " = " + q.col(tbl, "id")
+	to := jtName + "." + quoteIdent(jt.To)
+
+	switch f.Op {
+	case "has":
+		return fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s WHERE %s AND %s = %s)",
+			jtName, from, to, q.arg(f.Val),
+		), nil
+	case "has-any":
+		vals := sqlValues(f.Val)
+		if len(vals) == 0 {
+			return "1 = 0", nil
+		}
+
+		return fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s WHERE %s AND %s IN (%s))",
+			jtName, from, to, q.argList(vals),
+		), nil
+	case "has-all":
+		ids, _ := f.Val.([]string)
+
+		vals := sqlValues(uniqueStrings(ids))
+		if len(vals) == 0 {
+			return "1 = 1", nil
+		}
+
+		return fmt.Sprintf(
+			"(SELECT COUNT(DISTINCT %s) FROM %s WHERE %s AND %s IN (%s)) = %d",
+			to, jtName, from, to, q.argList(vals), len(vals),
+		), nil
+	case "=", "!=":
+		// The relationship holds exactly the IDs if it holds
+		// as many IDs and all of them.
+		ids, _ := f.Val.([]string)
+		vals := sqlValues(uniqueStrings(ids))
+
+		cond := fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s)", jtName, from)
+
+		if len(vals) > 0 {
+			cond = fmt.Sprintf(
+				"(SELECT COUNT(DISTINCT %s) FROM %s WHERE %s) = %d"+
+					" AND (SELECT COUNT(DISTINCT %s) FROM %s WHERE %s AND %s IN (%s)) = %d",
+				to, jtName, from, len(vals),
+				to, jtName, from, to, q.argList(vals), len(vals),
+			)
+		}
+
+		if f.Op == "!=" {
+			return "NOT (" + cond + ")", nil
+		}
+
+		return "(" + cond + ")", nil
+	default:
+		return "", NewErrUnknownOperatorInFilterParameter(f.Op)
+	}
+}
+
+// sqlValues returns the elements of v if it is a slice, dereferenced like
+// derefValue does.
+func sqlValues(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+
+	vals := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		vals = append(vals, derefValue(rv.Index(i).Interface()))
+	}
+
+	return vals
+}
+
+// uniqueStrings returns strs without its duplicates.
+func uniqueStrings(strs []string) []string {
+	unique := make([]string, 0, len(strs))
+
+	for _, str := range strs {
+		if !containsID(unique, str) {
+			unique = append(unique, str)
+		}
+	}
+
+	return unique
+}
+
+// quoteIdent returns the identifier name quoted with double quotes.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,315 @@
+package jsonapi_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLBuilderSelect(t *testing.T) {
+	schema := newMockSchema()
+
+	builder := &SQLBuilder{
+		Tables: map[string]SQLTable{
+			"mocktypes1": {
+				Name: "mt1",
+				JoinTables: map[string]SQLJoinTable{
+					"to-many-from-many": {
+						Name: "mt1_mt2",
+						From: "mt1_id",
+						To:   "mt2_id",
+					},
+				},
+			},
+			"mocktypes2": {
+				Name: "mt2",
+				ID:   "key",
+				Columns: map[string]string{
+					"strptr": "str",
+				},
+			},
+			"mocktypes3": {
+				Name: "mt3",
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		url           string
+		filter        *Filter
+		sort          []string
+		after         string
+		before        string
+		placeholder   func(int) string
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedError bool
+	}{
+		{
+			name: "collection",
+			url:  "/mocktypes3?fields[mocktypes3]=attr1,rel2",
+			expectedQuery: `SELECT "mt3"."id", "mt3"."attr1", "mt3"."rel2" FROM "mt3"` +
+				` ORDER BY "mt3"."id" ASC`,
+			expectedArgs: nil,
+		}, {
+			name: "single resource",
+			url:  "/mocktypes2/abc?fields[mocktypes2]=strptr",
+			expectedQuery: `SELECT "mt2"."key", "mt2"."str" FROM "mt2"` +
+				` WHERE "mt2"."key" = ? ORDER BY "mt2"."key" ASC`,
+			expectedArgs: []interface{}{"abc"},
+		}, {
+			name: "sorting and pagination",
+			url: "/mocktypes3?fields[mocktypes3]=attr1" +
+				"&page[size]=10&page[number]=2",
+			sort:        []string{"-attr2", "attr1"},
+			placeholder: DollarPlaceholder,
+			expectedQuery: `SELECT "mt3"."id", "mt3"."attr1" FROM "mt3"` +
+				` ORDER BY "mt3"."attr2" DESC, "mt3"."attr1" ASC, "mt3"."id" ASC` +
+				` LIMIT $1 OFFSET $2`,
+			expectedArgs: []interface{}{uint(10), uint(20)},
+		}, {
+			name: "null ordering",
+			url:  "/mocktypes2?fields[mocktypes2]=strptr",
+			sort: []string{"-strptr:nulls-last", "intptr:nulls-first"},
+			expectedQuery: `SELECT "mt2"."key", "mt2"."str" FROM "mt2"` +
+				` ORDER BY "mt2"."str" DESC NULLS LAST, "mt2"."intptr" ASC NULLS FIRST,` +
+				` "mt2"."key" ASC`,
+		}, {
+			name:          "sorting through relationships",
+			url:           "/mocktypes2",
+			sort:          []string{"to-one-from-many.str"},
+			expectedError: true,
+		}, {
+			name: "filter",
+			url:  "/mocktypes3?fields[mocktypes3]=attr1",
+			filter: &Filter{
+				Op: "or",
+				Val: []*Filter{
+					{Field: "attr1", Op: "=", Val: "abc"},
+					{
+						Op: "and",
+						Val: []*Filter{
+							{Field: "attr2", Op: ">=", Val: 3},
+							{Field: "attr2", Op: "!=", Val: 5},
+						},
+					},
+					{Field: "rel1", Op: "in", Val: []string{"a", "b"}},
+				},
+			},
+			placeholder: DollarPlaceholder,
+			expectedQuery: `SELECT "mt3"."id", "mt3"."attr1" FROM "mt3"` +
+				` WHERE ("mt3"."attr1" = $1 OR ("mt3"."attr2" >= $2 AND "mt3"."attr2" <> $3)` +
+				` OR "mt3"."rel1" IN ($4, $5)) ORDER BY "mt3"."id" ASC`,
+			expectedArgs: []interface{}{"abc", 3, 5, "a", "b"},
+		}, {
+			name: "filter with null values",
+			url:  "/mocktypes2?fields[mocktypes2]=strptr",
+			filter: &Filter{
+				Op: "and",
+				Val: []*Filter{
+					{Field: "strptr", Op: "=", Val: (*string)(nil)},
+					{Field: "intptr", Op: "!=", Val: nil},
+					{Field: "intptr", Op: "<", Val: nil},
+					{Field: "boolptr", Op: "=", Val: ptr(true)},
+					{Field: "id", Op: "in", Val: []string{}},
+					{Field: "intptr", Op: "not-in", Val: []interface{}{ptr(1), ptr(2)}},
+				},
+			},
+			expectedQuery: `SELECT "mt2"."key", "mt2"."str" FROM "mt2"` +
+				` WHERE ("mt2"."str" IS NULL AND "mt2"."intptr" IS NOT NULL AND 1 = 0` +
+				` AND "mt2"."boolptr" = ? AND 1 = 0 AND "mt2"."intptr" NOT IN (?, ?))` +
+				` ORDER BY "mt2"."key" ASC`,
+			expectedArgs: []interface{}{true, 1, 2},
+		}, {
+			name: "filter with has",
+			url:  "/mocktypes1?fields[mocktypes1]=str,to-many-from-many",
+			filter: &Filter{
+				Field: "to-many-from-many", Op: "has", Val: "mt2-1",
+			},
+			expectedQuery: `SELECT "mt1"."id", "mt1"."str" FROM "mt1"` +
+				` WHERE EXISTS (SELECT 1 FROM "mt1_mt2" WHERE "mt1_mt2"."mt1_id" = "mt1"."id"` +
+				` AND "mt1_mt2"."mt2_id" = ?) ORDER BY "mt1"."id" ASC`,
+			expectedArgs: []interface{}{"mt2-1"},
+		}, {
+			name: "filter with negation and null checks",
+			url:  "/mocktypes2?fields[mocktypes2]=strptr",
+			filter: &Filter{
+				Op: "not",
+				Val: &Filter{Op: "or", Val: []*Filter{
+					{Field: "strptr", Op: "is-null"},
+					{Field: "intptr", Op: "is-not-null"},
+					{Field: "id", Op: "not-in", Val: []string{"a", "b"}},
+					{Field: "id", Op: "not-in", Val: []string{}},
+				}},
+			},
+			expectedQuery: `SELECT "mt2"."key", "mt2"."str" FROM "mt2"` +
+				` WHERE NOT (("mt2"."str" IS NULL OR "mt2"."intptr" IS NOT NULL` +
+				` OR "mt2"."key" NOT IN (?, ?) OR 1 = 1)) ORDER BY "mt2"."key" ASC`,
+			expectedArgs: []interface{}{"a", "b"},
+		}, {
+			name: "filter with has-any and has-all",
+			url:  "/mocktypes1?fields[mocktypes1]=str",
+			filter: &Filter{Op: "and", Val: []*Filter{
+				{Field: "to-many-from-many", Op: "has-any", Val: []string{"a", "b"}},
+				{Field: "to-many-from-many", Op: "has-all", Val: []string{"a", "b", "a"}},
+				{Field: "to-many-from-many", Op: "has-any", Val: []string{}},
+				{Field: "to-many-from-many", Op: "has-all", Val: []string{}},
+			}},
+			expectedQuery: `SELECT "mt1"."id", "mt1"."str" FROM "mt1"` +
+				` WHERE (EXISTS (SELECT 1 FROM "mt1_mt2" WHERE "mt1_mt2"."mt1_id" = "mt1"."id"` +
+				` AND "mt1_mt2"."mt2_id" IN (?, ?))` +
+				` AND (SELECT COUNT(DISTINCT "mt1_mt2"."mt2_id") FROM "mt1_mt2"` +
+				` WHERE "mt1_mt2"."mt1_id" = "mt1"."id" AND "mt1_mt2"."mt2_id" IN (?, ?)) = 2` +
+				` AND 1 = 0 AND 1 = 1) ORDER BY "mt1"."id" ASC`,
+			expectedArgs: []interface{}{"a", "b", "a", "b"},
+		}, {
+			name: "filter with equal IDs",
+			url:  "/mocktypes1?fields[mocktypes1]=str",
+			filter: &Filter{Op: "or", Val: []*Filter{
+				{Field: "to-many-from-many", Op: "=", Val: []string{"a", "b", "a"}},
+				{Field: "to-many-from-many", Op: "!=", Val: []string{}},
+			}},
+			expectedQuery: `SELECT "mt1"."id", "mt1"."str" FROM "mt1"` +
+				` WHERE (((SELECT COUNT(DISTINCT "mt1_mt2"."mt2_id") FROM "mt1_mt2"` +
+				` WHERE "mt1_mt2"."mt1_id" = "mt1"."id") = 2` +
+				` AND (SELECT COUNT(DISTINCT "mt1_mt2"."mt2_id") FROM "mt1_mt2"` +
+				` WHERE "mt1_mt2"."mt1_id" = "mt1"."id" AND "mt1_mt2"."mt2_id" IN (?, ?)) = 2)` +
+				` OR NOT (NOT EXISTS (SELECT 1 FROM "mt1_mt2"` +
+				` WHERE "mt1_mt2"."mt1_id" = "mt1"."id"))) ORDER BY "mt1"."id" ASC`,
+			expectedArgs: []interface{}{"a", "b"},
+		}, {
+			name: "related collection from join table",
+			url:  "/mocktypes1/abc/to-many-from-many?fields[mocktypes2]=strptr",
+			expectedQuery: `SELECT "mt2"."key", "mt2"."str" FROM "mt2"` +
+				` WHERE "mt2"."key" IN (SELECT "mt1_mt2"."mt2_id" FROM "mt1_mt2"` +
+				` WHERE "mt1_mt2"."mt1_id" = ?) ORDER BY "mt2"."key" ASC`,
+			expectedArgs: []interface{}{"abc"},
+		}, {
+			name: "related resource from column",
+			url:  "/mocktypes3/abc/rel1?fields[mocktypes1]=str",
+			expectedQuery: `SELECT "mt1"."id", "mt1"."str" FROM "mt1"` +
+				` WHERE "mt1"."id" IN (SELECT "mt3"."rel1" FROM "mt3"` +
+				` WHERE "mt3"."id" = ?) ORDER BY "mt1"."id" ASC`,
+			expectedArgs: []interface{}{"abc"},
+		}, {
+			name: "quoted identifiers",
+			url:  "/mocktypes3?fields[mocktypes3]=attr1",
+			filter: &Filter{
+				Field: `attr1" OR 1 = 1 --`, Op: "=", Val: "abc",
+			},
+			expectedQuery: `SELECT "mt3"."id", "mt3"."attr1" FROM "mt3"` +
+				` WHERE "mt3"."attr1"" OR 1 = 1 --" = ? ORDER BY "mt3"."id" ASC`,
+			expectedArgs: []interface{}{"abc"},
+		}, {
+			name: "unknown operator",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "attr1", Op: "~", Val: "abc",
+			},
+			expectedError: true,
+		}, {
+			name: "not without filter",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Op: "not", Val: "abc",
+			},
+			expectedError: true,
+		}, {
+			name: "has without join table",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "rel2", Op: "has", Val: "abc",
+			},
+			expectedError: true,
+		}, {
+			name: "equal IDs without join table",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "rel2", Op: "=", Val: []string{"abc"},
+			},
+			expectedError: true,
+		}, {
+			name: "text operator",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "attr1", Op: "contains", Val: "abc",
+			},
+			expectedError: true,
+		}, {
+			name: "filter on a path",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "rel1.str", Op: "=", Val: "abc",
+			},
+			expectedError: true,
+		}, {
+			name: "nested filter on a path",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Op: "not", Val: &Filter{
+					Field: "rel1.str", Op: "is-null",
+				},
+			},
+			expectedError: true,
+		}, {
+			name: "filter with a collation",
+			url:  "/mocktypes3",
+			filter: &Filter{
+				Field: "attr1", Op: "=", Val: "abc", Col: CollationCaseInsensitive,
+			},
+			expectedError: true,
+		}, {
+			name:          "page after",
+			url:           "/mocktypes3?page[size]=2",
+			after:         "abc",
+			expectedError: true,
+		}, {
+			name:          "page before",
+			url:           "/mocktypes3?page[size]=2",
+			before:        "abc",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			url, err := NewURLFromRaw(schema, test.url)
+			assert.NoError(err)
+
+			url.Params.Filter = test.filter
+			url.Params.SortingRules = test.sort
+			url.Params.PageAfter = test.after
+			url.Params.PageBefore = test.before
+			builder.Placeholder = test.placeholder
+
+			query, args, err := builder.Select(url)
+
+			if test.expectedError {
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(test.expectedQuery, query)
+			assert.Equal(test.expectedArgs, args)
+		})
+	}
+
+	// Unknown type
+	url, _ := NewURLFromRaw(schema, "/mocktypes3")
+	_, _, err := (&SQLBuilder{}).Select(url)
+	assert.Error(t, err)
+
+	// Text operators are valid filters that cannot be translated.
+	for _, op := range []string{"contains", "starts-with", "ends-with", "like", "regex"} {
+		url.Params.Filter = &Filter{Field: "attr1", Op: op, Val: "abc"}
+		_, _, err = builder.Select(url)
+		assert.EqualError(t, err, fmt.Sprintf("jsonapi: operator %q is not supported", op))
+	}
+}
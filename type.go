@@ -1,6 +1,7 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +26,12 @@ import (
 //  - bool
 //  - time (Go type is time.Time)
 //  - bytes (Go type is []uint8 or []byte)
+//  - float32, float64
+//  - decimal (Go type is Decimal)
+//  - uuid (Go type is UUID)
+//  - array (Go type is a slice of one of the types above, see Attr)
+//  - object (Go type is map[string]interface{} or a struct, see Attr)
+//  - json (Go type is interface{}, any JSON value)
 //
 // An asterisk is present as a prefix when the type is nullable (like *string).
 //
@@ -46,6 +53,13 @@ const (
 	AttrTypeBool
 	AttrTypeTime
 	AttrTypeBytes
+	AttrTypeFloat32
+	AttrTypeFloat64
+	AttrTypeDecimal
+	AttrTypeUUID
+	AttrTypeArray
+	AttrTypeObject
+	AttrTypeJSON
 )
 
 // A Type stores all the necessary information about a type as represented in
@@ -77,6 +91,10 @@ func (t *Type) AddAttr(attr Attr) error {
 		return fmt.Errorf("jsonapi: attribute type is invalid")
 	}
 
+	if attr.Type == AttrTypeArray && !isScalarAttrType(attr.Elem) {
+		return fmt.Errorf("jsonapi: array element type is invalid")
+	}
+
 	// Make sure the name isn't already used
 	for i := range t.Attrs {
 		if t.Attrs[i].Name == attr.Name {
@@ -198,17 +216,37 @@ func (t Type) Copy() Type {
 }
 
 // Attr represents a resource attribute.
+//
+// Rules holds the validation rules of the attribute, if any.
+//
+// Elem is the type of the elements of an array (AttrTypeArray). The elements
+// are scalars and cannot be null. An array of strings is a []string, or a
+// *[]string if the attribute is nullable.
+//
+// Fields holds the fields of an object (AttrTypeObject) indexed by name. Each
+// field is described by an Attr, so objects can be nested. An object is a
+// map[string]interface{} that holds a value for each field, or a nil map if it
+// is null. Wrap and BuildType also accept structs and pointers to structs.
+//
+// A free-form JSON value (AttrTypeJSON) can be anything json.Unmarshal returns
+// when decoding into an interface{}, except that numbers are json.Number values
+// to preserve their precision.
 type Attr struct {
 	Name     string
 	Type     int
 	Nullable bool
+	Rules    *AttrRules
+	Elem     int
+	Fields   map[string]Attr
 }
 
 // UnmarshalToType unmarshals the data into a value of the type represented by
 // the attribute and returns it.
+//
+// An error is returned if an integer does not fit in the size of the type.
 func (a Attr) UnmarshalToType(data []byte) (interface{}, error) {
 	if a.Nullable && string(data) == "null" {
-		return GetZeroValue(a.Type, a.Nullable), nil
+		return a.zeroValue(), nil
 	}
 
 	var (
@@ -236,40 +274,40 @@ func (a Attr) UnmarshalToType(data []byte) (interface{}, error) {
 			v = v.(int)
 		}
 	case AttrTypeInt8:
-		v, err = strconv.Atoi(string(data))
+		v, err = strconv.ParseInt(string(data), 10, 8)
 
 		if a.Nullable {
-			n := int8(v.(int))
+			n := int8(v.(int64))
 			v = &n
 		} else {
-			v = int8(v.(int))
+			v = int8(v.(int64))
 		}
 	case AttrTypeInt16:
-		v, err = strconv.Atoi(string(data))
+		v, err = strconv.ParseInt(string(data), 10, 16)
 
 		if a.Nullable {
-			n := int16(v.(int))
+			n := int16(v.(int64))
 			v = &n
 		} else {
-			v = int16(v.(int))
+			v = int16(v.(int64))
 		}
 	case AttrTypeInt32:
-		v, err = strconv.Atoi(string(data))
+		v, err = strconv.ParseInt(string(data), 10, 32)
 
 		if a.Nullable {
-			n := int32(v.(int))
+			n := int32(v.(int64))
 			v = &n
 		} else {
-			v = int32(v.(int))
+			v = int32(v.(int64))
 		}
 	case AttrTypeInt64:
-		v, err = strconv.Atoi(string(data))
+		v, err = strconv.ParseInt(string(data), 10, 64)
 
 		if a.Nullable {
-			n := int64(v.(int))
+			n := v.(int64)
 			v = &n
 		} else {
-			v = int64(v.(int))
+			v = v.(int64)
 		}
 	case AttrTypeUint:
 		v, err = strconv.ParseUint(string(data), 10, 64)
@@ -350,6 +388,47 @@ func (a Attr) UnmarshalToType(data []byte) (interface{}, error) {
 		} else {
 			v = s
 		}
+	case AttrTypeFloat32:
+		var f float64
+		f, err = strconv.ParseFloat(string(data), 32)
+		f32 := float32(f)
+		v = f32
+
+		if a.Nullable {
+			v = &f32
+		}
+	case AttrTypeFloat64:
+		var f float64
+		f, err = strconv.ParseFloat(string(data), 64)
+		v = f
+
+		if a.Nullable {
+			v = &f
+		}
+	case AttrTypeDecimal:
+		var d Decimal
+		err = json.Unmarshal(data, &d)
+		v = d
+
+		if a.Nullable {
+			v = &d
+		}
+	case AttrTypeUUID:
+		var u UUID
+		err = json.Unmarshal(data, &u)
+		v = u
+
+		if a.Nullable {
+			v = &u
+		}
+	case AttrTypeArray:
+		v, err = a.unmarshalArray(data)
+	case AttrTypeObject:
+		v, err = a.unmarshalObject(data)
+	case AttrTypeJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&v)
 	default:
 		err = errors.New("attribute is of invalid or unknown type")
 	}
@@ -365,6 +444,134 @@ func (a Attr) UnmarshalToType(data []byte) (interface{}, error) {
 	return v, nil
 }
 
+// unmarshalArray unmarshals data into a slice of the element type of a.
+func (a Attr) unmarshalArray(data []byte) (interface{}, error) {
+	if !isScalarAttrType(a.Elem) {
+		return nil, errors.New("array element type is invalid")
+	}
+
+	var raws []json.RawMessage
+
+	err := json.Unmarshal(data, &raws)
+	if err != nil {
+		return nil, err
+	} else if raws == nil {
+		return nil, errors.New("array is null")
+	}
+
+	elem := Attr{Name: a.Name, Type: a.Elem}
+	typ := reflect.TypeOf(GetZeroValue(a.Elem, false))
+	arr := reflect.MakeSlice(reflect.SliceOf(typ), 0, len(raws))
+
+	for _, raw := range raws {
+		v, err := elem.UnmarshalToType(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		arr = reflect.Append(arr, reflect.ValueOf(v))
+	}
+
+	if a.Nullable {
+		ptr := reflect.New(arr.Type())
+		ptr.Elem().Set(arr)
+
+		return ptr.Interface(), nil
+	}
+
+	return arr.Interface(), nil
+}
+
+// unmarshalObject unmarshals data into a map that holds a value for each field
+// of a. Missing fields are set to their zero values.
+func (a Attr) unmarshalObject(data []byte) (interface{}, error) {
+	var raws map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &raws)
+	if err != nil {
+		return nil, err
+	} else if raws == nil {
+		return nil, errors.New("object is null")
+	}
+
+	obj := make(map[string]interface{}, len(a.Fields))
+
+	for name, raw := range raws {
+		field, ok := a.Fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+
+		obj[name], err = field.UnmarshalToType(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for name, field := range a.Fields {
+		if _, ok := obj[name]; !ok {
+			obj[name] = field.zeroValue()
+		}
+	}
+
+	return obj, nil
+}
+
+// zeroValue returns the zero value of the attribute.
+//
+// Unlike GetZeroValue, it takes the element type of arrays and the fields of
+// objects into account.
+func (a Attr) zeroValue() interface{} {
+	switch a.Type {
+	case AttrTypeArray:
+		if !isScalarAttrType(a.Elem) {
+			return nil
+		}
+
+		typ := reflect.SliceOf(reflect.TypeOf(GetZeroValue(a.Elem, false)))
+		if a.Nullable {
+			return reflect.Zero(reflect.PtrTo(typ)).Interface()
+		}
+
+		return reflect.MakeSlice(typ, 0, 0).Interface()
+	case AttrTypeObject:
+		if a.Nullable {
+			return map[string]interface{}(nil)
+		}
+
+		obj := make(map[string]interface{}, len(a.Fields))
+		for name, field := range a.Fields {
+			obj[name] = field.zeroValue()
+		}
+
+		return obj
+	default:
+		return GetZeroValue(a.Type, a.Nullable)
+	}
+}
+
+// accepts reports whether v can be the value of the attribute.
+func (a Attr) accepts(v interface{}) bool {
+	switch a.Type {
+	case AttrTypeArray:
+		return v != nil && reflect.TypeOf(v) == reflect.TypeOf(a.zeroValue())
+	case AttrTypeObject:
+		obj, ok := v.(map[string]interface{})
+		return ok && (obj != nil || a.Nullable)
+	case AttrTypeJSON:
+		return true
+	default:
+		typ, nullable := GetAttrType(fmt.Sprintf("%T", v))
+		return a.Type == typ && a.Nullable == nullable
+	}
+}
+
+// isScalarAttrType reports whether t is the type of an attribute that is not an
+// array, an object, or a JSON value.
+func isScalarAttrType(t int) bool {
+	return t >= AttrTypeString && t <= AttrTypeUUID
+}
+
 // Rel represents a resource relationship.
 type Rel struct {
 	FromType string
@@ -454,6 +661,20 @@ func GetAttrType(t string) (int, bool) {
 		return AttrTypeTime, nullable
 	case "[]uint8", "[]byte", "bytes":
 		return AttrTypeBytes, nullable
+	case "float32":
+		return AttrTypeFloat32, nullable
+	case "float64":
+		return AttrTypeFloat64, nullable
+	case "jsonapi.Decimal", "decimal":
+		return AttrTypeDecimal, nullable
+	case "jsonapi.UUID", "uuid":
+		return AttrTypeUUID, nullable
+	case "array":
+		return AttrTypeArray, nullable
+	case "object":
+		return AttrTypeObject, nullable
+	case "json":
+		return AttrTypeJSON, nullable
 	default:
 		return AttrTypeInvalid, false
 	}
@@ -493,6 +714,20 @@ func GetAttrTypeString(t int, nullable bool) string {
 		str = "time"
 	case AttrTypeBytes:
 		str = "bytes"
+	case AttrTypeFloat32:
+		str = "float32"
+	case AttrTypeFloat64:
+		str = "float64"
+	case AttrTypeDecimal:
+		str = "decimal"
+	case AttrTypeUUID:
+		str = "uuid"
+	case AttrTypeArray:
+		str = "array"
+	case AttrTypeObject:
+		str = "object"
+	case AttrTypeJSON:
+		str = "json"
 	default:
 		str = ""
 	}
@@ -508,6 +743,9 @@ func GetAttrTypeString(t int, nullable bool) string {
 // specified int (see constants).
 //
 // If nullable is true, the returned value is a nil pointer.
+//
+// Since the zero values of arrays and objects depend on their element type and
+// fields, nil is returned for them, like for JSON values.
 func GetZeroValue(t int, nullable bool) interface{} {
 	switch t {
 	case AttrTypeString:
@@ -594,6 +832,30 @@ func GetZeroValue(t int, nullable bool) interface{} {
 		}
 
 		return []byte{}
+	case AttrTypeFloat32:
+		if nullable {
+			return (*float32)(nil)
+		}
+
+		return float32(0)
+	case AttrTypeFloat64:
+		if nullable {
+			return (*float64)(nil)
+		}
+
+		return float64(0)
+	case AttrTypeDecimal:
+		if nullable {
+			return (*Decimal)(nil)
+		}
+
+		return Decimal{}
+	case AttrTypeUUID:
+		if nullable {
+			return (*UUID)(nil)
+		}
+
+		return UUID{}
 	default:
 		return nil
 	}
@@ -123,6 +123,10 @@ func TestAttrUnmarshalToType(t *testing.T) {
 		vuint32 = uint32(32)
 		vuint64 = uint64(64)
 		vbool   = true
+		vf32    = float32(3.5)
+		vf64    = 6.25
+		vdec    = MustParseDecimal("12345678901234567890.123456789")
+		vuuid   = MustParseUUID("123e4567-e89b-12d3-a456-426614174000")
 	)
 
 	tests := []struct {
@@ -156,6 +160,14 @@ func TestAttrUnmarshalToType(t *testing.T) {
 		{val: &vbool},           // *bool
 		{val: &time.Time{}},     // *time
 		{val: &[]byte{1, 2, 3}}, // *[]byte
+		{val: vf32},             // float32
+		{val: vf64},             // float64
+		{val: vdec},             // decimal
+		{val: vuuid},            // uuid
+		{val: &vf32},            // *float32
+		{val: &vf64},            // *float64
+		{val: &vdec},            // *decimal
+		{val: &vuuid},           // *uuid
 	}
 
 	attr := Attr{}
@@ -190,6 +202,43 @@ func TestAttrUnmarshalToType(t *testing.T) {
 	// assert.Error(err)
 	// assert.Nil(val)
 
+	// Invalid numbers and UUIDs
+	for _, typ := range []int{AttrTypeFloat32, AttrTypeDecimal, AttrTypeUUID} {
+		attr.Type = typ
+		val, err = attr.UnmarshalToType([]byte(`"abc"`))
+		assert.Error(err)
+		assert.Nil(val)
+	}
+
+	// Out of range integers
+	outOfRange := map[int]string{
+		AttrTypeInt8:   "128",
+		AttrTypeInt16:  "-32769",
+		AttrTypeInt32:  "2147483648",
+		AttrTypeInt64:  "9223372036854775808",
+		AttrTypeUint8:  "256",
+		AttrTypeUint16: "65536",
+		AttrTypeUint32: "4294967296",
+	}
+
+	for typ, num := range outOfRange {
+		attr.Type = typ
+		val, err = attr.UnmarshalToType([]byte(num))
+		assert.Error(err, num)
+		assert.Nil(val)
+	}
+
+	// Integers at the limits of their range
+	attr.Type, attr.Nullable = AttrTypeInt8, false
+	val, err = attr.UnmarshalToType([]byte("-128"))
+	assert.NoError(err)
+	assert.Equal(int8(-128), val)
+
+	attr.Type, attr.Nullable = AttrTypeInt32, true
+	val, err = attr.UnmarshalToType([]byte("2147483647"))
+	assert.NoError(err)
+	assert.Equal(ptr(int32(2147483647)), val)
+
 	// Invalid attribute type
 	attr.Type = AttrTypeInvalid
 	val, err = attr.UnmarshalToType([]byte("invalid"))
@@ -199,6 +248,87 @@ func TestAttrUnmarshalToType(t *testing.T) {
 	assert.Nil(val)
 }
 
+func TestAttrUnmarshalToTypeStructured(t *testing.T) {
+	assert := assert.New(t)
+
+	// Array
+	attr := Attr{Name: "tags", Type: AttrTypeArray, Elem: AttrTypeString}
+
+	val, err := attr.UnmarshalToType([]byte(`["a","b"]`))
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b"}, val)
+
+	val, err = attr.UnmarshalToType([]byte(`[]`))
+	assert.NoError(err)
+	assert.Equal([]string{}, val)
+
+	for _, data := range []string{`null`, `"a"`, `[1]`} {
+		_, err = attr.UnmarshalToType([]byte(data))
+		assert.Error(err)
+	}
+
+	attr.Elem = AttrTypeUUID
+	attr.Nullable = true
+	id := MustParseUUID("123e4567-e89b-12d3-a456-426614174000")
+
+	val, err = attr.UnmarshalToType([]byte(`["` + id.String() + `"]`))
+	assert.NoError(err)
+	assert.Equal(&[]UUID{id}, val)
+
+	val, err = attr.UnmarshalToType([]byte(`null`))
+	assert.NoError(err)
+	assert.Equal((*[]UUID)(nil), val)
+
+	// Object
+	attr = Attr{
+		Name: "address",
+		Type: AttrTypeObject,
+		Fields: map[string]Attr{
+			"street": {Name: "street", Type: AttrTypeString},
+			"number": {Name: "number", Type: AttrTypeInt, Nullable: true},
+			"geo": {Name: "geo", Type: AttrTypeObject, Fields: map[string]Attr{
+				"lat": {Name: "lat", Type: AttrTypeFloat64},
+				"lng": {Name: "lng", Type: AttrTypeFloat64},
+			}},
+		},
+	}
+
+	val, err = attr.UnmarshalToType([]byte(`{"street":"Main","geo":{"lat":1.5}}`))
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{
+		"street": "Main",
+		"number": (*int)(nil),
+		"geo":    map[string]interface{}{"lat": 1.5, "lng": float64(0)},
+	}, val)
+
+	for _, data := range []string{`null`, `[]`, `{"unknown":1}`, `{"geo":{"lat":"a"}}`} {
+		_, err = attr.UnmarshalToType([]byte(data))
+		assert.Error(err)
+	}
+
+	attr.Nullable = true
+	val, err = attr.UnmarshalToType([]byte(`null`))
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}(nil), val)
+
+	// JSON
+	attr = Attr{Name: "settings", Type: AttrTypeJSON}
+
+	val, err = attr.UnmarshalToType([]byte(`{"a":[1,"b",null],"c":12345678901234567890}`))
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{
+		"a": []interface{}{json.Number("1"), "b", nil},
+		"c": json.Number("12345678901234567890"),
+	}, val)
+
+	val, err = attr.UnmarshalToType([]byte(`null`))
+	assert.NoError(err)
+	assert.Nil(val)
+
+	_, err = attr.UnmarshalToType([]byte(`{`))
+	assert.Error(err)
+}
+
 func TestRelInvert(t *testing.T) {
 	assert := assert.New(t)
 
@@ -408,6 +538,42 @@ func TestGetAttrType(t *testing.T) {
 	assert.Equal(AttrTypeBytes, typ)
 	assert.True(nullable)
 
+	typ, nullable = GetAttrType("float32")
+	assert.Equal(AttrTypeFloat32, typ)
+	assert.False(nullable)
+
+	typ, nullable = GetAttrType("*float64")
+	assert.Equal(AttrTypeFloat64, typ)
+	assert.True(nullable)
+
+	typ, nullable = GetAttrType("jsonapi.Decimal")
+	assert.Equal(AttrTypeDecimal, typ)
+	assert.False(nullable)
+
+	typ, nullable = GetAttrType("*decimal")
+	assert.Equal(AttrTypeDecimal, typ)
+	assert.True(nullable)
+
+	typ, nullable = GetAttrType("*jsonapi.UUID")
+	assert.Equal(AttrTypeUUID, typ)
+	assert.True(nullable)
+
+	typ, nullable = GetAttrType("uuid")
+	assert.Equal(AttrTypeUUID, typ)
+	assert.False(nullable)
+
+	typ, nullable = GetAttrType("array")
+	assert.Equal(AttrTypeArray, typ)
+	assert.False(nullable)
+
+	typ, nullable = GetAttrType("*object")
+	assert.Equal(AttrTypeObject, typ)
+	assert.True(nullable)
+
+	typ, nullable = GetAttrType("json")
+	assert.Equal(AttrTypeJSON, typ)
+	assert.False(nullable)
+
 	typ, nullable = GetAttrType("invalid")
 	assert.Equal(AttrTypeInvalid, typ)
 	assert.False(nullable)
@@ -448,6 +614,13 @@ func TestGetAttrTypeString(t *testing.T) {
 	assert.Equal("*bool", GetAttrTypeString(AttrTypeBool, true))
 	assert.Equal("*time", GetAttrTypeString(AttrTypeTime, true))
 	assert.Equal("*bytes", GetAttrTypeString(AttrTypeBytes, true))
+	assert.Equal("float32", GetAttrTypeString(AttrTypeFloat32, false))
+	assert.Equal("*float64", GetAttrTypeString(AttrTypeFloat64, true))
+	assert.Equal("decimal", GetAttrTypeString(AttrTypeDecimal, false))
+	assert.Equal("*uuid", GetAttrTypeString(AttrTypeUUID, true))
+	assert.Equal("array", GetAttrTypeString(AttrTypeArray, false))
+	assert.Equal("*object", GetAttrTypeString(AttrTypeObject, true))
+	assert.Equal("json", GetAttrTypeString(AttrTypeJSON, false))
 	assert.Equal("", GetAttrTypeString(AttrTypeInvalid, false))
 	assert.Equal("", GetAttrTypeString(999, false))
 }
@@ -483,6 +656,14 @@ func TestGetZeroValue(t *testing.T) {
 	assert.Equal(nilptr("bool"), GetZeroValue(AttrTypeBool, true))
 	assert.Equal(nilptr("time.Time"), GetZeroValue(AttrTypeTime, true))
 	assert.Equal(nilptr("[]byte"), GetZeroValue(AttrTypeBytes, true))
+	assert.Equal(float32(0), GetZeroValue(AttrTypeFloat32, false))
+	assert.Equal(float64(0), GetZeroValue(AttrTypeFloat64, false))
+	assert.Equal(Decimal{}, GetZeroValue(AttrTypeDecimal, false))
+	assert.Equal(UUID{}, GetZeroValue(AttrTypeUUID, false))
+	assert.Equal(nilptr("float32"), GetZeroValue(AttrTypeFloat32, true))
+	assert.Equal(nilptr("float64"), GetZeroValue(AttrTypeFloat64, true))
+	assert.Equal(nilptr("decimal"), GetZeroValue(AttrTypeDecimal, true))
+	assert.Equal(nilptr("uuid"), GetZeroValue(AttrTypeUUID, true))
 	assert.Equal(nil, GetZeroValue(AttrTypeInvalid, false))
 	assert.Equal(nil, GetZeroValue(999, false))
 }
@@ -123,7 +123,7 @@ type URL struct {
 // are escaped.
 //
 // The URL is normalized, so it always returns exactly the same string given the
-// same URL.
+// same URL. The filter is written in the style given by Params.FilterStyle.
 func (u *URL) String() string {
 	// Path
 	path := "/"
@@ -158,7 +158,13 @@ func (u *URL) String() string {
 	}
 
 	// Filter
-	if u.Params.Filter != nil {
+	if u.Params.FilterLabel != "" {
+		// The label is kept instead of the filter it stands for.
+		urlParams = append(urlParams, "filter="+u.Params.FilterLabel)
+	} else if params, ok := filterParams(u.Params.Filter); ok &&
+		u.Params.FilterStyle == FilterStyleBrackets {
+		urlParams = append(urlParams, params...)
+	} else if u.Params.Filter != nil {
 		mf, err := json.Marshal(u.Params.Filter)
 		if err != nil {
 			// This should not happen since Filter should be validated
@@ -168,8 +174,6 @@ func (u *URL) String() string {
 
 		param := "filter=" + string(mf)
 		urlParams = append(urlParams, param)
-	} else if u.Params.FilterLabel != "" {
-		urlParams = append(urlParams, "filter="+u.Params.FilterLabel)
 	}
 
 	// Pagination
@@ -181,6 +185,20 @@ func (u *URL) String() string {
 			)
 		}
 
+		if u.Params.CursorPagination {
+			if u.Params.PageAfter != "" {
+				urlParams = append(urlParams, "page%5Bafter%5D="+u.Params.PageAfter)
+			}
+
+			if u.Params.PageBefore != "" {
+				urlParams = append(urlParams, "page%5Bbefore%5D="+u.Params.PageBefore)
+			}
+
+			if u.Params.PageAfter == "" && u.Params.PageBefore == "" {
+				urlParams = append(urlParams, "page%5Bcursor%5D=")
+			}
+		}
+
 		if u.Params.PageSize != 0 {
 			urlParams = append(
 				urlParams,
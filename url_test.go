@@ -1,8 +1,11 @@
 package jsonapi_test
 
 import (
+	"encoding/json"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/mfcochauxlaberge/jsonapi"
 
@@ -77,6 +80,13 @@ func TestParseURL(t *testing.T) {
 				?page[size]=invalid
 			`,
 			expectedError: true,
+		}, {
+			name: "invalid page cursor",
+			url: `
+				/mocktypes1
+				?page[after]=invalid
+			`,
+			expectedError: true,
 		}, {
 			name: "full url for collection",
 			url:  `https://api.example.com/mocktypes1`,
@@ -232,6 +242,10 @@ func TestParseParams(t *testing.T) {
 	mockTypes1 := schema.GetType("mocktypes1")
 	mockTypes2 := schema.GetType("mocktypes2")
 
+	_ = schema.AddFilterLabel("mocktypes1", "label", FilterTemplate{
+		Filter: &Filter{Field: "str", Op: "=", Val: "a"},
+	})
+
 	tests := []struct {
 		name           string
 		url            string
@@ -399,6 +413,7 @@ func TestParseParams(t *testing.T) {
 				Rels:         map[string][]Rel{},
 				RelData:      map[string][]string{},
 				FilterLabel:  "label",
+				Filter:       &Filter{Field: "str", Op: "=", Val: "a"},
 				SortingRules: []string{},
 				Include:      [][]Rel{},
 			},
@@ -445,6 +460,89 @@ func TestParseParams(t *testing.T) {
 				Include: [][]Rel{},
 			},
 			expectedError: false,
+		}, {
+			name: "sorting rules with paths and null ordering",
+			url: `
+				/mocktypes2
+				?sort=to-one-from-many.str,-strptr:nulls-last,to-one-from-one.uint8:nulls-first
+			`,
+			colType: "mocktypes2",
+			expectedParams: Params{
+				Fields: map[string][]string{
+					"mocktypes2": mockTypes2.Fields(),
+				},
+				Attrs:   map[string][]Attr{},
+				Rels:    map[string][]Rel{},
+				RelData: map[string][]string{},
+				SortingRules: []string{
+					"to-one-from-many.str", "-strptr:nulls-last",
+					"to-one-from-one.uint8:nulls-first",
+					"boolptr", "int16ptr", "int32ptr", "int64ptr", "int8ptr", "intptr",
+					"timeptr", "uint16ptr", "uint32ptr", "uint64ptr", "uint8ptr",
+					"uintptr", "id",
+				},
+				Include: [][]Rel{},
+			},
+			expectedError: false,
+		}, {
+			name: "unknown field in sorting rules",
+			url: `
+				/mocktypes1
+				?sort=str,unknown
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
+		}, {
+			name: "to-many relationship in sorting rules",
+			url: `
+				/mocktypes1
+				?sort=to-many-from-many.strptr
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
+		}, {
+			name: "null ordering of non-nullable attribute in sorting rules",
+			url: `
+				/mocktypes1
+				?sort=str:nulls-first
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
+		}, {
+			name: "unknown null ordering in sorting rules",
+			url: `
+				/mocktypes2
+				?sort=strptr:nulls-middle
+			`,
+			colType:       "mocktypes2",
+			expectedError: true,
+		}, {
+			name: "path in sorting rules with page cursor",
+			url: `
+				/mocktypes1
+				?sort=to-one-from-many.strptr
+				&page[cursor]=
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
+		}, {
+			name: "path in sorting rules with page after",
+			url: `
+				/mocktypes1
+				?sort=to-one-from-many.strptr
+				&page[after]=abc
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
+		}, {
+			name: "path in sorting rules with page before",
+			url: `
+				/mocktypes1
+				?sort=-int,to-one-from-many.strptr
+				&page[before]=abc
+			`,
+			colType:       "mocktypes1",
+			expectedError: true,
 		}, {
 			name: "fields with duplicates",
 			url: `
@@ -526,12 +624,36 @@ func TestParseParams(t *testing.T) {
 			assert.Equal(test.expectedParams, *params, test.name)
 		}
 	}
+
+	// Cursors cannot hold the values of related resources.
+	_, err := NewURLFromRaw(schema, "/mocktypes1?sort=to-one-from-many.strptr&page[after]=")
+	assert.Equal(NewErrSortRuleWithCursors("to-one-from-many.strptr"), err)
+
+	// Only scalar values can be sorted.
+	_ = schema.AddAttr("mocktypes3", Attr{Name: "tags", Type: AttrTypeArray, Elem: AttrTypeString})
+	_ = schema.AddAttr("mocktypes3", Attr{Name: "data", Type: AttrTypeJSON})
+	_ = schema.AddAttr("mocktypes3", Attr{
+		Name:   "geo",
+		Type:   AttrTypeObject,
+		Fields: map[string]Attr{"city": {Name: "city", Type: AttrTypeString}},
+	})
+
+	for _, rule := range []string{"tags", "-data", "geo"} {
+		_, err = NewURLFromRaw(schema, "/mocktypes3?sort="+rule)
+		assert.Equal(NewErrUnknownFieldInSortParameter(rule), err)
+	}
+
+	_, err = NewURLFromRaw(schema, "/mocktypes3?sort=geo.city")
+	assert.NoError(err)
 }
 
 func TestURLEscaping(t *testing.T) {
 	assert := assert.New(t)
 
 	schema := newMockSchema()
+	_ = schema.AddFilterLabel("mocktypes1", "a_label", FilterTemplate{
+		Filter: &Filter{Field: "bool", Op: "=", Val: true},
+	})
 
 	tests := []struct {
 		url       string
@@ -565,6 +687,28 @@ func TestURLEscaping(t *testing.T) {
 					uint32,uint64,uint8,id
 			`,
 		},
+		{
+			url: `
+				/mocktypes3
+				?fields[mocktypes3]=attr1
+				&page[cursor]=
+				&page[size]=10
+			`,
+			escaped: `
+				/mocktypes3
+				?fields%5Bmocktypes3%5D=attr1
+				&page%5Bcursor%5D=
+				&page%5Bsize%5D=10
+				&sort=attr1%2Cattr2%2Cid
+			`,
+			unescaped: `
+				/mocktypes3
+				?fields[mocktypes3]=attr1
+				&page[cursor]=
+				&page[size]=10
+				&sort=attr1,attr2,id
+			`,
+		},
 	}
 
 	for _, test := range tests {
@@ -590,7 +734,7 @@ func TestURLString(t *testing.T) {
 		?include=
 			to-many-from-one.to-one-from-many.to-one.to-many-from-many%2C
 			to-one-from-one.to-many-from-many
-		&sort=to-many%2Cstr,%2C%2C-bool
+		&sort=str,%2C%2C-bool
 		&page[number]=3
 		&sort=uint8
 		&include=
@@ -626,3 +770,251 @@ func TestURLString(t *testing.T) {
 		_ = url.String()
 	})
 }
+
+func TestParseParamsFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	now, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+
+	tests := []struct {
+		name           string
+		colType        string
+		filter         string
+		expectedFilter *Filter
+		expectedError  error
+	}{
+		{
+			name:           "string",
+			colType:        "mocktypes1",
+			filter:         `{"f":"str","o":"=","v":"abc"}`,
+			expectedFilter: &Filter{Field: "str", Op: "=", Val: "abc"},
+		}, {
+			name:           "int64",
+			colType:        "mocktypes1",
+			filter:         `{"f":"int64","o":">","v":3}`,
+			expectedFilter: &Filter{Field: "int64", Op: ">", Val: int64(3)},
+		}, {
+			name:           "time",
+			colType:        "mocktypes1",
+			filter:         `{"f":"time","o":"<","v":"2020-01-02T03:04:05Z"}`,
+			expectedFilter: &Filter{Field: "time", Op: "<", Val: now},
+		}, {
+			name:           "nullable",
+			colType:        "mocktypes2",
+			filter:         `{"f":"strptr","o":"=","v":"abc"}`,
+			expectedFilter: &Filter{Field: "strptr", Op: "=", Val: ptr("abc")},
+		}, {
+			name:           "null",
+			colType:        "mocktypes2",
+			filter:         `{"f":"uint8ptr","o":"!=","v":null}`,
+			expectedFilter: &Filter{Field: "uint8ptr", Op: "!=", Val: nilptr("uint8")},
+		}, {
+			name:    "and & or",
+			colType: "mocktypes1",
+			filter: `{"o":"or","v":[
+				{"f":"uint16","o":"<=","v":10},
+				{"o":"and","v":[
+					{"f":"bool","o":"=","v":true},
+					{"f":"str","o":"in","v":["a","b"]}
+				]}
+			]}`,
+			expectedFilter: &Filter{Op: "or", Val: []*Filter{
+				{Field: "uint16", Op: "<=", Val: uint16(10)},
+				{Op: "and", Val: []*Filter{
+					{Field: "bool", Op: "=", Val: true},
+					{Field: "str", Op: "in", Val: []string{"a", "b"}},
+				}},
+			}},
+		}, {
+			name:           "relationships",
+			colType:        "mocktypes1",
+			filter:         `{"f":"to-many","o":"=","v":["id1","id2"]}`,
+			expectedFilter: &Filter{Field: "to-many", Op: "=", Val: []string{"id1", "id2"}},
+		}, {
+			name:           "has",
+			colType:        "mocktypes1",
+			filter:         `{"f":"to-many","o":"has","v":"id1"}`,
+			expectedFilter: &Filter{Field: "to-many", Op: "has", Val: "id1"},
+		}, {
+			name:          "unknown field",
+			colType:       "mocktypes1",
+			filter:        `{"o":"and","v":[{"f":"unknown","o":"=","v":1}]}`,
+			expectedError: NewErrUnknownFieldInFilterParameter("unknown"),
+		}, {
+			name:          "unknown operator",
+			colType:       "mocktypes1",
+			filter:        `{"f":"str","o":"~","v":"abc"}`,
+			expectedError: NewErrUnknownOperatorInFilterParameter("~"),
+		}, {
+			name:          "operator not allowed for type",
+			colType:       "mocktypes1",
+			filter:        `{"f":"bool","o":"<","v":true}`,
+			expectedError: NewErrUnknownOperatorInFilterParameter("<"),
+		}, {
+			name:          "invalid int",
+			colType:       "mocktypes1",
+			filter:        `{"f":"int8","o":"=","v":1000}`,
+			expectedError: NewErrInvalidValueInFilterParameter("1000", "int8"),
+		}, {
+			name:          "invalid time",
+			colType:       "mocktypes1",
+			filter:        `{"f":"time","o":"=","v":"yesterday"}`,
+			expectedError: NewErrInvalidValueInFilterParameter(`"yesterday"`, "time"),
+		}, {
+			name:          "null not allowed",
+			colType:       "mocktypes1",
+			filter:        `{"f":"str","o":"=","v":null}`,
+			expectedError: NewErrInvalidValueInFilterParameter("null", "string"),
+		}, {
+			name:          "invalid ids",
+			colType:       "mocktypes1",
+			filter:        `{"f":"to-one","o":"in","v":["a",1]}`,
+			expectedError: NewErrInvalidValueInFilterParameter(`["a",1]`, "[]string"),
+		},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse("/" + test.colType + "?filter=" + url.QueryEscape(test.filter))
+		assert.NoError(err, test.name)
+
+		su, err := NewSimpleURL(u)
+		assert.NoError(err, test.name)
+
+		params, err := NewParams(schema, su, test.colType)
+
+		if test.expectedError != nil {
+			assert.Equal(test.expectedError, err, test.name)
+		} else {
+			assert.NoError(err, test.name)
+			assert.Equal(test.expectedFilter, params.Filter, test.name)
+		}
+	}
+}
+
+func TestURLFilterBracketSyntax(t *testing.T) {
+	assert := assert.New(t)
+
+	schema := newMockSchema()
+	now, _ := time.Parse(time.RFC3339, "2020-01-02T03:04:05Z")
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedFilter *Filter
+		expectedParams string
+	}{
+		{
+			name:           "equality",
+			url:            `/mocktypes1?filter[str]=abc`,
+			expectedFilter: &Filter{Field: "str", Op: "=", Val: "abc"},
+			expectedParams: `filter[str]=abc`,
+		}, {
+			name: "many filters",
+			url:  `/mocktypes1?filter[str][eq]=abc&filter[int][gt]=3&filter[bool]=true`,
+			expectedFilter: &Filter{Op: "and", Val: []*Filter{
+				{Field: "bool", Op: "=", Val: true},
+				{Field: "int", Op: ">", Val: 3},
+				{Field: "str", Op: "=", Val: "abc"},
+			}},
+			expectedParams: `filter[bool]=true&filter[int][gt]=3&filter[str]=abc`,
+		}, {
+			name:           "time",
+			url:            `/mocktypes1?filter[time][lte]=2020-01-02T03:04:05Z`,
+			expectedFilter: &Filter{Field: "time", Op: "<=", Val: now},
+			expectedParams: `filter[time][lte]=2020-01-02T03:04:05Z`,
+		}, {
+			name:           "in",
+			url:            `/mocktypes1?filter[str][in]=a,b`,
+			expectedFilter: &Filter{Field: "str", Op: "in", Val: []string{"a", "b"}},
+			expectedParams: `filter[str][in]=a,b`,
+		}, {
+			name:           "not in",
+			url:            `/mocktypes1?filter[int][not-in]=1,2`,
+			expectedFilter: &Filter{Field: "int", Op: "not-in", Val: []interface{}{1, 2}},
+			expectedParams: `filter[int][not-in]=1,2`,
+		}, {
+			name:           "in (nullable)",
+			url:            `/mocktypes2?filter[intptr][in]=3`,
+			expectedFilter: &Filter{Field: "intptr", Op: "in", Val: []interface{}{ptr(3)}},
+			expectedParams: `filter[intptr][in]=3`,
+		}, {
+			name:           "collation",
+			url:            `/mocktypes1?filter[str][contains][ci]=a%20b`,
+			expectedFilter: &Filter{Field: "str", Op: "contains", Val: "a b", Col: "ci"},
+			expectedParams: `filter[str][contains][ci]=a b`,
+		}, {
+			name:           "nullable",
+			url:            `/mocktypes2?filter[uint8ptr][ne]=5`,
+			expectedFilter: &Filter{Field: "uint8ptr", Op: "!=", Val: ptr(uint8(5))},
+			expectedParams: `filter[uint8ptr][ne]=5`,
+		}, {
+			name:           "null check",
+			url:            `/mocktypes2?filter[strptr][is-null]=ignored`,
+			expectedFilter: &Filter{Field: "strptr", Op: "is-null"},
+			expectedParams: `filter[strptr][is-null]=`,
+		}, {
+			name:           "set operator",
+			url:            `/mocktypes1?filter[to-many][has-any]=a,b`,
+			expectedFilter: &Filter{Field: "to-many", Op: "has-any", Val: []string{"a", "b"}},
+			expectedParams: `filter[to-many][has-any]=a,b`,
+		}, {
+			name:           "negation",
+			url:            `/mocktypes1?filter={"o":"not","v":{"f":"str","o":"=","v":"a"}}`,
+			expectedFilter: &Filter{Op: "not", Val: &Filter{Field: "str", Op: "=", Val: "a"}},
+			expectedParams: `filter={"f":"","o":"not",` +
+				`"v":{"f":"str","o":"=","v":"a","c":""},"c":""}`,
+		}, {
+			name:           "relationship",
+			url:            `/mocktypes1?filter[to-one]=abc`,
+			expectedFilter: &Filter{Field: "to-one", Op: "=", Val: "abc"},
+			expectedParams: `filter[to-one]=abc`,
+		}, {
+			name: "json",
+			url:  `/mocktypes1?filter={"o":"or","v":[{"f":"str","o":"=","v":"a"}]}`,
+			expectedFilter: &Filter{Op: "or", Val: []*Filter{
+				{Field: "str", Op: "=", Val: "a"},
+			}},
+			expectedParams: `filter={"f":"","o":"or",` +
+				`"v":[{"f":"str","o":"=","v":"a","c":""}],"c":""}`,
+		}, {
+			name:           "null value",
+			url:            `/mocktypes2?filter={"f":"uint8ptr","o":"=","v":null}`,
+			expectedFilter: &Filter{Field: "uint8ptr", Op: "=", Val: (*uint8)(nil)},
+			expectedParams: `filter={"f":"uint8ptr","o":"=","v":null,"c":""}`,
+		},
+	}
+
+	for _, test := range tests {
+		url, err := NewURLFromRaw(schema, test.url)
+		assert.NoError(err, test.name)
+		assert.Equal(test.expectedFilter, url.Params.Filter, test.name)
+
+		// The filter is written as JSON by default.
+		mf, _ := json.Marshal(url.Params.Filter)
+		assert.Contains(url.UnescapedString(), "&filter="+string(mf), test.name)
+
+		url.Params.FilterStyle = FilterStyleBrackets
+		params := []string{}
+
+		for _, param := range strings.Split(url.UnescapedString(), "&") {
+			if i := strings.Index(param, "filter"); i != -1 {
+				params = append(params, param[i:])
+			}
+		}
+
+		assert.Equal(test.expectedParams, strings.Join(params, "&"), test.name)
+
+		// The canonical URL is parsed back into the same filter.
+		url2, err := NewURLFromRaw(schema, url.String())
+		assert.NoError(err, test.name)
+		assert.Equal(test.expectedFilter, url2.Params.Filter, test.name)
+	}
+
+	// Escaping
+	url, err := NewURLFromRaw(schema, `/mocktypes1?filter[str][like]=a%25%26b`)
+	assert.NoError(err)
+
+	url.Params.FilterStyle = FilterStyleBrackets
+	assert.Contains(url.String(), "&filter%5Bstr%5D%5Blike%5D=a%25%26b&")
+}
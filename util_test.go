@@ -3,6 +3,8 @@ package jsonapi_test
 import (
 	"strings"
 	"time"
+
+	"github.com/mfcochauxlaberge/jsonapi"
 )
 
 func makeOneLineNoSpaces(str string) string {
@@ -47,6 +49,16 @@ func ptr(v interface{}) interface{} {
 	// []byte
 	case []byte:
 		return &c
+	// Floats
+	case float32:
+		return &c
+	case float64:
+		return &c
+	// Decimal and UUID
+	case jsonapi.Decimal:
+		return &c
+	case jsonapi.UUID:
+		return &c
 	default:
 		return nil
 	}
@@ -101,6 +113,20 @@ func nilptr(t string) interface{} {
 	case "[]byte":
 		var p *[]byte
 		return p
+	// Floats
+	case "float32":
+		var p *float32
+		return p
+	case "float64":
+		var p *float64
+		return p
+	// Decimal and UUID
+	case "decimal":
+		var p *jsonapi.Decimal
+		return p
+	case "uuid":
+		var p *jsonapi.UUID
+		return p
 	default:
 		return nil
 	}
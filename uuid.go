@@ -0,0 +1,85 @@
+package jsonapi
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+// A UUID is a universally unique identifier as defined by RFC 4122.
+//
+// It is represented as a string in the canonical form, like
+// "123e4567-e89b-12d3-a456-426614174000". The zero value is the nil UUID.
+type UUID [16]byte
+
+// ParseUUID parses s, a UUID in the canonical form, and returns it. Both lower
+// and upper case hexadecimal digits are accepted.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	errInvalid := errors.New("jsonapi: invalid UUID " + strconv.Quote(s))
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errInvalid
+	}
+
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+
+	_, err := hex.Decode(u[:], []byte(digits))
+	if err != nil {
+		return UUID{}, errInvalid
+	}
+
+	return u, nil
+}
+
+// MustParseUUID is like ParseUUID but panics on error.
+func MustParseUUID(s string) UUID {
+	u, err := ParseUUID(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+// String returns the canonical form of the UUID in lower case.
+func (u UUID) String() string {
+	buf := make([]byte, 36)
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:36], u[10:16])
+
+	return string(buf)
+}
+
+// MarshalText returns the canonical form of the UUID.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses a UUID in the canonical form.
+func (u *UUID) UnmarshalText(text []byte) error {
+	uuid, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+
+	*u = uuid
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface. The UUID is passed to the
+// database in its canonical form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
@@ -0,0 +1,54 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUID(t *testing.T) {
+	assert := assert.New(t)
+
+	// Parsing
+	u, err := ParseUUID("123E4567-e89b-12d3-a456-426614174000")
+	assert.NoError(err)
+	assert.Equal("123e4567-e89b-12d3-a456-426614174000", u.String())
+	assert.Equal(byte(0x12), u[0])
+
+	invalid := []string{
+		"",
+		"123e4567e89b12d3a456426614174000",
+		"123e4567-e89b-12d3-a456-42661417400",
+		"123e4567-e89b-12d3-a456_426614174000",
+		"123e4567-e89b-12d3-a456-42661417400g",
+	}
+
+	for _, s := range invalid {
+		_, err := ParseUUID(s)
+		assert.Error(err)
+	}
+
+	assert.Panics(func() { _ = MustParseUUID("abc") })
+
+	// Zero value
+	assert.Equal("00000000-0000-0000-0000-000000000000", UUID{}.String())
+
+	// JSON
+	payload, err := json.Marshal(u)
+	assert.NoError(err)
+	assert.Equal(`"123e4567-e89b-12d3-a456-426614174000"`, string(payload))
+
+	var u2 UUID
+
+	assert.NoError(json.Unmarshal(payload, &u2))
+	assert.Equal(u, u2)
+	assert.Error(json.Unmarshal([]byte(`"abc"`), &u2))
+
+	// Database value
+	v, err := u.Value()
+	assert.NoError(err)
+	assert.Equal("123e4567-e89b-12d3-a456-426614174000", v)
+}
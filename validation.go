@@ -0,0 +1,261 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AttrRules holds the validation rules of an attribute.
+//
+// Required means that the value cannot be null, an empty string, or an empty
+// slice of bytes. Min and Max bound the value of numeric attributes. MinLen and
+// MaxLen bound the number of characters of strings, the number of bytes of byte
+// slices, and the number of elements of arrays. Pattern is a regular expression
+// that strings must match. Enum lists the allowed values, in their string
+// representation.
+//
+// Except for Required, the rules are not checked when the value is null.
+//
+// The rules can be defined with the validate tag of a struct field given to
+// BuildType or Wrap. The rules are separated by commas, like in the following
+// example:
+//
+//	Name string `json:"name" api:"attr" validate:"required,maxlen=20,pattern=^[a-z]+$"`
+//
+// The valid rules are required, min, max, minlen, maxlen, enum, and pattern.
+// The values of enum are separated by "|". Since a pattern may contain commas,
+// the pattern rule must be the last one.
+type AttrRules struct {
+	Required bool
+	Min      *float64
+	Max      *float64
+	MinLen   *int
+	MaxLen   *int
+	Pattern  *regexp.Regexp
+	Enum     []string
+}
+
+// Validate checks the attributes of res against the validation rules of the
+// type and returns an error for each rule that is not satisfied.
+//
+// Only the attributes of res that are defined by t are checked, so a partial
+// resource from UnmarshalPartialResource is checked only on the attributes it
+// holds. The errors are sorted by attribute name.
+func (t *Type) Validate(res Resource) []Error {
+	names := make([]string, 0, len(res.Attrs()))
+
+	for name := range res.Attrs() {
+		if attr, ok := t.Attrs[name]; ok && attr.Rules != nil {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	errs := []Error{}
+
+	for _, name := range names {
+		attr := t.Attrs[name]
+
+		if detail := attr.Rules.check(res.Get(name)); detail != "" {
+			errs = append(errs, NewErrInvalidAttrValue(name, detail))
+		}
+	}
+
+	return errs
+}
+
+// Validate checks res against the validation rules of its type in the schema.
+//
+// See Type.Validate for more information.
+func (s *Schema) Validate(res Resource) []Error {
+	typ := s.GetType(res.GetType().Name)
+	if typ.Name == "" {
+		return []Error{NewErrBadRequest(
+			"Unknown type",
+			fmt.Sprintf("%q is not a known type.", res.GetType().Name),
+		)}
+	}
+
+	return typ.Validate(res)
+}
+
+// check returns the detail of an error if v does not satisfy the rules, or an
+// empty string otherwise.
+func (r *AttrRules) check(v interface{}) string {
+	val := reflect.ValueOf(v)
+
+	if v == nil || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		if r.Required {
+			return "The value is required."
+		}
+
+		return ""
+	}
+
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	// Lengths are counted in characters for strings, in bytes
+	// for slices of bytes, and in elements for arrays.
+	length, unit := -1, ""
+
+	switch val.Kind() {
+	case reflect.String:
+		length, unit = utf8.RuneCountInString(val.String()), "characters"
+	case reflect.Slice:
+		length, unit = val.Len(), "elements"
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			unit = "bytes"
+		}
+	}
+
+	if r.Required && length == 0 {
+		return "The value is required."
+	}
+
+	if num, ok := toFloat(val); ok {
+		if r.Min != nil && num < *r.Min {
+			return "The value must be greater than or equal to " + formatFloat(*r.Min) + "."
+		}
+
+		if r.Max != nil && num > *r.Max {
+			return "The value must be less than or equal to " + formatFloat(*r.Max) + "."
+		}
+	}
+
+	if length >= 0 {
+		if r.MinLen != nil && length < *r.MinLen {
+			return fmt.Sprintf("The value must be at least %d %s long.", *r.MinLen, unit)
+		}
+
+		if r.MaxLen != nil && length > *r.MaxLen {
+			return fmt.Sprintf("The value must be at most %d %s long.", *r.MaxLen, unit)
+		}
+	}
+
+	if r.Pattern != nil && val.Kind() == reflect.String {
+		if !r.Pattern.MatchString(val.String()) {
+			return fmt.Sprintf("The value must match the pattern %q.", r.Pattern.String())
+		}
+	}
+
+	if len(r.Enum) > 0 {
+		str := fmt.Sprint(val.Interface())
+		if t, ok := val.Interface().(time.Time); ok {
+			str = t.Format(time.RFC3339Nano)
+		}
+
+		if !containsID(r.Enum, str) {
+			quoted := make([]string, len(r.Enum))
+			for i := range r.Enum {
+				quoted[i] = strconv.Quote(r.Enum[i])
+			}
+
+			return "The value must be one of " + strings.Join(quoted, ", ") + "."
+		}
+	}
+
+	return ""
+}
+
+// parseAttrRules parses the content of a validate tag and returns the rules
+// it defines, or nil if the tag is empty.
+func parseAttrRules(tag string) (*AttrRules, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	rules := &AttrRules{}
+
+	for tag != "" {
+		var rule string
+
+		if strings.HasPrefix(tag, "pattern=") {
+			rule, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+
+		name, val := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, val = rule[:i], rule[i+1:]
+		}
+
+		var err error
+
+		switch name {
+		case "required":
+			rules.Required = true
+		case "min":
+			rules.Min, err = parseFloatRule(val)
+		case "max":
+			rules.Max, err = parseFloatRule(val)
+		case "minlen":
+			rules.MinLen, err = parseIntRule(val)
+		case "maxlen":
+			rules.MaxLen, err = parseIntRule(val)
+		case "pattern":
+			rules.Pattern, err = regexp.Compile(val)
+		case "enum":
+			rules.Enum = strings.Split(val, "|")
+		default:
+			err = fmt.Errorf("unknown rule %q", name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate tag: %s", err)
+		}
+	}
+
+	return rules, nil
+}
+
+func parseFloatRule(val string) (*float64, error) {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func parseIntRule(val string) (*int, error) {
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// toFloat returns the value of val as a float64 if it is a number.
+func toFloat(val reflect.Value) (float64, bool) {
+	if d, ok := val.Interface().(Decimal); ok {
+		return d.Float64(), true
+	}
+
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+
+	return 0, false
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,201 @@
+package jsonapi_test
+
+import (
+	"regexp"
+	"testing"
+
+	. "github.com/mfcochauxlaberge/jsonapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedType struct {
+	ID     string  `json:"id" api:"validated"`
+	Name   string  `json:"name" api:"attr" validate:"required,maxlen=5,pattern=^[a-z,]+$"`
+	Age    int     `json:"age" api:"attr" validate:"min=0,max=150"`
+	Color  *string `json:"color" api:"attr" validate:"enum=red|green"`
+	Code   []byte  `json:"code" api:"attr" validate:"minlen=2"`
+	Remark string  `json:"remark" api:"attr"`
+}
+
+func TestValidate(t *testing.T) {
+	typ, err := BuildType(validatedType{})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		values         map[string]interface{}
+		expectedErrors map[string]string
+	}{
+		{
+			name: "valid",
+			values: map[string]interface{}{
+				"name":  "ab,c",
+				"age":   30,
+				"color": ptr("red"),
+				"code":  []byte{1, 2},
+			},
+		}, {
+			name: "null values",
+			values: map[string]interface{}{
+				"name":  "abc",
+				"color": (*string)(nil),
+				"code":  []byte{1, 2},
+			},
+		}, {
+			name: "required",
+			values: map[string]interface{}{
+				"code": []byte{1, 2},
+			},
+			expectedErrors: map[string]string{
+				"name": "The value is required.",
+			},
+		}, {
+			name: "all rules",
+			values: map[string]interface{}{
+				"name":  "abcdéf",
+				"age":   151,
+				"color": ptr("blue"),
+				"code":  []byte{1},
+			},
+			expectedErrors: map[string]string{
+				"name":  "The value must be at most 5 characters long.",
+				"age":   "The value must be less than or equal to 150.",
+				"color": `The value must be one of "red", "green".`,
+				"code":  "The value must be at least 2 bytes long.",
+			},
+		}, {
+			name: "pattern and min",
+			values: map[string]interface{}{
+				"name": "ABC",
+				"age":  -1,
+				"code": []byte{1, 2},
+			},
+			expectedErrors: map[string]string{
+				"name": `The value must match the pattern "^[a-z,]+$".`,
+				"age":  "The value must be greater than or equal to 0.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			res := typ.New()
+			for field, v := range test.values {
+				res.Set(field, v)
+			}
+
+			errs := typ.Validate(res)
+			assert.Len(errs, len(test.expectedErrors))
+
+			for i, e := range errs {
+				attr := e.Meta["attribute"].(string)
+
+				assert.Equal("422", e.Status)
+				assert.Equal(test.expectedErrors[attr], e.Detail)
+				assert.Equal("/data/attributes/"+attr, e.Source["pointer"])
+
+				if i > 0 {
+					assert.Less(errs[i-1].Meta["attribute"], attr)
+				}
+			}
+		})
+	}
+
+	// Schema
+	schema := &Schema{Types: []Type{typ}}
+
+	res := typ.New()
+	assert.Len(t, schema.Validate(res), 2)
+
+	other := &SoftResource{Type: &Type{Name: "unknown"}}
+	assert.Equal(t, "400", schema.Validate(other)[0].Status)
+}
+
+func TestAttrRulesFromTags(t *testing.T) {
+	assert := assert.New(t)
+
+	typ, err := BuildType(validatedType{})
+	assert.NoError(err)
+
+	min, max, minLen, maxLen := 0.0, 150.0, 2, 5
+
+	assert.Equal(&AttrRules{
+		Required: true,
+		MaxLen:   &maxLen,
+		Pattern:  regexp.MustCompile("^[a-z,]+$"),
+	}, typ.Attrs["name"].Rules)
+	assert.Equal(&AttrRules{Min: &min, Max: &max}, typ.Attrs["age"].Rules)
+	assert.Equal(&AttrRules{Enum: []string{"red", "green"}}, typ.Attrs["color"].Rules)
+	assert.Equal(&AttrRules{MinLen: &minLen}, typ.Attrs["code"].Rules)
+	assert.Nil(typ.Attrs["remark"].Rules)
+
+	// Wrapper
+	assert.Equal(typ.Attrs, Wrap(&validatedType{}).Attrs())
+
+	// Invalid tags
+	tags := []interface{}{
+		struct {
+			ID  string `json:"id" api:"invalid"`
+			Str string `json:"str" api:"attr" validate:"unknown"`
+		}{},
+		struct {
+			ID  string `json:"id" api:"invalid"`
+			Int int    `json:"int" api:"attr" validate:"min=abc"`
+		}{},
+		struct {
+			ID  string `json:"id" api:"invalid"`
+			Str string `json:"str" api:"attr" validate:"maxlen=1.5"`
+		}{},
+		struct {
+			ID  string `json:"id" api:"invalid"`
+			Str string `json:"str" api:"attr" validate:"pattern=["`
+		}{},
+	}
+
+	for _, v := range tags {
+		_, err := BuildType(v)
+		assert.Error(err)
+	}
+}
+
+func TestUnmarshalResourceValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	typ, _ := BuildType(validatedType{})
+	schema := &Schema{Types: []Type{typ}}
+
+	payload := `{
+		"type": "validated",
+		"attributes": {"name": "abc", "age": 200, "code": "AQI="}
+	}`
+
+	_, err := UnmarshalResource([]byte(payload), schema)
+	assert.Equal(NewErrInvalidAttrValue(
+		"age",
+		"The value must be less than or equal to 150.",
+	), err)
+
+	// Partial resources are only checked on the attributes they hold.
+	payload = `{
+		"type": "validated",
+		"attributes": {"age": 20}
+	}`
+
+	_, err = UnmarshalResource([]byte(payload), schema)
+	assert.Error(err)
+
+	res, err := UnmarshalPartialResource([]byte(payload), schema)
+	assert.NoError(err)
+	assert.Equal(20, res.Get("age"))
+
+	payload = `{
+		"type": "validated",
+		"attributes": {"name": ""}
+	}`
+
+	_, err = UnmarshalPartialResource([]byte(payload), schema)
+	assert.Equal(NewErrInvalidAttrValue("name", "The value is required."), err)
+}
@@ -20,6 +20,11 @@ type Wrapper struct {
 	attrs map[string]Attr
 	rels  map[string]Rel
 	meta  Meta
+
+	// Local IDs
+	lid     string
+	relLIDs map[string][]string
+	linkage map[string]Identifiers
 }
 
 // Wrap wraps v (a struct or a pointer to a struct) and returns a Wrapper that
@@ -74,12 +79,10 @@ func Wrap(v interface{}) *Wrapper {
 		apiTag := fs.Tag.Get("api")
 
 		if apiTag == "attr" {
-			typ, null := GetAttrType(fs.Type.String())
-			w.attrs[jsonTag] = Attr{
-				Name:     jsonTag,
-				Type:     typ,
-				Nullable: null,
-			}
+			attr, _ := goTypeToAttr(fs.Type)
+			attr.Name = jsonTag
+			attr.Rules, _ = parseAttrRules(fs.Tag.Get("validate"))
+			w.attrs[jsonTag] = attr
 		}
 	}
 
@@ -224,6 +227,11 @@ func (w *Wrapper) Copy() Resource {
 		}
 	}
 
+	// Local IDs
+	nw.lid = w.lid
+	nw.relLIDs = copyRelLIDs(w.relLIDs)
+	nw.linkage = copyRelLinkage(w.linkage)
+
 	return nw
 }
 
@@ -237,6 +245,55 @@ func (w *Wrapper) SetMeta(m Meta) {
 	w.meta = m
 }
 
+// LID returns the local ID of the resource.
+func (w *Wrapper) LID() string {
+	return w.lid
+}
+
+// SetLID sets the local ID of the resource.
+func (w *Wrapper) SetLID(lid string) {
+	w.lid = lid
+}
+
+// RelLIDs returns the local IDs referenced by the relationship named after key.
+func (w *Wrapper) RelLIDs(key string) []string {
+	return w.relLIDs[key]
+}
+
+// SetRelLIDs sets the local IDs referenced by the relationship named after key.
+func (w *Wrapper) SetRelLIDs(key string, lids []string) {
+	if len(lids) == 0 {
+		delete(w.relLIDs, key)
+		return
+	}
+
+	if w.relLIDs == nil {
+		w.relLIDs = map[string][]string{}
+	}
+
+	w.relLIDs[key] = lids
+}
+
+// relLinkage returns the identifiers of the to-many relationship named after
+// key in the order they were unmarshaled in, if they mix IDs and local IDs.
+func (w *Wrapper) relLinkage(key string) Identifiers {
+	return w.linkage[key]
+}
+
+// setRelLinkage sets the identifiers returned by relLinkage.
+func (w *Wrapper) setRelLinkage(key string, idens Identifiers) {
+	if len(idens) == 0 {
+		delete(w.linkage, key)
+		return
+	}
+
+	if w.linkage == nil {
+		w.linkage = map[string]Identifiers{}
+	}
+
+	w.linkage[key] = idens
+}
+
 // Private methods
 
 func (w *Wrapper) getField(key string) interface{} {
@@ -281,6 +338,16 @@ func (w *Wrapper) setField(key string, v interface{}) {
 				return
 			}
 
+			// Arrays, objects, and JSON values can come in another form,
+			// like a map for a struct, so they are converted.
+			if attr := w.attrs[key]; !isScalarAttrType(attr.Type) {
+				nv := reflect.New(field.Type())
+				if convertValue(v, nv.Interface()) == nil {
+					field.Set(nv.Elem())
+					return
+				}
+			}
+
 			panic(fmt.Sprintf(
 				"got value of type %q, not %q",
 				field.Type(), val.Type(),
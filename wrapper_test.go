@@ -1,6 +1,7 @@
 package jsonapi_test
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -295,6 +296,103 @@ func TestWrapperSet(t *testing.T) {
 	}
 }
 
+func TestWrapperStructuredAttrs(t *testing.T) {
+	assert := assert.New(t)
+
+	type address struct {
+		Street string    `json:"street"`
+		Tags   []string  `json:"tags"`
+		Since  time.Time `json:"since"`
+		secret string
+	}
+
+	type structured struct {
+		ID       string                 `json:"id" api:"structured"`
+		Tags     []string               `json:"tags" api:"attr"`
+		Scores   *[]float64             `json:"scores" api:"attr"`
+		Address  address                `json:"address" api:"attr"`
+		Previous *address               `json:"previous" api:"attr"`
+		Settings map[string]interface{} `json:"settings" api:"attr"`
+		Raw      json.RawMessage        `json:"raw" api:"attr"`
+	}
+
+	wrap := Wrap(&structured{ID: "id1"})
+	addrFields := map[string]Attr{
+		"street": {Name: "street", Type: AttrTypeString},
+		"tags":   {Name: "tags", Type: AttrTypeArray, Elem: AttrTypeString},
+		"since":  {Name: "since", Type: AttrTypeTime},
+	}
+
+	assert.Equal(map[string]Attr{
+		"tags": {Name: "tags", Type: AttrTypeArray, Elem: AttrTypeString},
+		"scores": {
+			Name:     "scores",
+			Type:     AttrTypeArray,
+			Nullable: true,
+			Elem:     AttrTypeFloat64,
+		},
+		"address": {Name: "address", Type: AttrTypeObject, Fields: addrFields},
+		"previous": {
+			Name:     "previous",
+			Type:     AttrTypeObject,
+			Nullable: true,
+			Fields:   addrFields,
+		},
+		"settings": {Name: "settings", Type: AttrTypeJSON},
+		"raw":      {Name: "raw", Type: AttrTypeJSON},
+	}, wrap.Attrs())
+
+	// Values from a payload are converted.
+	typ := wrap.GetType()
+	since := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	values := map[string]string{
+		"tags":     `["a","b"]`,
+		"scores":   `[1.5]`,
+		"address":  `{"street":"Main","since":"2020-01-02T03:04:05Z"}`,
+		"previous": `null`,
+		"settings": `{"theme":"dark","size":12}`,
+		"raw":      `[true]`,
+	}
+
+	soft := &SoftResource{Type: &typ}
+	soft.SetID("id1")
+
+	for name, data := range values {
+		val, err := typ.Attrs[name].UnmarshalToType([]byte(data))
+		assert.NoError(err)
+		wrap.Set(name, val)
+		soft.Set(name, val)
+	}
+
+	assert.Equal([]string{"a", "b"}, wrap.Get("tags"))
+	assert.Equal(&[]float64{1.5}, wrap.Get("scores"))
+	assert.Equal(address{Street: "Main", Tags: []string{}, Since: since}, wrap.Get("address"))
+	assert.Nil(wrap.Get("previous"))
+	assert.Equal(map[string]interface{}{
+		"theme": "dark",
+		"size":  json.Number("12"),
+	}, wrap.Get("settings"))
+	assert.Equal(json.RawMessage(`[true]`), wrap.Get("raw"))
+
+	assert.True(EqualStrict(wrap, soft))
+	assert.True(Equal(soft.Copy(), wrap.Copy()))
+
+	// Both resources are marshaled the same way.
+	fields := typ.Fields()
+	assert.JSONEq(
+		string(MarshalResource(wrap, "", fields, nil)),
+		string(MarshalResource(soft.Copy(), "", fields, nil)),
+	)
+
+	soft.Set("tags", []string{"b", "a"})
+	assert.False(Equal(wrap, soft))
+
+	// Values that cannot be converted
+	assert.Panics(func() {
+		wrap.Set("address", "not an object")
+	})
+}
+
 func TestWrapperGetAndSetErrors(t *testing.T) {
 	assert := assert.New(t)
 